@@ -186,7 +186,7 @@ func Execute() error {
 	// since we have a custom error handler in main.go
 	Command.SilenceErrors = true
 
-	return Command.Execute()
+	return Command.ExecuteContext(utils.SignalCtx)
 }
 
 func init() {
@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/briandowns/spinner v1.12.0
+	github.com/chzyer/readline v0.0.0-20180603132655-2972be24d48e
 	github.com/client9/misspell v0.3.4
 	github.com/fatih/color v1.10.0
 	github.com/git-chglog/git-chglog v0.10.0
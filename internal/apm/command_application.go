@@ -8,6 +8,7 @@ import (
 	"github.com/newrelic/newrelic-client-go/pkg/entities"
 
 	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/entities/resolver"
 	"github.com/newrelic/newrelic-cli/internal/output"
 	"github.com/newrelic/newrelic-cli/internal/utils"
 )
@@ -90,16 +91,23 @@ var cmdAppGet = &cobra.Command{
 	Short: "Get a New Relic application",
 	Long: `Get a New Relic application
 
-The get command performs a query for an APM application by GUID.
+The get command performs a query for an APM application by GUID.  The application
+can also be given by name, with a --guid such as name=checkout, which must match
+exactly one APM application.
 `,
-	Example: "newrelic apm application get --guid <entityGUID>",
+	Example: `newrelic apm application get --guid <entityGUID>
+newrelic apm application get --guid name=checkout`,
 	Run: func(cmd *cobra.Command, args []string) {
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
 			var results *entities.EntityInterface
 			var err error
 
 			if appGUID != "" {
-				results, err = nrClient.Entities.GetEntity(entities.EntityGUID(appGUID))
+				var guid string
+				guid, err = resolveAppGUID(nrClient, appGUID)
+				utils.LogIfFatal(err)
+
+				results, err = nrClient.Entities.GetEntity(entities.EntityGUID(guid))
 				utils.LogIfFatal(err)
 			} else {
 				utils.LogIfError(cmd.Help())
@@ -111,6 +119,25 @@ The get command performs a query for an APM application by GUID.
 	},
 }
 
+// resolveAppGUID returns the GUID of the application given by its GUID or
+// by name.  Names are looked up among APM applications unless told otherwise.
+func resolveAppGUID(nrClient *newrelic.NewRelic, ref string) (string, error) {
+	sel, err := resolver.ParseReference(ref)
+	if err != nil || sel == nil {
+		return ref, err
+	}
+
+	if sel.Domain == "" {
+		sel.Domain = "APM"
+	}
+
+	if sel.Type == "" {
+		sel.Type = "APPLICATION"
+	}
+
+	return resolver.New(&nrClient.NerdGraph).ResolveSelector(utils.SignalCtx, *sel)
+}
+
 func init() {
 	Command.AddCommand(cmdApp)
 
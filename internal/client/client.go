@@ -3,9 +3,9 @@ package client
 import (
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/newrelic/newrelic-client-go/newrelic"
+	nrConfig "github.com/newrelic/newrelic-client-go/pkg/config"
 
 	"github.com/newrelic/newrelic-cli/internal/config"
 	"github.com/newrelic/newrelic-cli/internal/credentials"
@@ -18,24 +18,71 @@ var (
 
 // CreateNRClient initializes the New Relic client.
 func CreateNRClient(cfg *config.Config, creds *credentials.Credentials) (*newrelic.NewRelic, *credentials.Profile, error) {
+	// Create the New Relic Client
+	defProfile := creds.Default()
+
+	cfgOpts, err := clientOptions(cfg, defProfile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nrClient, err := newrelic.New(cfgOpts...)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create New Relic client with error: %s", err)
+	}
+
+	return nrClient, defProfile, nil
+}
+
+// NerdGraphEndpoint holds what is needed to send requests to the NerdGraph API
+// directly, for commands that need the raw GraphQL response.
+type NerdGraphEndpoint struct {
+	URL       string
+	Region    string
+	APIKey    string
+	UserAgent string
+}
+
+// CreateNerdGraphEndpoint resolves the NerdGraph endpoint for the default profile,
+// honoring the same region and endpoint overrides as CreateNRClient.
+func CreateNerdGraphEndpoint(cfg *config.Config, creds *credentials.Credentials) (*NerdGraphEndpoint, error) {
+	cfgOpts, err := clientOptions(cfg, creds.Default())
+	if err != nil {
+		return nil, err
+	}
+
+	clientCfg := nrConfig.New()
+	for _, fn := range cfgOpts {
+		if err := fn(&clientCfg); err != nil {
+			return nil, fmt.Errorf("unable to create New Relic client with error: %s", err)
+		}
+	}
+
+	return &NerdGraphEndpoint{
+		URL:       clientCfg.Region().NerdGraphURL(),
+		Region:    clientCfg.Region().String(),
+		APIKey:    clientCfg.PersonalAPIKey,
+		UserAgent: clientCfg.UserAgent,
+	}, nil
+}
+
+// clientOptions returns the client options for the given profile.
+func clientOptions(cfg *config.Config, profile *credentials.Profile) ([]newrelic.ConfigOption, error) {
 	var (
-		err               error
 		apiKey            string
 		insightsInsertKey string
 		regionValue       string
 	)
 
-	// Create the New Relic Client
-	defProfile := creds.Default()
-
-	if defProfile != nil {
-		apiKey = defProfile.APIKey
-		insightsInsertKey = defProfile.InsightsInsertKey
-		regionValue = defProfile.Region
+	if profile != nil {
+		apiKey = profile.APIKey
+		insightsInsertKey = profile.InsightsInsertKey
+		regionValue = profile.Region
 	}
 
 	if apiKey == "" {
-		return nil, nil, errors.New("an API key is required, set a default profile or use the NEW_RELIC_API_KEY environment variable")
+		return nil, errors.New("an API key is required, set a default profile or use the NEW_RELIC_API_KEY environment variable")
 	}
 
 	userAgent := fmt.Sprintf("newrelic-cli/%s (https://github.com/newrelic/newrelic-cli)", version)
@@ -49,16 +96,54 @@ func CreateNRClient(cfg *config.Config, creds *credentials.Credentials) (*newrel
 		newrelic.ConfigServiceName(serviceName),
 	}
 
-	nerdGraphURLOverride := os.Getenv("NEW_RELIC_NERDGRAPH_URL")
-	if nerdGraphURLOverride != "" {
-		cfgOpts = append(cfgOpts, newrelic.ConfigNerdGraphBaseURL(nerdGraphURLOverride))
+	return append(cfgOpts, endpointOptions(cfg)...), nil
+}
+
+// CreateRecipeServiceClient initializes a New Relic client whose NerdGraph
+// endpoint points at the recipe service.  Unless a recipe service URL override
+// has been configured, this is equivalent to CreateNRClient.
+func CreateRecipeServiceClient(cfg *config.Config, creds *credentials.Credentials) (*newrelic.NewRelic, error) {
+	if cfg.RecipeServiceURL == "" {
+		nrClient, _, err := CreateNRClient(cfg, creds)
+		return nrClient, err
 	}
 
-	nrClient, err := newrelic.New(cfgOpts...)
+	recipeCfg := *cfg
+	recipeCfg.NerdGraphURL = cfg.RecipeServiceURL
 
-	if err != nil {
-		return nil, nil, fmt.Errorf("unable to create New Relic client with error: %s", err)
+	nrClient, _, err := CreateNRClient(&recipeCfg, creds)
+
+	return nrClient, err
+}
+
+// endpointOptions returns the client options required to honor any service
+// endpoint overrides present in the CLI configuration.
+func endpointOptions(cfg *config.Config) []newrelic.ConfigOption {
+	var opts []newrelic.ConfigOption
+
+	if cfg.NerdGraphURL != "" {
+		opts = append(opts, newrelic.ConfigNerdGraphBaseURL(cfg.NerdGraphURL))
 	}
 
-	return nrClient, defProfile, nil
+	if cfg.RestURL != "" {
+		opts = append(opts, newrelic.ConfigBaseURL(cfg.RestURL))
+	}
+
+	// The client does not expose options for the Insights and Log API
+	// endpoints, so the region is updated directly.
+	if cfg.InsightsInsertURL != "" {
+		opts = append(opts, func(c *nrConfig.Config) error {
+			c.Region().SetInsightsBaseURL(cfg.InsightsInsertURL)
+			return nil
+		})
+	}
+
+	if cfg.LogsURL != "" {
+		opts = append(opts, func(c *nrConfig.Config) error {
+			c.Region().SetLogsBaseURL(cfg.LogsURL)
+			return nil
+		})
+	}
+
+	return opts
 }
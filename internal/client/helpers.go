@@ -47,3 +47,72 @@ func WithClientAndProfileFrom(configDir string, f func(c *newrelic.NewRelic, p *
 		})
 	})
 }
+
+// WithClientAndNamedProfile returns a New Relic client initialized from the named
+// profile, or from the default profile when no name is given.
+func WithClientAndNamedProfile(profileName string, f func(c *newrelic.NewRelic, p *credentials.Profile)) {
+	WithClientAndNamedProfileFrom(config.DefaultConfigDirectory, profileName, f)
+}
+
+// WithClientAndNamedProfileFrom returns a New Relic client initialized from the named
+// profile in the specified location, or from the default profile when no name is given.
+func WithClientAndNamedProfileFrom(configDir string, profileName string, f func(c *newrelic.NewRelic, p *credentials.Profile)) {
+	config.WithConfigFrom(configDir, func(cfg *config.Config) {
+		credentials.WithCredentialsFrom(configDir, func(creds *credentials.Credentials) {
+			if profileName != "" {
+				if _, ok := creds.Profiles[profileName]; !ok {
+					log.Fatalf("profile %s does not exist, see newrelic profiles --help", profileName)
+				}
+
+				creds.DefaultProfile = profileName
+			}
+
+			nrClient, profile, err := CreateNRClient(cfg, creds)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			f(nrClient, profile)
+		})
+	})
+}
+
+// WithNerdGraphEndpoint returns the NerdGraph endpoint for the default profile.
+func WithNerdGraphEndpoint(f func(e *NerdGraphEndpoint)) {
+	WithNerdGraphEndpointFrom(config.DefaultConfigDirectory, f)
+}
+
+// WithNerdGraphEndpointFrom returns the NerdGraph endpoint for the default profile,
+// initialized from configuration in the specified location.
+func WithNerdGraphEndpointFrom(configDir string, f func(e *NerdGraphEndpoint)) {
+	config.WithConfigFrom(configDir, func(cfg *config.Config) {
+		credentials.WithCredentialsFrom(configDir, func(creds *credentials.Credentials) {
+			endpoint, err := CreateNerdGraphEndpoint(cfg, creds)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			f(endpoint)
+		})
+	})
+}
+
+// WithRecipeServiceClient returns a New Relic client configured to query the recipe service.
+func WithRecipeServiceClient(f func(c *newrelic.NewRelic)) {
+	WithRecipeServiceClientFrom(config.DefaultConfigDirectory, f)
+}
+
+// WithRecipeServiceClientFrom returns a New Relic client configured to query the recipe service,
+// initialized from configuration in the specified location.
+func WithRecipeServiceClientFrom(configDir string, f func(c *newrelic.NewRelic)) {
+	config.WithConfigFrom(configDir, func(cfg *config.Config) {
+		credentials.WithCredentialsFrom(configDir, func(creds *credentials.Credentials) {
+			nrClient, err := CreateRecipeServiceClient(cfg, creds)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			f(nrClient)
+		})
+	})
+}
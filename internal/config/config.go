@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"reflect"
 	"strings"
@@ -42,6 +43,11 @@ type Config struct {
 	PluginDir          string  `mapstructure:"pluginDir"`          // PluginDir is the directory where plugins will be installed
 	SendUsageData      Ternary `mapstructure:"sendUsageData"`      // SendUsageData enables sending usage statistics to New Relic
 	PreReleaseFeatures Ternary `mapstructure:"preReleaseFeatures"` // PreReleaseFeatures enables display on features within the CLI that are announced but not generally available to customers
+	NerdGraphURL       string  `mapstructure:"nerdGraphURL"`       // NerdGraphURL overrides the NerdGraph API endpoint for the configured region
+	RestURL            string  `mapstructure:"restURL"`            // RestURL overrides the REST API v2 base URL for the configured region
+	InsightsInsertURL  string  `mapstructure:"insightsInsertURL"`  // InsightsInsertURL overrides the Insights custom event insert base URL
+	LogsURL            string  `mapstructure:"logsURL"`            // LogsURL overrides the Log API ingest endpoint
+	RecipeServiceURL   string  `mapstructure:"recipeServiceURL"`   // RecipeServiceURL overrides the GraphQL endpoint used to look up install recipes
 
 	configDir string
 }
@@ -301,9 +307,27 @@ func (c *Config) applyOverrides() error {
 		c.PreReleaseFeatures = Ternary(v)
 	}
 
+	for envVar, field := range c.endpointOverrides() {
+		if v := os.Getenv(envVar); v != "" {
+			*field = v
+		}
+	}
+
 	return nil
 }
 
+// endpointOverrides maps the environment variables that can be used to
+// override a service endpoint to the config field they replace.
+func (c *Config) endpointOverrides() map[string]*string {
+	return map[string]*string{
+		"NEW_RELIC_NERDGRAPH_URL":       &c.NerdGraphURL,
+		"NEW_RELIC_REST_URL":            &c.RestURL,
+		"NEW_RELIC_INSIGHTS_INSERT_URL": &c.InsightsInsertURL,
+		"NEW_RELIC_LOGS_URL":            &c.LogsURL,
+		"NEW_RELIC_RECIPE_SERVICE_URL":  &c.RecipeServiceURL,
+	}
+}
+
 func (c *Config) setDefaults() error {
 	log.Debug("setting config default")
 
@@ -331,6 +355,13 @@ func (c *Config) validate() error {
 			if err != nil {
 				return fmt.Errorf("invalid value for '%s': %s", v.Name, err)
 			}
+		case "nerdgraphurl", "resturl", "insightsinserturl", "logsurl", "recipeserviceurl":
+			if s := v.Value.(string); s != "" {
+				u, err := url.Parse(s)
+				if err != nil || u.Scheme == "" || u.Host == "" {
+					return fmt.Errorf("\"%s\" is not a valid %s value; Please use an absolute URL such as https://api.newrelic.com/graphql", s, v.Name)
+				}
+			}
 		}
 
 		return nil
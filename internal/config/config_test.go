@@ -0,0 +1,50 @@
+// +build unit
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigApplyOverridesEndpoints(t *testing.T) {
+	envVars := map[string]string{
+		"NEW_RELIC_NERDGRAPH_URL":       "http://localhost:3000/graphql",
+		"NEW_RELIC_REST_URL":            "http://localhost:3000/v2",
+		"NEW_RELIC_INSIGHTS_INSERT_URL": "http://localhost:3000/v1",
+		"NEW_RELIC_LOGS_URL":            "http://localhost:3000/log/v1",
+		"NEW_RELIC_RECIPE_SERVICE_URL":  "http://localhost:3001/graphql",
+	}
+
+	for k, v := range envVars {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	c := Config{RestURL: "https://staging-api.newrelic.com/v2"}
+
+	err := c.applyOverrides()
+	assert.NoError(t, err)
+
+	assert.Equal(t, envVars["NEW_RELIC_NERDGRAPH_URL"], c.NerdGraphURL)
+	assert.Equal(t, envVars["NEW_RELIC_REST_URL"], c.RestURL)
+	assert.Equal(t, envVars["NEW_RELIC_INSIGHTS_INSERT_URL"], c.InsightsInsertURL)
+	assert.Equal(t, envVars["NEW_RELIC_LOGS_URL"], c.LogsURL)
+	assert.Equal(t, envVars["NEW_RELIC_RECIPE_SERVICE_URL"], c.RecipeServiceURL)
+}
+
+func TestConfigValidateEndpoints(t *testing.T) {
+	c := Config{
+		LogLevel:           DefaultLogLevel,
+		SendUsageData:      TernaryValues.Unknown,
+		PreReleaseFeatures: TernaryValues.Unknown,
+		NerdGraphURL:       "http://localhost:3000/graphql",
+	}
+
+	assert.NoError(t, c.validate())
+
+	c.RestURL = "localhost:3000"
+	assert.Error(t, c.validate())
+}
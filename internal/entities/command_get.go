@@ -0,0 +1,186 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/pipe"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+// maxEntitiesPerRequest is the most entities NerdGraph returns by GUID in a
+// single request.
+const maxEntitiesPerRequest = 25
+
+var (
+	entityGoldenMetrics bool
+	entitySince         string
+)
+
+var cmdEntityGet = &cobra.Command{
+	Use:   "get",
+	Short: "Get the details of New Relic entities",
+	Long: `Get the details of New Relic entities
+
+The get command returns the details of an entity, including the fields specific to
+its type, its tags, alert severity, reporting status and relationships to other
+entities.  With --goldenMetrics, the entity's golden metrics are also evaluated
+over the time range given by --since.
+
+GUIDs can also be piped from the output of another command, such as 'entity
+search', in which case the details of every entity are returned.
+`,
+	Example: `newrelic entity get --guid <entityGUID>
+newrelic entity get --guid <entityGUID> --goldenMetrics --since "1 hour ago"
+newrelic entity search --domain APM --tag team:payments | newrelic entity get`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			guids := []entities.EntityGUID{entities.EntityGUID(entityGUID)}
+
+			if value, ok := pipe.Get("guid"); ok {
+				guids = pipedGUIDs(value)
+			}
+
+			found, err := getEntities(&nrClient.Entities, guids)
+			utils.LogIfFatal(err)
+
+			results := make([]interface{}, len(found))
+
+			for i, e := range found {
+				results[i] = e
+
+				if !entityGoldenMetrics {
+					continue
+				}
+
+				metrics, err := getGoldenMetrics(utils.SignalCtx, &nrClient.NerdGraph, &nrClient.Nrdb, e, entitySince)
+				utils.LogIfFatal(err)
+
+				results[i], err = withGoldenMetrics(e, metrics)
+				utils.LogIfFatal(err)
+			}
+
+			if len(results) == 1 {
+				utils.LogIfFatal(output.Print(results[0]))
+				return
+			}
+
+			utils.LogIfFatal(output.Print(results))
+		})
+	},
+}
+
+// entityGetter is the part of the entities client used to fetch entities.
+type entityGetter interface {
+	GetEntity(entities.EntityGUID) (*entities.EntityInterface, error)
+	GetEntities([]entities.EntityGUID) (*[]entities.EntityInterface, error)
+}
+
+// getEntities fetches entities by GUID, in batches of the most NerdGraph
+// allows.  GUIDs that match no entity are logged and skipped, unless none
+// match.
+func getEntities(getter entityGetter, guids []entities.EntityGUID) ([]entities.EntityInterface, error) {
+	if len(guids) == 1 {
+		e, err := getter.GetEntity(guids[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if e == nil || *e == nil {
+			return nil, fmt.Errorf("no entity found with GUID %s", guids[0])
+		}
+
+		return []entities.EntityInterface{*e}, nil
+	}
+
+	var found []entities.EntityInterface
+
+	for start := 0; start < len(guids); start += maxEntitiesPerRequest {
+		end := start + maxEntitiesPerRequest
+		if end > len(guids) {
+			end = len(guids)
+		}
+
+		batch, err := getter.GetEntities(guids[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		if batch != nil {
+			found = append(found, *batch...)
+		}
+	}
+
+	returned := map[entities.EntityGUID]bool{}
+	for _, e := range found {
+		returned[e.GetGUID()] = true
+	}
+
+	for _, guid := range guids {
+		if !returned[guid] {
+			log.Warnf("no entity found with GUID %s", guid)
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no entities found with the %d GUIDs given", len(guids))
+	}
+
+	return found, nil
+}
+
+// pipedGUIDs returns the distinct GUIDs read from stdin.
+func pipedGUIDs(values []string) []entities.EntityGUID {
+	var guids []entities.EntityGUID
+
+	seen := map[string]bool{}
+
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+
+		seen[v] = true
+		guids = append(guids, entities.EntityGUID(v))
+	}
+
+	return guids
+}
+
+// withGoldenMetrics adds the golden metrics to the entity's fields.
+func withGoldenMetrics(entity entities.EntityInterface, metrics []goldenMetric) (map[string]interface{}, error) {
+	content, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+
+	fields["goldenMetrics"] = metrics
+
+	return fields, nil
+}
+
+func init() {
+	Command.AddCommand(cmdEntityGet)
+
+	pipe.GetInput([]string{"guid"})
+
+	if !pipe.Exists("guid") {
+		cmdEntityGet.Flags().StringVarP(&entityGUID, "guid", "g", "", "the GUID of the entity to get")
+		utils.LogIfError(cmdEntityGet.MarkFlagRequired("guid"))
+	}
+
+	cmdEntityGet.Flags().BoolVar(&entityGoldenMetrics, "goldenMetrics", false, "evaluate the entity's golden metrics")
+	cmdEntityGet.Flags().StringVar(&entitySince, "since", "30 minutes ago", "the time range to evaluate golden metrics over, as a NRQL SINCE clause")
+}
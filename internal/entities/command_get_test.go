@@ -0,0 +1,100 @@
+// +build unit
+
+package entities
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+func TestEntityGet(t *testing.T) {
+	assert.Equal(t, "get", cmdEntityGet.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdEntityGet)
+	testcobra.CheckCobraRequiredFlags(t, cmdEntityGet, []string{"guid"})
+}
+
+// fakeEntityGetter returns the entities it holds, recording the size of each
+// batch requested.
+type fakeEntityGetter struct {
+	entities map[entities.EntityGUID]entities.EntityInterface
+	batches  []int
+}
+
+func (f *fakeEntityGetter) GetEntity(guid entities.EntityGUID) (*entities.EntityInterface, error) {
+	e, ok := f.entities[guid]
+	if !ok {
+		var missing entities.EntityInterface
+		return &missing, nil
+	}
+
+	return &e, nil
+}
+
+func (f *fakeEntityGetter) GetEntities(guids []entities.EntityGUID) (*[]entities.EntityInterface, error) {
+	f.batches = append(f.batches, len(guids))
+
+	found := []entities.EntityInterface{}
+	for _, guid := range guids {
+		if e, ok := f.entities[guid]; ok {
+			found = append(found, e)
+		}
+	}
+
+	return &found, nil
+}
+
+func newFakeEntityGetter(count int) *fakeEntityGetter {
+	f := &fakeEntityGetter{entities: map[entities.EntityGUID]entities.EntityInterface{}}
+
+	for i := 0; i < count; i++ {
+		guid := entities.EntityGUID(fmt.Sprintf("G%d", i))
+		f.entities[guid] = &entities.GenericEntity{GUID: guid, Name: fmt.Sprintf("entity %d", i)}
+	}
+
+	return f
+}
+
+func TestGetEntities(t *testing.T) {
+	getter := newFakeEntityGetter(30)
+
+	found, err := getEntities(getter, []entities.EntityGUID{"G1"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "entity 1", found[0].GetName())
+
+	_, err = getEntities(getter, []entities.EntityGUID{"missing"})
+	assert.EqualError(t, err, "no entity found with GUID missing")
+
+	var guids []entities.EntityGUID
+	for i := 0; i < 32; i++ {
+		guids = append(guids, entities.EntityGUID(fmt.Sprintf("G%d", i)))
+	}
+
+	found, err = getEntities(getter, guids)
+	require.NoError(t, err)
+	assert.Len(t, found, 30)
+	assert.Equal(t, []int{25, 7}, getter.batches)
+
+	_, err = getEntities(getter, []entities.EntityGUID{"x", "y"})
+	assert.EqualError(t, err, "no entities found with the 2 GUIDs given")
+}
+
+func TestPipedGUIDs(t *testing.T) {
+	assert.Equal(t, []entities.EntityGUID{"a", "b"}, pipedGUIDs([]string{"a", "", "b", "a"}))
+}
+
+func TestWithGoldenMetrics(t *testing.T) {
+	fields, err := withGoldenMetrics(&entities.GenericEntity{GUID: "G1", Name: "web"}, []goldenMetric{{Name: "throughput", Value: 12.5}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "G1", fields["guid"])
+	assert.Equal(t, "web", fields["name"])
+	assert.Len(t, fields["goldenMetrics"], 1)
+}
@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+)
+
+var (
+	graphDepth       int
+	graphFormat      string
+	graphConcurrency int
+)
+
+var cmdEntityGraph = &cobra.Command{
+	Use:   "graph",
+	Short: "Export the relationships of a New Relic entity as a graph",
+	Long: `Export the relationships of a New Relic entity as a graph
+
+The graph command walks the relationships of an entity breadth first, up to the
+depth given with --depth, and renders the entities and their relationships as a
+Graphviz DOT digraph or a Mermaid flowchart, as chosen with --graph-format.  With
+--graph-format data, the entities and relationships are printed in the output
+format given with --format instead.  Each entity is visited once, so cycles in the
+relationships are safe to follow, and the relationships of at most --concurrency
+entities are fetched at a time.
+
+When the relationships of an entity other than the starting one cannot be fetched,
+a warning is logged and the entity is marked as unexpanded, drawn with a dashed
+outline in DOT, while the rest of the graph is still walked.
+`,
+	Example: `newrelic entity graph --guid <entityGUID> --depth 2 > service-map.dot
+newrelic entity graph --guid <entityGUID> --graph-format mermaid > service-map.mmd
+newrelic entity graph --guid <entityGUID> --graph-format data --format yaml`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if graphDepth < 1 {
+			return fmt.Errorf("--depth must be at least 1")
+		}
+
+		switch strings.ToLower(graphFormat) {
+		case "dot", "mermaid", "data":
+			return nil
+		}
+
+		return fmt.Errorf("unknown graph format %s, must be one of dot, mermaid or data", graphFormat)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			graph, err := walkEntityGraph(utils.SignalCtx, &nrClient.NerdGraph, entityGUID, graphDepth, graphConcurrency)
+			utils.LogIfFatal(err)
+
+			switch strings.ToLower(graphFormat) {
+			case "dot":
+				utils.LogIfFatal(writeDOT(os.Stdout, graph))
+			case "mermaid":
+				utils.LogIfFatal(writeMermaid(os.Stdout, graph))
+			default:
+				utils.LogIfFatal(output.Print(graph))
+			}
+		})
+	},
+}
+
+func init() {
+	Command.AddCommand(cmdEntityGraph)
+	cmdEntityGraph.Flags().StringVarP(&entityGUID, "guid", "g", "", "the GUID of the entity to start from")
+	utils.LogIfError(cmdEntityGraph.MarkFlagRequired("guid"))
+	cmdEntityGraph.Flags().IntVar(&graphDepth, "depth", 1, "the number of relationships to follow from the entity")
+	cmdEntityGraph.Flags().StringVar(&graphFormat, "graph-format", "dot", "the graph format [dot, mermaid, data]")
+	cmdEntityGraph.Flags().IntVar(&graphConcurrency, "concurrency", defaultGraphConcurrency, "the maximum number of entities to fetch relationships for at a time")
+}
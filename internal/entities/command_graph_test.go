@@ -0,0 +1,18 @@
+// +build unit
+
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestEntityGraph(t *testing.T) {
+	assert.Equal(t, "graph", cmdEntityGraph.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdEntityGraph)
+	testcobra.CheckCobraRequiredFlags(t, cmdEntityGraph, []string{"guid"})
+}
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+)
+
+var (
+	inventoryAccountIDs  string
+	inventoryTagColumns  []string
+	inventoryConcurrency int
+)
+
+var cmdEntityInventory = &cobra.Command{
+	Use:   "inventory",
+	Short: "List every entity in your accounts as a table",
+	Long: `List every entity in your accounts as a table
+
+The inventory command pages through every entity in the accounts given by
+--accounts, or in every account visible to your API key with --accounts all, and
+flattens them into one table of their GUID, name, domain, type, account, reporting
+status, alert severity, the time they were last indexed and their tags.  The
+indexedAt column is when the entity was last updated in the entity index, not when
+it last reported data.  Tags are listed as key:value pairs separated by semicolons,
+and --tag-columns adds a column with the values of each tag key given.
+
+Use --format csv to export the inventory for use in other tools.
+`,
+	Example: `newrelic entity inventory --accounts all --format csv > inventory.csv
+newrelic entity inventory --accounts 12345678,87654321 --tag-columns team,env`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			accts, err := inventoryAccounts(utils.SignalCtx, &nrClient.Accounts, inventoryAccountIDs)
+			utils.LogIfFatal(err)
+
+			table, err := takeInventory(utils.SignalCtx, &nrClient.NerdGraph, accts, inventoryTagColumns, inventoryConcurrency)
+			utils.LogIfFatal(err)
+
+			utils.LogIfFatal(output.Print(table))
+
+			log.Infof("found %d entities in %d accounts", len(table.Rows), len(accts))
+		})
+	},
+}
+
+func init() {
+	Command.AddCommand(cmdEntityInventory)
+	cmdEntityInventory.Flags().StringVar(&inventoryAccountIDs, "accounts", "all", "the comma separated IDs of the accounts to list the entities of, or all")
+	cmdEntityInventory.Flags().StringSliceVar(&inventoryTagColumns, "tag-columns", []string{}, "the tag keys to add a column for")
+	cmdEntityInventory.Flags().IntVar(&inventoryConcurrency, "concurrency", defaultInventoryConcurrency, "the maximum number of accounts to list the entities of at a time")
+}
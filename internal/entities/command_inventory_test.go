@@ -0,0 +1,18 @@
+// +build unit
+
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestEntityInventory(t *testing.T) {
+	assert.Equal(t, "inventory", cmdEntityInventory.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdEntityInventory)
+	testcobra.CheckCobraRequiredFlags(t, cmdEntityInventory, []string{})
+}
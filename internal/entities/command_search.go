@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"errors"
 	"strconv"
 
 	log "github.com/sirupsen/logrus"
@@ -13,70 +14,50 @@ import (
 	"github.com/newrelic/newrelic-client-go/pkg/entities"
 )
 
+var (
+	entityQuery       string
+	entitySortBy      []string
+	entitySearchLimit int
+)
+
 var cmdEntitySearch = &cobra.Command{
 	Use:   "search",
 	Short: "Search for New Relic entities",
 	Long: `Search for New Relic entities
 
-The search command performs a search for New Relic entities.
+The search command performs a search for New Relic entities.  Entities can be
+selected with an entity search query given with --query, or with the --name, --type,
+--alert-severity, --reporting, --domain and --tag flags, which cannot be combined
+with --query.  The --tag flag may be repeated to match entities with every tag.
+
+Results are paged through automatically until every matching entity has been
+returned, or until the number given with --limit is reached.  Results can be
+sorted by one or more of ALERT_SEVERITY, DOMAIN, MOST_RELEVANT, NAME, REPORTING
+and TYPE.
 `,
-	Example: "newrelic entity search --name <applicationName>",
+	Example: `newrelic entity search --name <applicationName>
+newrelic entity search --query "domain = 'APM' AND tags.team = 'payments'" --sort NAME,DOMAIN
+newrelic entity search --domain APM --tag team:payments --tag env:production --limit 50`,
 	Run: func(cmd *cobra.Command, args []string) {
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
-			params := entities.EntitySearchQueryBuilder{}
-
-			if entityName == "" && entityType == "" && entityAlertSeverity == "" && entityDomain == "" {
+			params, err := buildEntitySearchParams()
+			if err != nil {
 				utils.LogIfError(cmd.Help())
-				log.Fatal("one of --name, --type, --alert-severity, or --domain are required")
-			}
-
-			if entityName != "" {
-				params.Name = entityName
+				log.Fatal(err)
 			}
 
-			if entityType != "" {
-				params.Type = entities.EntitySearchQueryBuilderType(entityType)
-			}
-
-			if entityAlertSeverity != "" {
-				params.AlertSeverity = entities.EntityAlertSeverity(entityAlertSeverity)
-			}
-
-			if entityDomain != "" {
-				params.Domain = entities.EntitySearchQueryBuilderDomain(entityDomain)
-			}
+			var found []entities.EntityOutlineInterface
 
-			if entityTag != "" {
-				key, value, err := assembleTagValue(entityTag)
-				utils.LogIfFatal(err)
-
-				params.Tags = []entities.EntitySearchQueryBuilderTag{{Key: key, Value: value}}
-			}
-
-			if entityReporting != "" {
-				reporting, err := strconv.ParseBool(entityReporting)
-
-				if err != nil {
-					log.Fatalf("invalid value provided for flag --reporting. Must be true or false.")
-				}
-
-				params.Reporting = reporting
-			}
-
-			results, err := nrClient.Entities.GetEntitySearch(
-				entities.EntitySearchOptions{},
-				"",
-				params,
-				[]entities.EntitySearchSortCriteria{},
-			)
+			err = searchEntities(utils.SignalCtx, &nrClient.NerdGraph, params, func(page []entities.EntityOutlineInterface) error {
+				found = append(found, page...)
+				return nil
+			})
 			utils.LogIfFatal(err)
 
-			entities := results.Results.Entities
-
 			var result interface{}
 
 			if len(entityFields) > 0 {
-				mapped := mapEntities(entities, entityFields, utils.StructToMap)
+				mapped := mapEntities(found, entityFields, utils.StructToMap)
 
 				if len(mapped) == 1 {
 					result = mapped[0]
@@ -84,10 +65,10 @@ The search command performs a search for New Relic entities.
 					result = mapped
 				}
 			} else {
-				if len(entities) == 1 {
-					result = entities[0]
+				if len(found) == 1 {
+					result = found[0]
 				} else {
-					result = entities
+					result = found
 				}
 			}
 
@@ -96,6 +77,63 @@ The search command performs a search for New Relic entities.
 	},
 }
 
+// buildEntitySearchParams assembles the search from the command's flags.
+func buildEntitySearchParams() (entitySearchParams, error) {
+	params := entitySearchParams{Limit: entitySearchLimit}
+
+	if entitySearchLimit < 0 {
+		return params, errors.New("--limit must not be negative")
+	}
+
+	sortBy, err := parseSortCriteria(entitySortBy)
+	if err != nil {
+		return params, err
+	}
+
+	params.SortBy = sortBy
+
+	builderFlags := entityName != "" || entityType != "" || entityAlertSeverity != "" || entityDomain != "" || entityReporting != "" || len(entityTags) > 0
+
+	if entityQuery != "" {
+		if builderFlags {
+			return params, errors.New("--query cannot be combined with --name, --type, --alert-severity, --reporting, --domain or --tag")
+		}
+
+		params.Query = entityQuery
+
+		return params, nil
+	}
+
+	if !builderFlags {
+		return params, errors.New("one of --query, --name, --type, --alert-severity, --reporting, --domain or --tag is required")
+	}
+
+	params.QueryBuilder.Name = entityName
+	params.QueryBuilder.Type = entities.EntitySearchQueryBuilderType(entityType)
+	params.QueryBuilder.AlertSeverity = entities.EntityAlertSeverity(entityAlertSeverity)
+	params.QueryBuilder.Domain = entities.EntitySearchQueryBuilderDomain(entityDomain)
+
+	if len(entityTags) > 0 {
+		tags, err := assembleTagValues(entityTags)
+		if err != nil {
+			return params, err
+		}
+
+		params.QueryBuilder.Tags = tags
+	}
+
+	if entityReporting != "" {
+		reporting, err := strconv.ParseBool(entityReporting)
+		if err != nil {
+			return params, errors.New("invalid value provided for flag --reporting. Must be true or false")
+		}
+
+		params.QueryBuilder.Reporting = reporting
+	}
+
+	return params, nil
+}
+
 func mapEntities(entities []entities.EntityOutlineInterface, fields []string, fn utils.StructToMapCallback) []map[string]interface{} {
 	mappedEntities := make([]map[string]interface{}, len(entities))
 
@@ -113,6 +151,9 @@ func init() {
 	cmdEntitySearch.Flags().StringVarP(&entityAlertSeverity, "alert-severity", "a", "", "search for entities matching the given alert severity type")
 	cmdEntitySearch.Flags().StringVarP(&entityReporting, "reporting", "r", "", "search for entities based on whether or not an entity is reporting (true or false)")
 	cmdEntitySearch.Flags().StringVarP(&entityDomain, "domain", "d", "", "search for entities matching the given entity domain")
-	cmdEntitySearch.Flags().StringVar(&entityTag, "tag", "", "search for entities matching the given entity tag")
+	cmdEntitySearch.Flags().StringSliceVar(&entityTags, "tag", []string{}, "search for entities with the given tags, as key:value, may be repeated")
+	cmdEntitySearch.Flags().StringVarP(&entityQuery, "query", "q", "", "an entity search query, such as \"domain = 'APM' AND tags.team = 'payments'\"")
+	cmdEntitySearch.Flags().StringSliceVar(&entitySortBy, "sort", []string{}, "the criteria to sort results by, such as NAME,DOMAIN")
+	cmdEntitySearch.Flags().IntVarP(&entitySearchLimit, "limit", "l", 0, "the maximum number of entities to return, or 0 for all")
 	cmdEntitySearch.Flags().StringSliceVarP(&entityFields, "fields-filter", "f", []string{}, "filter search results to only return certain fields for each search result")
 }
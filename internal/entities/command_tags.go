@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/entities/resolver"
 	"github.com/newrelic/newrelic-cli/internal/output"
 	"github.com/newrelic/newrelic-cli/internal/pipe"
 	"github.com/newrelic/newrelic-cli/internal/utils"
@@ -16,8 +17,8 @@ import (
 )
 
 var (
-	entityTag  string
 	entityTags []string
+	entityRef  string
 )
 
 var cmdTags = &cobra.Command{
@@ -27,6 +28,12 @@ var cmdTags = &cobra.Command{
 
 The tag command allows users to manage the tags applied on the requested
 entity. Use --help for more information.
+
+The entity can be given by its GUID with --guid, or by its name with --entity,
+such as --entity "name:checkout-service type:APPLICATION", which fails unless the
+name matches exactly one entity.  The keys domain and accountId can also be used
+to tell entities with the same name apart.  A --guid given as name=<name> is also
+looked up by name.
 `,
 	Example: "newrelic entity tags get --guid <guid>",
 }
@@ -38,7 +45,15 @@ var cmdTagsGet = &cobra.Command{
 
 The get command returns JSON output of the tags for the requested entity.
 `,
-	Example: "newrelic entity tags get --guid <entityGUID>",
+	Example: `newrelic entity tags get --guid <entityGUID>
+newrelic entity tags get --entity "name:checkout-service type:APPLICATION"`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if pipe.Exists("guid") {
+			return nil
+		}
+
+		return requireEntity(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
 			// Temporary until bulk actions can be build into newrelic-client-go
@@ -47,7 +62,7 @@ The get command returns JSON output of the tags for the requested entity.
 				utils.LogIfFatal(err)
 				utils.LogIfError(output.Print(tags))
 			} else {
-				tags, err := nrClient.Entities.GetTagsForEntity(entities.EntityGUID(entityGUID))
+				tags, err := nrClient.Entities.GetTagsForEntity(resolveEntityGUID(nrClient))
 				utils.LogIfFatal(err)
 				utils.LogIfError(output.Print(tags))
 			}
@@ -64,9 +79,10 @@ The delete command deletes all tags on the given entity
 that match the specified keys.
 `,
 	Example: "newrelic entity tags delete --guid <entityGUID> --tag tag1 --tag tag2 --tag tag3,tag4",
+	PreRunE: requireEntity,
 	Run: func(cmd *cobra.Command, args []string) {
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
-			_, err := nrClient.Entities.TaggingDeleteTagFromEntity(entities.EntityGUID(entityGUID), entityTags)
+			_, err := nrClient.Entities.TaggingDeleteTagFromEntity(resolveEntityGUID(nrClient), entityTags)
 			utils.LogIfFatal(err)
 
 			log.Info("success")
@@ -82,12 +98,13 @@ var cmdTagsDeleteValues = &cobra.Command{
 The delete-values command deletes the specified tag:value pairs on a given entity.
 `,
 	Example: "newrelic entity tags delete-values --guid <guid> --tag tag1:value1",
+	PreRunE: requireEntity,
 	Run: func(cmd *cobra.Command, args []string) {
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
 			tagValues, err := assembleTagValuesInput(entityValues)
 			utils.LogIfFatal(err)
 
-			_, err = nrClient.Entities.TaggingDeleteTagValuesFromEntity(entities.EntityGUID(entityGUID), tagValues)
+			_, err = nrClient.Entities.TaggingDeleteTagValuesFromEntity(resolveEntityGUID(nrClient), tagValues)
 			utils.LogIfFatal(err)
 
 			log.Info("success")
@@ -103,12 +120,13 @@ var cmdTagsCreate = &cobra.Command{
 The create command adds tag:value pairs to the given entity.
 `,
 	Example: "newrelic entity tags create --guid <entityGUID> --tag tag1:value1",
+	PreRunE: requireEntity,
 	Run: func(cmd *cobra.Command, args []string) {
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
 			tags, err := assembleTagsInput(entityTags)
 			utils.LogIfFatal(err)
 
-			_, err = nrClient.Entities.TaggingAddTagsToEntity(entities.EntityGUID(entityGUID), tags)
+			_, err = nrClient.Entities.TaggingAddTagsToEntity(resolveEntityGUID(nrClient), tags)
 			utils.LogIfFatal(err)
 
 			log.Info("success")
@@ -125,12 +143,13 @@ The replace command replaces any existing tag:value pairs with those
 provided for the given entity.
 `,
 	Example: "newrelic entity tags replace --guid <entityGUID> --tag tag1:value1",
+	PreRunE: requireEntity,
 	Run: func(cmd *cobra.Command, args []string) {
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
 			tags, err := assembleTagsInput(entityTags)
 			utils.LogIfFatal(err)
 
-			_, err = nrClient.Entities.TaggingReplaceTagsOnEntity(entities.EntityGUID(entityGUID), tags)
+			_, err = nrClient.Entities.TaggingReplaceTagsOnEntity(resolveEntityGUID(nrClient), tags)
 			utils.LogIfFatal(err)
 
 			log.Info("success")
@@ -138,6 +157,40 @@ provided for the given entity.
 	},
 }
 
+// requireEntity checks that the entity is given by one of --guid or --entity.
+func requireEntity(cmd *cobra.Command, args []string) error {
+	if entityGUID == "" && entityRef == "" {
+		return errors.New("one of --guid or --entity is required")
+	}
+
+	if entityGUID != "" && entityRef != "" {
+		return errors.New("only one of --guid or --entity can be given")
+	}
+
+	return nil
+}
+
+// resolveEntityGUID returns the GUID of the entity given by --guid or
+// --entity, looking it up by name where needed.
+func resolveEntityGUID(nrClient *newrelic.NewRelic) entities.EntityGUID {
+	r := resolver.New(&nrClient.NerdGraph)
+
+	if entityRef == "" {
+		guid, err := r.Resolve(utils.SignalCtx, entityGUID)
+		utils.LogIfFatal(err)
+
+		return entities.EntityGUID(guid)
+	}
+
+	sel, err := resolver.ParseSelector(entityRef)
+	utils.LogIfFatal(err)
+
+	guid, err := r.ResolveSelector(utils.SignalCtx, sel)
+	utils.LogIfFatal(err)
+
+	return entities.EntityGUID(guid)
+}
+
 func assembleTagsInput(tags []string) ([]entities.TaggingTagInput, error) {
 	var t []entities.TaggingTagInput
 
@@ -224,30 +277,30 @@ func init() {
 
 	if !pipe.Exists("guid") {
 		cmdTagsGet.Flags().StringVarP(&entityGUID, "guid", "g", "", "the entity GUID to retrieve tags for")
-		utils.LogIfError(cmdTagsGet.MarkFlagRequired("guid"))
+		cmdTagsGet.Flags().StringVar(&entityRef, "entity", "", "the entity to retrieve tags for, by name, as name:<name> type:<type>")
 	}
 
 	cmdTags.AddCommand(cmdTagsDelete)
 	cmdTagsDelete.Flags().StringVarP(&entityGUID, "guid", "g", "", "the entity GUID to delete tags on")
+	cmdTagsDelete.Flags().StringVar(&entityRef, "entity", "", "the entity to delete tags on, by name, as name:<name> type:<type>")
 	cmdTagsDelete.Flags().StringSliceVarP(&entityTags, "tag", "t", []string{}, "the tag keys to delete from the entity")
-	utils.LogIfError(cmdTagsDelete.MarkFlagRequired("guid"))
 	utils.LogIfError(cmdTagsDelete.MarkFlagRequired("tag"))
 
 	cmdTags.AddCommand(cmdTagsDeleteValues)
 	cmdTagsDeleteValues.Flags().StringVarP(&entityGUID, "guid", "g", "", "the entity GUID to delete tag values on")
+	cmdTagsDeleteValues.Flags().StringVar(&entityRef, "entity", "", "the entity to delete tag values on, by name, as name:<name> type:<type>")
 	cmdTagsDeleteValues.Flags().StringSliceVarP(&entityValues, "value", "v", []string{}, "the tag key:value pairs to delete from the entity")
-	utils.LogIfError(cmdTagsDeleteValues.MarkFlagRequired("guid"))
 	utils.LogIfError(cmdTagsDeleteValues.MarkFlagRequired("value"))
 
 	cmdTags.AddCommand(cmdTagsCreate)
 	cmdTagsCreate.Flags().StringVarP(&entityGUID, "guid", "g", "", "the entity GUID to create tag values on")
+	cmdTagsCreate.Flags().StringVar(&entityRef, "entity", "", "the entity to create tag values on, by name, as name:<name> type:<type>")
 	cmdTagsCreate.Flags().StringSliceVarP(&entityTags, "tag", "t", []string{}, "the tag names to add to the entity")
-	utils.LogIfError(cmdTagsCreate.MarkFlagRequired("guid"))
 	utils.LogIfError(cmdTagsCreate.MarkFlagRequired("tag"))
 
 	cmdTags.AddCommand(cmdTagsReplace)
 	cmdTagsReplace.Flags().StringVarP(&entityGUID, "guid", "g", "", "the entity GUID to replace tag values on")
+	cmdTagsReplace.Flags().StringVar(&entityRef, "entity", "", "the entity to replace tag values on, by name, as name:<name> type:<type>")
 	cmdTagsReplace.Flags().StringSliceVarP(&entityTags, "tag", "t", []string{}, "the tag names to replace on the entity")
-	utils.LogIfError(cmdTagsReplace.MarkFlagRequired("guid"))
 	utils.LogIfError(cmdTagsReplace.MarkFlagRequired("tag"))
 }
@@ -0,0 +1,195 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+var (
+	tagsDryRun      bool
+	tagsConcurrency int
+	tagsRate        int
+)
+
+var cmdTagsApply = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply tags to every entity matching a search or a tags file",
+	Long: `Apply tags to every entity matching a search or a tags file
+
+The apply command sets tags on every entity matched by an entity search query,
+following every page of the search results.  Each key given with --tag is set to
+exactly the values given for it, so other values of that key are removed from the
+entities, while keys that are not given are left alone.
+
+With --file, the tags of the entities in a tags file are applied instead, as shown
+by 'entity tags plan', including deleting the keys not in the file when --prune is
+set.  See 'entity tags plan --help' for the format of the file.
+
+The changes to each entity are printed as a diff before they are applied, and only
+printed when --dry-run is set.  Changes are applied to at most --concurrency
+entities at a time, and at most --rate tag mutations are made a second.  A summary
+of the entities changed, already up to date and failed is logged when done, and the
+command exits with a non-zero status when any entity could not be changed.
+`,
+	Example: `newrelic entity tags apply --query "domain = 'APM' AND name LIKE 'checkout%'" --tag team:payments --dry-run
+newrelic entity tags apply --query "tags.team = 'orders'" --tag team:payments --tag env:production
+newrelic entity tags apply -f tags.yaml --prune`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if tagsFilePath != "" {
+			if entityQuery != "" || len(entityTags) > 0 {
+				return errors.New("--file cannot be used with --query or --tag")
+			}
+
+			return nil
+		}
+
+		if entityQuery == "" || len(entityTags) == 0 {
+			return errors.New("either --file, or both --query and --tag are required")
+		}
+
+		if tagsPrune {
+			return errors.New("--prune can only be used with --file")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			if tagsFilePath != "" {
+				applyTagsFile(nrClient)
+				return
+			}
+
+			desired, err := assembleTagsInput(entityTags)
+			utils.LogIfFatal(err)
+
+			changes, err := planTagChanges(nrClient, desired)
+			utils.LogIfFatal(err)
+
+			if tagsDryRun {
+				printTagChanges(changes, true)
+				return
+			}
+
+			printTagChanges(changes, false)
+
+			results := applyTagChanges(utils.SignalCtx, &nrClient.Entities, changes, tagsConcurrency, tagsRate)
+			reportTagApplyResults(results)
+		})
+	},
+}
+
+// applyTagsFile applies the plan for a tags file.
+func applyTagsFile(nrClient *newrelic.NewRelic) {
+	changes, ops, err := planTagsFileChanges(nrClient, tagsFilePath, tagsPrune)
+	utils.LogIfFatal(err)
+
+	if tagsDryRun || output.CurrentFormat() == output.FormatText {
+		printTagPlan(ops)
+	}
+
+	if tagsDryRun || len(ops) == 0 {
+		return
+	}
+
+	results := applyTagChanges(utils.SignalCtx, &nrClient.Entities, changes, tagsConcurrency, tagsRate)
+	reportTagApplyResults(results)
+}
+
+// planTagChanges finds the changes needed to apply the tags to every entity
+// matching the search query.
+func planTagChanges(nrClient *newrelic.NewRelic, desired []entities.TaggingTagInput) ([]tagChange, error) {
+	var changes []tagChange
+
+	err := searchEntities(utils.SignalCtx, &nrClient.NerdGraph, entitySearchParams{Query: entityQuery}, func(page []entities.EntityOutlineInterface) error {
+		for _, e := range page {
+			current, err := outlineTags(e)
+			if err != nil {
+				return err
+			}
+
+			add, remove := diffTags(current, desired)
+
+			changes = append(changes, tagChange{
+				GUID:   string(e.GetGUID()),
+				Name:   e.GetName(),
+				Add:    add,
+				Remove: remove,
+			})
+		}
+
+		return nil
+	})
+
+	return changes, err
+}
+
+// printTagChanges prints the changes to be made as a diff.  Other formats
+// print the changes themselves when nothing is to be applied.
+func printTagChanges(changes []tagChange, dryRun bool) {
+	pending := []tagChange{}
+	for _, c := range changes {
+		if !c.Empty() {
+			pending = append(pending, c)
+		}
+	}
+
+	log.Infof("%d of the %d entities found need their tags changed", len(pending), len(changes))
+
+	if output.CurrentFormat() != output.FormatText {
+		if dryRun {
+			utils.LogIfFatal(output.Print(pending))
+		}
+
+		return
+	}
+
+	for _, c := range pending {
+		fmt.Print(c)
+	}
+}
+
+// reportTagApplyResults prints the results of applying tags, failing when
+// any entity could not be changed.
+func reportTagApplyResults(results []tagApplyResult) {
+	counts := countTagApplyResults(results)
+
+	if output.CurrentFormat() != output.FormatText {
+		utils.LogIfFatal(output.Print(results))
+	} else {
+		for _, r := range results {
+			if r.Status == tagApplyStatusFailed {
+				fmt.Printf("failed: %s (%s): %s\n", r.Name, r.GUID, r.Error)
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("changed tags on %d entities, %d were already up to date and %d failed",
+		counts[tagApplyStatusApplied], counts[tagApplyStatusUnchanged], counts[tagApplyStatusFailed])
+
+	if counts[tagApplyStatusFailed] > 0 {
+		log.Fatal(summary)
+	}
+
+	log.Info(summary)
+}
+
+func init() {
+	cmdTags.AddCommand(cmdTagsApply)
+	cmdTagsApply.Flags().StringVarP(&entityQuery, "query", "q", "", "an entity search query selecting the entities to tag")
+	cmdTagsApply.Flags().StringSliceVarP(&entityTags, "tag", "t", []string{}, "the tags to set on the entities, as key:value, may be repeated")
+	cmdTagsApply.Flags().StringVarP(&tagsFilePath, "file", "f", "", "a YAML file of the entities' desired tags, instead of --query and --tag")
+	cmdTagsApply.Flags().BoolVar(&tagsPrune, "prune", false, "with --file, delete the tag keys of the entities not given in the file")
+	cmdTagsApply.Flags().BoolVar(&tagsDryRun, "dry-run", false, "print the changes without applying them")
+	cmdTagsApply.Flags().IntVar(&tagsConcurrency, "concurrency", defaultTagApplyConcurrency, "the maximum number of entities to change at a time")
+	cmdTagsApply.Flags().IntVar(&tagsRate, "rate", defaultTagApplyRate, "the maximum number of tag mutations to make a second")
+}
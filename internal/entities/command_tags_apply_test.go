@@ -0,0 +1,18 @@
+// +build unit
+
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestEntitiesApplyTags(t *testing.T) {
+	assert.Equal(t, "apply", cmdTagsApply.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdTagsApply)
+	testcobra.CheckCobraRequiredFlags(t, cmdTagsApply, []string{})
+}
@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+var (
+	auditRequired        []string
+	auditAllowed         []string
+	auditAllowedPatterns []string
+	auditGroupBy         string
+	auditDetails         bool
+)
+
+var cmdTagsAudit = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit the tags of entities against a tagging policy",
+	Long: `Audit the tags of entities against a tagging policy
+
+The audit command checks the tags of every entity matched by an entity search query
+against a tagging policy.  An entity violates the policy when it is missing a key
+given with --required, or has a value of a key that is not allowed.  The values
+allowed for a key are given either as a set of values with --allowed, such as
+env=production|staging, or as a regular expression the whole value must match with
+--allowed-pattern, such as owner=[a-z.]+@example\.com.
+
+The number of entities audited, compliant and violating the policy are reported for
+each value of the tag given by --group-by, along with the number of entities
+missing each required key or with values of each key that are not allowed.  Use
+--format csv for a CSV report.  With --details, each violation is reported instead.
+
+The command exits with a non-zero status when any entity violates the policy, so it
+can be used to enforce a tagging policy in CI.
+`,
+	Example: `newrelic entity tags audit --required team,env,owner --query "domain IN ('APM', 'INFRA')"
+newrelic entity tags audit --query "domain = 'APM'" --required team --allowed "env=production|staging" --format csv
+newrelic entity tags audit --query "domain = 'APM'" --allowed-pattern "owner=[a-z.]+@example\.com" --details`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(auditRequired) == 0 && len(auditAllowed) == 0 && len(auditAllowedPatterns) == 0 {
+			return errors.New("at least one of --required, --allowed or --allowed-pattern is required")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			policy, err := parseTagPolicy(auditRequired, auditAllowed, auditAllowedPatterns)
+			utils.LogIfFatal(err)
+
+			var audits []tagAudit
+
+			err = searchEntities(utils.SignalCtx, &nrClient.NerdGraph, entitySearchParams{Query: entityQuery}, func(page []entities.EntityOutlineInterface) error {
+				for _, e := range page {
+					tags, err := outlineTags(e)
+					if err != nil {
+						return err
+					}
+
+					audits = append(audits, auditEntityTags(e, tags, policy, auditGroupBy))
+				}
+
+				return nil
+			})
+			utils.LogIfFatal(err)
+
+			violating := 0
+			violations := []tagViolation{}
+
+			for _, a := range audits {
+				if len(a.Violations) > 0 {
+					violating++
+					violations = append(violations, a.Violations...)
+				}
+			}
+
+			if auditDetails {
+				utils.LogIfFatal(output.Print(violations))
+			} else {
+				utils.LogIfFatal(output.Print(summarizeTagAudits(audits, policy, auditGroupBy)))
+			}
+
+			if violating > 0 {
+				log.Fatalf("%d of the %d entities audited violate the tagging policy", violating, len(audits))
+			}
+
+			log.Infof("all %d entities audited comply with the tagging policy", len(audits))
+		})
+	},
+}
+
+func init() {
+	cmdTags.AddCommand(cmdTagsAudit)
+	cmdTagsAudit.Flags().StringVarP(&entityQuery, "query", "q", "", "an entity search query selecting the entities to audit")
+	cmdTagsAudit.Flags().StringSliceVar(&auditRequired, "required", []string{}, "the tag keys every entity must have")
+	cmdTagsAudit.Flags().StringArrayVar(&auditAllowed, "allowed", []string{}, "the values allowed for a tag key, as key=value1|value2, may be repeated")
+	cmdTagsAudit.Flags().StringArrayVar(&auditAllowedPatterns, "allowed-pattern", []string{}, "a regular expression the values of a tag key must match, as key=regex, may be repeated")
+	cmdTagsAudit.Flags().StringVar(&auditGroupBy, "group-by", "team", "the tag key to count entities by")
+	cmdTagsAudit.Flags().BoolVar(&auditDetails, "details", false, "report each violation rather than counts")
+	utils.LogIfError(cmdTagsAudit.MarkFlagRequired("query"))
+}
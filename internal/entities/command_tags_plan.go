@@ -0,0 +1,114 @@
+package entities
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+)
+
+var (
+	tagsFilePath    string
+	tagsPrune       bool
+	tagsFailOnDrift bool
+)
+
+var cmdTagsPlan = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the tag changes needed to match a tags file",
+	Long: `Show the tag changes needed to match a tags file
+
+The plan command compares the tags of the entities in a tags file with their live
+tags, and shows the operations 'entity tags apply -f' would make.  A tags file maps
+entities, selected by GUID, name or entity search query, to their tags:
+
+  entities:
+    - guid: MXxBUE18QVBQTElDQVRJT058MQ
+      tags: [team:payments, env:production]
+    - name: checkout
+      tags: [team:payments]
+    - query: "domain = 'APM' AND name LIKE 'orders%'"
+      tags: [team:orders]
+
+Each key given is set to exactly the values given.  Keys that are not given are
+left alone, unless --prune is set, in which case they are deleted, except for the
+tags New Relic adds to entities, which cannot be changed.
+
+A key missing from an entity is created, a key with other values is replaced and a
+key deleted by --prune is deleted.  With --fail-on-drift, the command exits with a
+non-zero status when any operation is needed, so it can be used to detect drift
+from the tags file in CI.
+`,
+	Example: `newrelic entity tags plan -f tags.yaml
+newrelic entity tags plan -f tags.yaml --prune --fail-on-drift --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			_, ops, err := planTagsFileChanges(nrClient, tagsFilePath, tagsPrune)
+			utils.LogIfFatal(err)
+
+			printTagPlan(ops)
+
+			if tagsFailOnDrift && len(ops) > 0 {
+				log.Fatalf("tags have drifted from %s: %s", tagsFilePath, tagPlanSummary(ops))
+			}
+		})
+	},
+}
+
+// planTagsFileChanges reads a tags file and plans the changes needed to match
+// it.
+func planTagsFileChanges(nrClient *newrelic.NewRelic, path string, prune bool) ([]tagChange, []tagOperation, error) {
+	f, err := readTagsFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	targets, err := resolveTagsFile(utils.SignalCtx, &nrClient.NerdGraph, f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes, ops := planTagsFile(targets, prune)
+
+	return changes, ops, nil
+}
+
+// printTagPlan prints the operations of a plan, grouped by entity, followed
+// by a summary.  Other formats print the operations themselves.
+func printTagPlan(ops []tagOperation) {
+	if output.CurrentFormat() != output.FormatText {
+		utils.LogIfFatal(output.Print(ops))
+		return
+	}
+
+	var guid string
+
+	for _, o := range ops {
+		if o.GUID != guid {
+			guid = o.GUID
+			fmt.Printf("%s (%s)\n", o.Name, o.GUID)
+		}
+
+		fmt.Println(o)
+	}
+
+	if len(ops) == 0 {
+		fmt.Println("No changes, the tags of the entities match the tags file.")
+		return
+	}
+
+	fmt.Printf("\nPlan: %s.\n", tagPlanSummary(ops))
+}
+
+func init() {
+	cmdTags.AddCommand(cmdTagsPlan)
+	cmdTagsPlan.Flags().StringVarP(&tagsFilePath, "file", "f", "", "the YAML file of the entities' desired tags")
+	cmdTagsPlan.Flags().BoolVar(&tagsPrune, "prune", false, "delete the tag keys of the entities not given in the file")
+	cmdTagsPlan.Flags().BoolVar(&tagsFailOnDrift, "fail-on-drift", false, "exit with a non-zero status when the tags do not match the file")
+	utils.LogIfError(cmdTagsPlan.MarkFlagRequired("file"))
+}
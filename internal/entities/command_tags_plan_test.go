@@ -0,0 +1,18 @@
+// +build unit
+
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestEntitiesPlanTags(t *testing.T) {
+	assert.Equal(t, "plan", cmdTagsPlan.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdTagsPlan)
+	testcobra.CheckCobraRequiredFlags(t, cmdTagsPlan, []string{"file"})
+}
@@ -14,8 +14,10 @@ import (
 
 func TestEntitiesGetTags(t *testing.T) {
 	assert.Equal(t, "get", cmdTagsGet.Name())
+	assert.NotNil(t, cmdTagsGet.Flag("guid"))
+	assert.NotNil(t, cmdTagsGet.Flag("entity"))
 
-	requiredFlags := []string{"guid"}
+	requiredFlags := []string{}
 
 	for _, r := range requiredFlags {
 		x := cmdTagsGet.Flag(r)
@@ -30,8 +32,10 @@ func TestEntitiesGetTags(t *testing.T) {
 
 func TestEntitiesDeleteTags(t *testing.T) {
 	assert.Equal(t, "delete", cmdTagsDelete.Name())
+	assert.NotNil(t, cmdTagsDelete.Flag("guid"))
+	assert.NotNil(t, cmdTagsDelete.Flag("entity"))
 
-	requiredFlags := []string{"guid", "tag"}
+	requiredFlags := []string{"tag"}
 
 	for _, r := range requiredFlags {
 		x := cmdTagsDelete.Flag(r)
@@ -46,8 +50,10 @@ func TestEntitiesDeleteTags(t *testing.T) {
 
 func TestEntitiesDeleteTagValues(t *testing.T) {
 	assert.Equal(t, "delete-values", cmdTagsDeleteValues.Name())
+	assert.NotNil(t, cmdTagsDeleteValues.Flag("guid"))
+	assert.NotNil(t, cmdTagsDeleteValues.Flag("entity"))
 
-	requiredFlags := []string{"guid", "value"}
+	requiredFlags := []string{"value"}
 
 	for _, r := range requiredFlags {
 		x := cmdTagsDeleteValues.Flag(r)
@@ -63,8 +69,10 @@ func TestEntitiesDeleteTagValues(t *testing.T) {
 func TestEntitiesCreateTags(t *testing.T) {
 	cur := *cmdTagsCreate
 	assert.Equal(t, "create", cur.Name())
+	assert.NotNil(t, cur.Flag("guid"))
+	assert.NotNil(t, cur.Flag("entity"))
 
-	requiredFlags := []string{"guid", "tag"}
+	requiredFlags := []string{"tag"}
 
 	for _, r := range requiredFlags {
 		x := cur.Flag(r)
@@ -80,8 +88,10 @@ func TestEntitiesCreateTags(t *testing.T) {
 func TestEntitiesReplaceTags(t *testing.T) {
 	cur := *cmdTagsReplace
 	assert.Equal(t, "replace", cur.Name())
+	assert.NotNil(t, cur.Flag("guid"))
+	assert.NotNil(t, cur.Flag("entity"))
 
-	requiredFlags := []string{"guid", "tag"}
+	requiredFlags := []string{"tag"}
 
 	for _, r := range requiredFlags {
 		x := cur.Flag(r)
@@ -227,3 +237,16 @@ func TestEntitiesAssembleTagValue(t *testing.T) {
 		assert.Equal(t, s.err, e)
 	}
 }
+
+func TestEntitiesRequireEntity(t *testing.T) {
+	defer func() { entityGUID, entityRef = "", "" }()
+
+	entityGUID, entityRef = "", ""
+	assert.EqualError(t, requireEntity(cmdTagsCreate, nil), "one of --guid or --entity is required")
+
+	entityGUID, entityRef = "G1", "name:checkout"
+	assert.EqualError(t, requireEntity(cmdTagsCreate, nil), "only one of --guid or --entity can be given")
+
+	entityGUID, entityRef = "", "name:checkout"
+	assert.NoError(t, requireEntity(cmdTagsCreate, nil))
+}
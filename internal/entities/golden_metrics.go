@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+// nrdbClient is the part of the NRDB client used to evaluate golden metrics.
+type nrdbClient interface {
+	QueryWithContext(context.Context, int, nrdb.NRQL) (*nrdb.NRDBResultContainer, error)
+}
+
+// goldenMetric is one of an entity's golden metrics and its value over the
+// requested time range.  The value is a number when the query returns a
+// single value, and the query's results otherwise.
+type goldenMetric struct {
+	Name  string      `json:"name" yaml:"name"`
+	Title string      `json:"title" yaml:"title"`
+	Unit  string      `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Query string      `json:"query" yaml:"query"`
+	Value interface{} `json:"value" yaml:"value"`
+	Error string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type goldenMetricsResponse struct {
+	Actor struct {
+		Entity struct {
+			GoldenMetrics struct {
+				Metrics []struct {
+					Name  string `json:"name"`
+					Title string `json:"title"`
+					Unit  string `json:"unit"`
+					Query string `json:"query"`
+				} `json:"metrics"`
+			} `json:"goldenMetrics"`
+		} `json:"entity"`
+	} `json:"actor"`
+}
+
+// getGoldenMetrics fetches an entity's golden metrics and evaluates each
+// query over the time range given by since, such as "30 minutes ago".  A
+// query that fails is reported with its error rather than failing the rest.
+func getGoldenMetrics(ctx context.Context, ngClient nerdGraphClient, nrdbClient nrdbClient, entity entities.EntityInterface, since string) ([]goldenMetric, error) {
+	vars := map[string]interface{}{
+		"guid": entity.GetGUID(),
+	}
+
+	var resp goldenMetricsResponse
+	if err := ngClient.QueryWithResponseAndContext(ctx, goldenMetricsQuery, vars, &resp); err != nil {
+		return nil, err
+	}
+
+	metrics := []goldenMetric{}
+
+	for _, m := range resp.Actor.Entity.GoldenMetrics.Metrics {
+		metric := goldenMetric{
+			Name:  m.Name,
+			Title: m.Title,
+			Unit:  m.Unit,
+			Query: fmt.Sprintf("%s SINCE %s", strings.TrimSpace(m.Query), since),
+		}
+
+		log.WithFields(log.Fields{
+			"guid":   entity.GetGUID(),
+			"metric": m.Name,
+		}).Debug("evaluating golden metric")
+
+		result, err := nrdbClient.QueryWithContext(ctx, entity.GetAccountID(), nrdb.NRQL(metric.Query))
+		if err != nil {
+			metric.Error = err.Error()
+		} else {
+			metric.Value = goldenMetricValue(result.Results)
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	return metrics, nil
+}
+
+// goldenMetricValue reduces a query's results to a single value where the
+// query returns one row with a single aggregate.
+func goldenMetricValue(results []nrdb.NRDBResult) interface{} {
+	if len(results) != 1 {
+		return results
+	}
+
+	var values []interface{}
+
+	for k, v := range results[0] {
+		switch k {
+		case "beginTimeSeconds", "endTimeSeconds", "timestamp":
+			continue
+		}
+
+		values = append(values, v)
+	}
+
+	if len(values) == 1 {
+		return values[0]
+	}
+
+	return results[0]
+}
+
+const goldenMetricsQuery = `query($guid: EntityGuid!) { actor { entity(guid: $guid) {
+	goldenMetrics {
+		metrics {
+			name
+			title
+			unit
+			query
+		}
+	}
+} } }`
@@ -0,0 +1,84 @@
+// +build unit
+
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+// staticNerdGraphClient returns the same response to every query.
+type staticNerdGraphClient struct {
+	body string
+}
+
+func (c staticNerdGraphClient) QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error {
+	return json.Unmarshal([]byte(c.body), respBody)
+}
+
+// fakeNRDBClient returns results by query, or an error for queries it does
+// not know.
+type fakeNRDBClient struct {
+	results  map[string][]nrdb.NRDBResult
+	queries  []nrdb.NRQL
+	accounts []int
+}
+
+func (c *fakeNRDBClient) QueryWithContext(ctx context.Context, accountID int, query nrdb.NRQL) (*nrdb.NRDBResultContainer, error) {
+	c.queries = append(c.queries, query)
+	c.accounts = append(c.accounts, accountID)
+
+	for prefix, results := range c.results {
+		if strings.HasPrefix(string(query), prefix) {
+			return &nrdb.NRDBResultContainer{Results: results}, nil
+		}
+	}
+
+	return nil, errors.New("unknown query")
+}
+
+func TestGetGoldenMetrics(t *testing.T) {
+	ngClient := staticNerdGraphClient{body: `{"actor":{"entity":{"goldenMetrics":{"metrics":[
+		{"name":"responseTimeMs","title":"Response time (ms)","unit":"MS","query":"SELECT average(duration) AS 'Response time (ms)' FROM Transaction "},
+		{"name":"throughput","title":"Throughput","unit":"REQUESTS_PER_MINUTE","query":"SELECT rate(count(*), 1 minute) FROM Transaction FACET host"},
+		{"name":"errorRate","title":"Error rate","query":"SELECT percentage(count(*), WHERE error) FROM Transaction"}
+	]}}}}`}
+
+	nrdbClient := &fakeNRDBClient{results: map[string][]nrdb.NRDBResult{
+		"SELECT average": {{"Response time (ms)": 120.5, "beginTimeSeconds": 1, "endTimeSeconds": 2}},
+		"SELECT rate":    {{"host": "a", "rate": 1.0}, {"host": "b", "rate": 2.0}},
+	}}
+
+	entity := &entities.ApmApplicationEntity{GUID: "G1", AccountID: 123}
+
+	metrics, err := getGoldenMetrics(context.Background(), ngClient, nrdbClient, entity, "1 hour ago")
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+
+	assert.Equal(t, "SELECT average(duration) AS 'Response time (ms)' FROM Transaction SINCE 1 hour ago", metrics[0].Query)
+	assert.Equal(t, 120.5, metrics[0].Value)
+	assert.Equal(t, "MS", metrics[0].Unit)
+
+	assert.Len(t, metrics[1].Value, 2)
+
+	assert.Nil(t, metrics[2].Value)
+	assert.Equal(t, "unknown query", metrics[2].Error)
+
+	assert.Equal(t, []int{123, 123, 123}, nrdbClient.accounts)
+}
+
+func TestGoldenMetricValue(t *testing.T) {
+	row := nrdb.NRDBResult{"count": 1.0, "sum": 2.0}
+
+	assert.Equal(t, row, goldenMetricValue([]nrdb.NRDBResult{row}))
+	assert.Equal(t, []nrdb.NRDBResult{}, goldenMetricValue([]nrdb.NRDBResult{}))
+}
@@ -0,0 +1,341 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultGraphConcurrency = 5
+
+// graphNode is an entity in a relationship graph.
+type graphNode struct {
+	GUID       string `json:"guid" yaml:"guid"`
+	Name       string `json:"name,omitempty" yaml:"name,omitempty"`
+	Domain     string `json:"domain,omitempty" yaml:"domain,omitempty"`
+	EntityType string `json:"entityType,omitempty" yaml:"entityType,omitempty"`
+	AccountID  int    `json:"accountId,omitempty" yaml:"accountId,omitempty"`
+	Depth      int    `json:"depth" yaml:"depth"`
+	Unexpanded bool   `json:"unexpanded,omitempty" yaml:"unexpanded,omitempty"`
+}
+
+func (n graphNode) label() string {
+	if n.Name != "" {
+		return n.Name
+	}
+
+	return n.GUID
+}
+
+// graphEdge is a relationship from one entity to another.
+type graphEdge struct {
+	Source string `json:"source" yaml:"source"`
+	Target string `json:"target" yaml:"target"`
+	Type   string `json:"type" yaml:"type"`
+}
+
+// entityGraph is the entities reachable from a root entity through their
+// relationships, in the order they were found.
+type entityGraph struct {
+	Root  string      `json:"root" yaml:"root"`
+	Depth int         `json:"depth" yaml:"depth"`
+	Nodes []graphNode `json:"nodes" yaml:"nodes"`
+	Edges []graphEdge `json:"edges" yaml:"edges"`
+}
+
+type relationshipNode struct {
+	GUID       string `json:"guid"`
+	EntityType string `json:"entityType"`
+	AccountID  int    `json:"accountId"`
+	Entity     *struct {
+		Name   string `json:"name"`
+		Domain string `json:"domain"`
+	} `json:"entity"`
+}
+
+func (n relationshipNode) node() graphNode {
+	node := graphNode{GUID: n.GUID, EntityType: n.EntityType, AccountID: n.AccountID}
+
+	if n.Entity != nil {
+		node.Name = n.Entity.Name
+		node.Domain = n.Entity.Domain
+	}
+
+	return node
+}
+
+type relationshipsResponse struct {
+	Actor struct {
+		Entity *struct {
+			GUID          string `json:"guid"`
+			Name          string `json:"name"`
+			Domain        string `json:"domain"`
+			EntityType    string `json:"entityType"`
+			AccountID     int    `json:"accountId"`
+			Relationships []struct {
+				Type   string           `json:"type"`
+				Source relationshipNode `json:"source"`
+				Target relationshipNode `json:"target"`
+			} `json:"relationships"`
+		} `json:"entity"`
+	} `json:"actor"`
+}
+
+// relationships is an entity and the entities it is related to.
+type relationships struct {
+	node      graphNode
+	neighbors []graphNode
+	edges     []graphEdge
+}
+
+func getRelationships(ctx context.Context, client nerdGraphClient, guid string) (*relationships, error) {
+	var resp relationshipsResponse
+	if err := client.QueryWithResponseAndContext(ctx, relationshipsQuery, map[string]interface{}{"guid": guid}, &resp); err != nil {
+		return nil, err
+	}
+
+	e := resp.Actor.Entity
+	if e == nil {
+		return nil, fmt.Errorf("no entity found with GUID %s", guid)
+	}
+
+	r := &relationships{
+		node: graphNode{GUID: guid, Name: e.Name, Domain: e.Domain, EntityType: e.EntityType, AccountID: e.AccountID},
+	}
+
+	for _, rel := range e.Relationships {
+		r.edges = append(r.edges, graphEdge{Source: rel.Source.GUID, Target: rel.Target.GUID, Type: rel.Type})
+
+		for _, n := range []relationshipNode{rel.Source, rel.Target} {
+			if n.GUID != guid {
+				r.neighbors = append(r.neighbors, n.node())
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// walkEntityGraph walks the relationships of the root entity breadth first,
+// up to the given depth, fetching the relationships of at most concurrency
+// entities at a time.  Entities already visited are not visited again, so
+// cycles in the relationships are only followed once.  The relationships of
+// the entities at the given depth are not fetched, so relationships between
+// two of them are not included.  Entities whose relationships cannot be
+// fetched are marked as unexpanded, and the walk carries on without them,
+// unless it is the root entity.
+func walkEntityGraph(ctx context.Context, client nerdGraphClient, root string, depth int, concurrency int) (*entityGraph, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	graph := &entityGraph{Root: root, Depth: depth}
+
+	nodes := map[string]int{root: 0}
+	graph.Nodes = append(graph.Nodes, graphNode{GUID: root})
+
+	edges := map[graphEdge]bool{}
+	frontier := []string{root}
+
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		log.WithFields(log.Fields{
+			"depth":    level,
+			"entities": len(frontier),
+		}).Debug("fetching relationships")
+
+		found, errs := fetchRelationships(ctx, client, frontier, concurrency)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if level == 0 && errs[0] != nil {
+			return nil, errs[0]
+		}
+
+		var next []string
+
+		for i, r := range found {
+			if r == nil {
+				log.Warn(errs[i])
+				graph.Nodes[nodes[frontier[i]]].Unexpanded = true
+
+				continue
+			}
+
+			// Keep what was known about the entity when it was found, should
+			// NerdGraph not return its details
+			i := nodes[r.node.GUID]
+			if r.node.Name != "" {
+				r.node.Depth = graph.Nodes[i].Depth
+				graph.Nodes[i] = r.node
+			}
+
+			for _, n := range r.neighbors {
+				if _, ok := nodes[n.GUID]; ok {
+					continue
+				}
+
+				n.Depth = level + 1
+				nodes[n.GUID] = len(graph.Nodes)
+				graph.Nodes = append(graph.Nodes, n)
+				next = append(next, n.GUID)
+			}
+
+			for _, e := range r.edges {
+				if !edges[e] {
+					edges[e] = true
+					graph.Edges = append(graph.Edges, e)
+				}
+			}
+		}
+
+		frontier = next
+	}
+
+	return graph, nil
+}
+
+// fetchRelationships fetches the relationships of each entity, returning
+// them, or the error fetching them, in the order of the GUIDs given.
+func fetchRelationships(ctx context.Context, client nerdGraphClient, guids []string, concurrency int) ([]*relationships, []error) {
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		results = make([]*relationships, len(guids))
+		errs    = make([]error, len(guids))
+	)
+
+	for i, guid := range guids {
+		wg.Add(1)
+
+		go func(i int, guid string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			r, err := getRelationships(ctx, client, guid)
+			if err != nil {
+				errs[i] = fmt.Errorf("unable to get the relationships of %s: %s", guid, err)
+				return
+			}
+
+			results[i] = r
+		}(i, guid)
+	}
+
+	wg.Wait()
+
+	return results, errs
+}
+
+// writeDOT renders the graph in the Graphviz DOT language.
+func writeDOT(w io.Writer, graph *entityGraph) error {
+	var b strings.Builder
+
+	b.WriteString("digraph entities {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, n := range graph.Nodes {
+		label := n.label()
+		if n.EntityType != "" {
+			label += "\n" + n.EntityType
+		}
+
+		attrs := fmt.Sprintf("label=%s", dotQuote(label))
+		if n.GUID == graph.Root {
+			attrs += ", style=bold"
+		} else if n.Unexpanded {
+			attrs += ", style=dashed"
+		}
+
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.GUID), attrs)
+	}
+
+	for _, e := range graph.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(e.Source), dotQuote(e.Target), dotQuote(e.Type))
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+
+	return `"` + s + `"`
+}
+
+// writeMermaid renders the graph as a Mermaid flowchart.  Nodes are given
+// short identifiers, as GUIDs are not valid Mermaid identifiers.
+func writeMermaid(w io.Writer, graph *entityGraph) error {
+	var b strings.Builder
+
+	b.WriteString("graph LR\n")
+
+	ids := map[string]string{}
+
+	for i, n := range graph.Nodes {
+		ids[n.GUID] = fmt.Sprintf("n%d", i)
+
+		label := mermaidEscape(n.label())
+		if n.EntityType != "" {
+			label += "<br/>" + mermaidEscape(n.EntityType)
+		}
+
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[n.GUID], label)
+	}
+
+	for _, e := range graph.Edges {
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[e.Source], mermaidEscape(e.Type), ids[e.Target])
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func mermaidEscape(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "|", "#124;", "<", "#lt;", ">", "#gt;")
+
+	return r.Replace(s)
+}
+
+const relationshipsQuery = `query($guid: EntityGuid!) { actor { entity(guid: $guid) {
+	guid
+	name
+	domain
+	entityType
+	accountId
+	relationships {
+		type
+		source {
+			guid
+			entityType
+			accountId
+			entity {
+				name
+				domain
+			}
+		}
+		target {
+			guid
+			entityType
+			accountId
+			entity {
+				name
+				domain
+			}
+		}
+	}
+} } }`
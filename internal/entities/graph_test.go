@@ -0,0 +1,151 @@
+// +build unit
+
+package entities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRelationshipsClient serves the relationships of a small graph of
+// entities, A calls B, B calls C and D, and C calls A.
+type fakeRelationshipsClient struct {
+	mu       sync.Mutex
+	requests []string
+	failing  map[string]bool
+}
+
+func (f *fakeRelationshipsClient) QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error {
+	guid := variables["guid"].(string)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, guid)
+	f.mu.Unlock()
+
+	if f.failing[guid] {
+		return fmt.Errorf("timed out")
+	}
+
+	calls := map[string][]string{"A": {"B"}, "B": {"C", "D"}, "C": {"A"}}
+
+	node := func(guid string) string {
+		return fmt.Sprintf(`{"guid":%q,"entityType":"APM_APPLICATION_ENTITY","accountId":1,"entity":{"name":"app %s","domain":"APM"}}`, guid, guid)
+	}
+
+	var rels []string
+	for source, targets := range calls {
+		for _, target := range targets {
+			if source == guid || target == guid {
+				rels = append(rels, fmt.Sprintf(`{"type":"CALLS","source":%s,"target":%s}`, node(source), node(target)))
+			}
+		}
+	}
+
+	if guid == "missing" {
+		return json.Unmarshal([]byte(`{"actor":{"entity":null}}`), respBody)
+	}
+
+	body := fmt.Sprintf(`{"actor":{"entity":{"guid":%q,"name":"app %s","domain":"APM","entityType":"APM_APPLICATION_ENTITY","relationships":[`, guid, guid)
+	for i, r := range rels {
+		if i > 0 {
+			body += ","
+		}
+		body += r
+	}
+	body += `]}}}`
+
+	return json.Unmarshal([]byte(body), respBody)
+}
+
+func TestWalkEntityGraph(t *testing.T) {
+	client := &fakeRelationshipsClient{}
+
+	graph, err := walkEntityGraph(context.Background(), client, "A", 1, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"A"}, client.requests)
+	require.Len(t, graph.Nodes, 3)
+	assert.Equal(t, "app A", graph.Nodes[0].Name)
+	assert.Equal(t, 0, graph.Nodes[0].Depth)
+	assert.Equal(t, 1, graph.Nodes[1].Depth)
+	assert.Len(t, graph.Edges, 2)
+
+	client = &fakeRelationshipsClient{}
+
+	graph, err = walkEntityGraph(context.Background(), client, "A", 5, 2)
+	require.NoError(t, err)
+
+	// Every entity is visited once, despite the cycle
+	assert.ElementsMatch(t, []string{"A", "B", "C", "D"}, client.requests)
+	assert.Len(t, graph.Nodes, 4)
+	assert.ElementsMatch(t, []graphEdge{
+		{Source: "A", Target: "B", Type: "CALLS"},
+		{Source: "B", Target: "C", Type: "CALLS"},
+		{Source: "B", Target: "D", Type: "CALLS"},
+		{Source: "C", Target: "A", Type: "CALLS"},
+	}, graph.Edges)
+
+	_, err = walkEntityGraph(context.Background(), client, "missing", 1, 1)
+	assert.EqualError(t, err, "unable to get the relationships of missing: no entity found with GUID missing")
+}
+
+func TestWalkEntityGraphPartial(t *testing.T) {
+	client := &fakeRelationshipsClient{failing: map[string]bool{"B": true}}
+
+	graph, err := walkEntityGraph(context.Background(), client, "A", 3, 2)
+	require.NoError(t, err)
+
+	// B is kept, but its relationships are not followed, so D is not found
+	unexpanded := map[string]bool{}
+	for _, n := range graph.Nodes {
+		unexpanded[n.GUID] = n.Unexpanded
+	}
+
+	assert.Equal(t, map[string]bool{"A": false, "B": true, "C": false}, unexpanded)
+	assert.ElementsMatch(t, []graphEdge{
+		{Source: "A", Target: "B", Type: "CALLS"},
+		{Source: "B", Target: "C", Type: "CALLS"},
+		{Source: "C", Target: "A", Type: "CALLS"},
+	}, graph.Edges)
+
+	var dot bytes.Buffer
+	require.NoError(t, writeDOT(&dot, graph))
+	assert.Contains(t, dot.String(), `"B" [label="app B\nAPM_APPLICATION_ENTITY", style=dashed];`)
+}
+
+func TestWriteGraph(t *testing.T) {
+	graph := &entityGraph{
+		Root: "A",
+		Nodes: []graphNode{
+			{GUID: "A", Name: `checkout "v2"`, EntityType: "APM_APPLICATION_ENTITY"},
+			{GUID: "B"},
+		},
+		Edges: []graphEdge{{Source: "A", Target: "B", Type: "CALLS"}},
+	}
+
+	var dot bytes.Buffer
+	require.NoError(t, writeDOT(&dot, graph))
+	assert.Equal(t, `digraph entities {
+  rankdir=LR;
+  node [shape=box];
+  "A" [label="checkout \"v2\"\nAPM_APPLICATION_ENTITY", style=bold];
+  "B" [label="B"];
+  "A" -> "B" [label="CALLS"];
+}
+`, dot.String())
+
+	var mermaid bytes.Buffer
+	require.NoError(t, writeMermaid(&mermaid, graph))
+	assert.Equal(t, `graph LR
+  n0["checkout #quot;v2#quot;<br/>APM_APPLICATION_ENTITY"]
+  n1["B"]
+  n0 -->|CALLS| n1
+`, mermaid.String())
+}
@@ -0,0 +1,233 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/accounts"
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+	"github.com/newrelic/newrelic-client-go/pkg/nrtime"
+)
+
+const defaultInventoryConcurrency = 5
+
+// inventoryColumns are the columns of the inventory, followed by a column
+// for each tag key asked for.
+var inventoryColumns = []string{
+	"guid",
+	"name",
+	"domain",
+	"type",
+	"entityType",
+	"accountId",
+	"accountName",
+	"reporting",
+	"alertSeverity",
+	"indexedAt",
+	"tags",
+}
+
+// accountLister is the part of the accounts client used to list the
+// accounts visible to the API key.
+type accountLister interface {
+	ListAccountsWithContext(context.Context, accounts.ListAccountsParams) ([]accounts.AccountOutline, error)
+}
+
+// inventoryAccounts returns the accounts to take the inventory of, either
+// every account visible to the API key for "all", or the comma separated
+// account IDs given.
+func inventoryAccounts(ctx context.Context, lister accountLister, accountIDs string) ([]accounts.AccountOutline, error) {
+	visible, err := lister.ListAccountsWithContext(ctx, accounts.ListAccountsParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(strings.TrimSpace(accountIDs), "all") {
+		return visible, nil
+	}
+
+	byID := map[int]accounts.AccountOutline{}
+	for _, a := range visible {
+		byID[a.ID] = a
+	}
+
+	var selected []accounts.AccountOutline
+
+	for _, s := range strings.Split(accountIDs, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid account ID %s, must be a number or all", s)
+		}
+
+		a, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("account %d is not visible to the API key", id)
+		}
+
+		selected = append(selected, a)
+	}
+
+	return selected, nil
+}
+
+// inventoryEntity is the fields of an entity search result in the inventory.
+type inventoryEntity struct {
+	GUID          string                    `json:"guid"`
+	Name          string                    `json:"name"`
+	Domain        string                    `json:"domain"`
+	Type          string                    `json:"type"`
+	EntityType    string                    `json:"entityType"`
+	AccountID     int                       `json:"accountId"`
+	Reporting     bool                      `json:"reporting"`
+	AlertSeverity string                    `json:"alertSeverity"`
+	IndexedAt     *nrtime.EpochMilliseconds `json:"indexedAt"`
+	Tags          []entities.EntityTag      `json:"tags"`
+}
+
+// inventoryRow flattens an entity into a row of the inventory.
+func inventoryRow(e entities.EntityOutlineInterface, accountName string, tagColumns []string) ([]interface{}, error) {
+	content, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields inventoryEntity
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+
+	var indexedAt string
+	if fields.IndexedAt != nil {
+		indexedAt = time.Time(*fields.IndexedAt).UTC().Format(time.RFC3339)
+	}
+
+	values := map[string][]string{}
+	for _, t := range fields.Tags {
+		values[t.Key] = append(values[t.Key], t.Values...)
+	}
+
+	row := []interface{}{
+		fields.GUID,
+		fields.Name,
+		fields.Domain,
+		fields.Type,
+		fields.EntityType,
+		fields.AccountID,
+		accountName,
+		fields.Reporting,
+		fields.AlertSeverity,
+		indexedAt,
+		flattenTags(values),
+	}
+
+	for _, key := range tagColumns {
+		row = append(row, strings.Join(sortedCopy(values[key]), ","))
+	}
+
+	return row, nil
+}
+
+// flattenTags renders tags as key:value pairs separated by semicolons, in
+// order.
+func flattenTags(values map[string][]string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var pairs []string
+
+	for _, k := range keys {
+		for _, v := range sortedCopy(values[k]) {
+			pairs = append(pairs, k+":"+v)
+		}
+	}
+
+	return strings.Join(pairs, ";")
+}
+
+// takeInventory pages through the entities of each account, fetching at
+// most concurrency accounts at a time, and flattens them into a table with
+// the entities of each account in the order the accounts are given.
+func takeInventory(ctx context.Context, client nerdGraphClient, accts []accounts.AccountOutline, tagColumns []string, concurrency int) (*output.Table, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	table := &output.Table{Columns: append([]string{}, inventoryColumns...)}
+	table.Columns = append(table.Columns, tagColumns...)
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		rows     = make([][][]interface{}, len(accts))
+		firstErr error
+	)
+
+	for i, a := range accts {
+		wg.Add(1)
+
+		go func(i int, a accounts.AccountOutline) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			log.WithFields(log.Fields{
+				"accountId": a.ID,
+			}).Debug("taking inventory of account")
+
+			var accountRows [][]interface{}
+
+			err := searchEntities(ctx, client, entitySearchParams{Query: fmt.Sprintf("accountId = %d", a.ID)}, func(page []entities.EntityOutlineInterface) error {
+				for _, e := range page {
+					row, err := inventoryRow(e, a.Name, tagColumns)
+					if err != nil {
+						return err
+					}
+
+					accountRows = append(accountRows, row)
+				}
+
+				return nil
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("unable to list the entities of account %d: %s", a.ID, err)
+				}
+
+				return
+			}
+
+			rows[i] = accountRows
+		}(i, a)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	for _, r := range rows {
+		table.Rows = append(table.Rows, r...)
+	}
+
+	return table, nil
+}
@@ -0,0 +1,85 @@
+// +build unit
+
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/accounts"
+)
+
+type fakeAccountLister struct {
+	accounts []accounts.AccountOutline
+}
+
+func (f fakeAccountLister) ListAccountsWithContext(ctx context.Context, params accounts.ListAccountsParams) ([]accounts.AccountOutline, error) {
+	return f.accounts, nil
+}
+
+func TestInventoryAccounts(t *testing.T) {
+	lister := fakeAccountLister{accounts: []accounts.AccountOutline{{ID: 1, Name: "Prod"}, {ID: 2, Name: "Staging"}}}
+
+	accts, err := inventoryAccounts(context.Background(), lister, "all")
+	require.NoError(t, err)
+	assert.Len(t, accts, 2)
+
+	accts, err = inventoryAccounts(context.Background(), lister, "2, 1")
+	require.NoError(t, err)
+	assert.Equal(t, []accounts.AccountOutline{{ID: 2, Name: "Staging"}, {ID: 1, Name: "Prod"}}, accts)
+
+	_, err = inventoryAccounts(context.Background(), lister, "3")
+	assert.EqualError(t, err, "account 3 is not visible to the API key")
+
+	_, err = inventoryAccounts(context.Background(), lister, "prod")
+	assert.EqualError(t, err, "invalid account ID prod, must be a number or all")
+}
+
+// inventoryNerdGraphClient answers entity searches by account.
+type inventoryNerdGraphClient struct {
+	mu       sync.Mutex
+	entities map[string]string
+}
+
+func (c *inventoryNerdGraphClient) QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	found, ok := c.entities[variables["query"].(string)]
+	if !ok {
+		return errors.New("unknown account")
+	}
+
+	return json.Unmarshal([]byte(fmt.Sprintf(`{"actor":{"entitySearch":{"results":{"entities":[%s]}}}}`, found)), respBody)
+}
+
+func TestTakeInventory(t *testing.T) {
+	client := &inventoryNerdGraphClient{entities: map[string]string{
+		"accountId = 1": `{"__typename":"ApmApplicationEntityOutline","guid":"G1","name":"checkout","domain":"APM","type":"APPLICATION",
+			"entityType":"APM_APPLICATION_ENTITY","accountId":1,"reporting":true,"alertSeverity":"CRITICAL","indexedAt":1600000000000,
+			"tags":[{"key":"team","values":["payments","orders"]},{"key":"env","values":["production"]}]}`,
+		"accountId = 2": `{"__typename":"GenericEntityOutline","guid":"G2","name":"db","domain":"INFRA","type":"HOST","accountId":2}`,
+	}}
+
+	accts := []accounts.AccountOutline{{ID: 2, Name: "Staging"}, {ID: 1, Name: "Prod"}}
+
+	table, err := takeInventory(context.Background(), client, accts, []string{"team"}, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, append(append([]string{}, inventoryColumns...), "team"), table.Columns)
+	assert.Equal(t, [][]interface{}{
+		{"G2", "db", "INFRA", "HOST", "", 2, "Staging", false, "", "", "", ""},
+		{"G1", "checkout", "APM", "APPLICATION", "APM_APPLICATION_ENTITY", 1, "Prod", true, "CRITICAL", "2020-09-13T12:26:40Z",
+			"env:production;team:orders;team:payments", "orders,payments"},
+	}, table.Rows)
+
+	_, err = takeInventory(context.Background(), client, []accounts.AccountOutline{{ID: 3}}, nil, 1)
+	assert.EqualError(t, err, "unable to list the entities of account 3: unknown account")
+}
@@ -0,0 +1,356 @@
+// Package resolver resolves references to New Relic entities, such as their
+// names, to their GUIDs.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// NerdGraphClient is the part of the NerdGraph client used to search for
+// entities.
+type NerdGraphClient interface {
+	QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error
+}
+
+// Selector selects an entity by its name, and optionally its type, domain
+// and account.
+type Selector struct {
+	Name      string
+	Type      string
+	Domain    string
+	AccountID int
+}
+
+// String renders the selector in the form ParseSelector accepts.
+func (s Selector) String() string {
+	parts := []string{"name:" + quote(s.Name)}
+
+	if s.Type != "" {
+		parts = append(parts, "type:"+s.Type)
+	}
+
+	if s.Domain != "" {
+		parts = append(parts, "domain:"+s.Domain)
+	}
+
+	if s.AccountID != 0 {
+		parts = append(parts, "accountId:"+strconv.Itoa(s.AccountID))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func quote(s string) string {
+	if strings.ContainsAny(s, " \t'\"") {
+		return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+	}
+
+	return s
+}
+
+// query returns the entity search query for the selector.
+func (s Selector) query() string {
+	conditions := []string{fmt.Sprintf("name = '%s'", escape(s.Name))}
+
+	if s.Type != "" {
+		conditions = append(conditions, fmt.Sprintf("type = '%s'", escape(s.Type)))
+	}
+
+	if s.Domain != "" {
+		conditions = append(conditions, fmt.Sprintf("domain = '%s'", escape(s.Domain)))
+	}
+
+	if s.AccountID != 0 {
+		conditions = append(conditions, fmt.Sprintf("accountId = %d", s.AccountID))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+func escape(s string) string {
+	return strings.ReplaceAll(s, "'", `\'`)
+}
+
+// ParseSelector parses a selector given as space separated key:value pairs,
+// such as "name:checkout-service type:APPLICATION".  The keys are name, type,
+// domain and accountId, and values containing spaces are quoted.  Words
+// without a key are taken to be the name, as is everything following a
+// name= prefix.
+func ParseSelector(s string) (Selector, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "name=") {
+		return Selector{Name: strings.TrimPrefix(s, "name=")}, nil
+	}
+
+	words, err := split(s)
+	if err != nil {
+		return Selector{}, err
+	}
+
+	var (
+		sel  Selector
+		bare []string
+	)
+
+	for _, w := range words {
+		v := strings.SplitN(w, ":", 2)
+		if len(v) == 1 {
+			bare = append(bare, w)
+			continue
+		}
+
+		key, value := v[0], v[1]
+		if value == "" {
+			return Selector{}, fmt.Errorf("no value given for %s in entity %s", key, s)
+		}
+
+		switch strings.ToLower(key) {
+		case "name":
+			sel.Name = value
+		case "type":
+			sel.Type = strings.ToUpper(value)
+		case "domain":
+			sel.Domain = strings.ToUpper(value)
+		case "accountid", "account":
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return Selector{}, fmt.Errorf("invalid account ID %s in entity %s", value, s)
+			}
+
+			sel.AccountID = id
+		default:
+			return Selector{}, fmt.Errorf("unknown key %s in entity %s, must be one of name, type, domain or accountId", key, s)
+		}
+	}
+
+	if len(bare) > 0 {
+		if sel.Name != "" {
+			return Selector{}, fmt.Errorf("entity %s gives a name more than once", s)
+		}
+
+		sel.Name = strings.Join(bare, " ")
+	}
+
+	if sel.Name == "" {
+		return Selector{}, fmt.Errorf("no name given in entity %s", s)
+	}
+
+	return sel, nil
+}
+
+// split splits s into words separated by spaces, where quoted parts of a
+// word may contain spaces.
+func split(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		q       rune
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && q != 0:
+			escaped = true
+		case q != 0:
+			if r == q {
+				q = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			q = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if q != 0 {
+		return nil, fmt.Errorf("unterminated quote in entity %s", s)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// ParseReference parses a value given where a GUID is accepted.  A value
+// with a name= prefix or containing key:value pairs is parsed as a selector,
+// and anything else is taken to be a GUID, in which case nil is returned.
+func ParseReference(ref string) (*Selector, error) {
+	if !strings.HasPrefix(ref, "name=") && !strings.Contains(ref, ":") {
+		return nil, nil
+	}
+
+	sel, err := ParseSelector(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sel, nil
+}
+
+// sessionCache holds the GUIDs resolved by every resolver, so an entity is
+// only searched for once each time the CLI is run.
+var sessionCache = &cache{guids: map[string]string{}}
+
+type cache struct {
+	mu    sync.Mutex
+	guids map[string]string
+}
+
+func (c *cache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	guid, ok := c.guids[key]
+
+	return guid, ok
+}
+
+func (c *cache) set(key string, guid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.guids[key] = guid
+}
+
+// Resolver resolves entity references to GUIDs.
+type Resolver struct {
+	client NerdGraphClient
+	cache  *cache
+}
+
+// New returns a resolver searching for entities with the client, sharing
+// the GUIDs resolved with the other resolvers.
+func New(client NerdGraphClient) *Resolver {
+	return &Resolver{client: client, cache: sessionCache}
+}
+
+// Resolve returns the GUID of the entity referred to by a value given where
+// a GUID is accepted, as parsed by ParseReference.  GUIDs are returned as
+// they are.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	sel, err := ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if sel == nil {
+		return ref, nil
+	}
+
+	return r.ResolveSelector(ctx, *sel)
+}
+
+// ResolveAll resolves each of the references.
+func (r *Resolver) ResolveAll(ctx context.Context, refs []string) ([]string, error) {
+	guids := make([]string, len(refs))
+
+	for i, ref := range refs {
+		guid, err := r.Resolve(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		guids[i] = guid
+	}
+
+	return guids, nil
+}
+
+type match struct {
+	GUID      string `json:"guid"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Domain    string `json:"domain"`
+	AccountID int    `json:"accountId"`
+}
+
+type searchResponse struct {
+	Actor struct {
+		EntitySearch struct {
+			Results struct {
+				Entities []match `json:"entities"`
+			} `json:"results"`
+		} `json:"entitySearch"`
+	} `json:"actor"`
+}
+
+// ResolveSelector returns the GUID of the one entity the selector matches.
+// Entities are matched by their whole name, ignoring case, and it is an
+// error for none or several entities to match.
+func (r *Resolver) ResolveSelector(ctx context.Context, sel Selector) (string, error) {
+	key := sel.String()
+
+	if guid, ok := r.cache.get(key); ok {
+		return guid, nil
+	}
+
+	log.WithFields(log.Fields{
+		"entity": key,
+	}).Debug("resolving entity GUID")
+
+	var resp searchResponse
+	if err := r.client.QueryWithResponseAndContext(ctx, searchQuery, map[string]interface{}{"query": sel.query()}, &resp); err != nil {
+		return "", err
+	}
+
+	var matches []match
+
+	for _, m := range resp.Actor.EntitySearch.Results.Entities {
+		if strings.EqualFold(m.Name, sel.Name) {
+			matches = append(matches, m)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no entity found matching %s", key)
+	case 1:
+		r.cache.set(key, matches[0].GUID)
+
+		return matches[0].GUID, nil
+	}
+
+	candidates := make([]string, len(matches))
+	for i, m := range matches {
+		candidates[i] = fmt.Sprintf("%s (%s %s in account %d, GUID %s)", m.Name, m.Domain, m.Type, m.AccountID, m.GUID)
+	}
+
+	return "", fmt.Errorf("%d entities match %s, add type:, domain: or accountId: to select one of %s",
+		len(matches), key, strings.Join(candidates, ", "))
+}
+
+const searchQuery = `query($query: String) { actor { entitySearch(query: $query) {
+	results {
+		entities {
+			guid
+			name
+			type
+			domain
+			accountId
+		}
+	}
+} } }`
@@ -0,0 +1,125 @@
+// +build unit
+
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	cases := map[string]Selector{
+		"name:checkout-service type:APPLICATION": {Name: "checkout-service", Type: "APPLICATION"},
+		"name:'checkout service' domain:apm":     {Name: "checkout service", Domain: "APM"},
+		`name:"it's" accountId:123`:              {Name: "it's", AccountID: 123},
+		"checkout service type:application":      {Name: "checkout service", Type: "APPLICATION"},
+		"name=checkout service type:APPLICATION": {Name: "checkout service type:APPLICATION"},
+	}
+
+	for s, expected := range cases {
+		sel, err := ParseSelector(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, sel, s)
+
+		// Selectors render in a form that parses back to themselves
+		parsed, err := ParseSelector(sel.String())
+		require.NoError(t, err, sel.String())
+		assert.Equal(t, sel, parsed, sel.String())
+	}
+}
+
+func TestParseSelectorInvalid(t *testing.T) {
+	cases := map[string]string{
+		"type:APPLICATION":           "no name given in entity type:APPLICATION",
+		"name:x owner:alice":         "unknown key owner in entity name:x owner:alice, must be one of name, type, domain or accountId",
+		"name:x accountId:abc":       "invalid account ID abc in entity name:x accountId:abc",
+		"name:'x":                    "unterminated quote in entity name:'x",
+		"name: type:APPLICATION":     "no value given for name in entity name: type:APPLICATION",
+		"checkout name:checkout-svc": "entity checkout name:checkout-svc gives a name more than once",
+	}
+
+	for s, message := range cases {
+		_, err := ParseSelector(s)
+		assert.EqualError(t, err, message, s)
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	sel, err := ParseReference("MXxBUE18QVBQTElDQVRJT058MQ")
+	require.NoError(t, err)
+	assert.Nil(t, sel)
+
+	sel, err = ParseReference("name=checkout")
+	require.NoError(t, err)
+	assert.Equal(t, &Selector{Name: "checkout"}, sel)
+
+	sel, err = ParseReference("name:checkout type:APPLICATION")
+	require.NoError(t, err)
+	assert.Equal(t, &Selector{Name: "checkout", Type: "APPLICATION"}, sel)
+}
+
+func TestSelectorQuery(t *testing.T) {
+	sel := Selector{Name: "it's", Type: "APPLICATION", Domain: "APM", AccountID: 1}
+
+	assert.Equal(t, `name = 'it\'s' AND type = 'APPLICATION' AND domain = 'APM' AND accountId = 1`, sel.query())
+}
+
+// fakeNerdGraphClient answers entity searches with the same entities,
+// counting the searches made.
+type fakeNerdGraphClient struct {
+	body     string
+	searches []string
+}
+
+func (c *fakeNerdGraphClient) QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error {
+	c.searches = append(c.searches, variables["query"].(string))
+
+	return json.Unmarshal([]byte(c.body), respBody)
+}
+
+func newTestResolver(body string) (*Resolver, *fakeNerdGraphClient) {
+	client := &fakeNerdGraphClient{body: body}
+
+	return &Resolver{client: client, cache: &cache{guids: map[string]string{}}}, client
+}
+
+func TestResolve(t *testing.T) {
+	r, client := newTestResolver(`{"actor":{"entitySearch":{"results":{"entities":[
+		{"guid":"G1","name":"Checkout","type":"APPLICATION","domain":"APM","accountId":1},
+		{"guid":"G2","name":"checkout-v2","type":"APPLICATION","domain":"APM","accountId":1}
+	]}}}}`)
+
+	guid, err := r.Resolve(context.Background(), "name=checkout")
+	require.NoError(t, err)
+	assert.Equal(t, "G1", guid)
+
+	// Resolutions are cached
+	guids, err := r.ResolveAll(context.Background(), []string{"name:checkout", "GUID"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"G1", "GUID"}, guids)
+	assert.Equal(t, []string{"name = 'checkout'"}, client.searches)
+}
+
+func TestResolveNoMatch(t *testing.T) {
+	r, _ := newTestResolver(`{"actor":{"entitySearch":{"results":{"entities":[
+		{"guid":"G2","name":"checkout-v2","type":"APPLICATION","domain":"APM","accountId":1}
+	]}}}}`)
+
+	_, err := r.Resolve(context.Background(), "name:checkout type:APPLICATION")
+	assert.EqualError(t, err, "no entity found matching name:checkout type:APPLICATION")
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	r, _ := newTestResolver(`{"actor":{"entitySearch":{"results":{"entities":[
+		{"guid":"G1","name":"checkout","type":"APPLICATION","domain":"APM","accountId":1},
+		{"guid":"G2","name":"checkout","type":"APPLICATION","domain":"BROWSER","accountId":2}
+	]}}}}`)
+
+	_, err := r.Resolve(context.Background(), "name=checkout")
+	assert.EqualError(t, err, "2 entities match name:checkout, add type:, domain: or accountId: to select one of "+
+		"checkout (APM APPLICATION in account 1, GUID G1), checkout (BROWSER APPLICATION in account 2, GUID G2)")
+}
@@ -0,0 +1,178 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+// nerdGraphClient is the part of the NerdGraph client used to run queries
+// that newrelic-client-go does not provide.
+type nerdGraphClient interface {
+	QueryWithResponseAndContext(context.Context, string, map[string]interface{}, interface{}) error
+}
+
+// entitySearchParams describes an entity search.  Either Query or
+// QueryBuilder selects the entities.  A Limit of 0 returns every entity.
+type entitySearchParams struct {
+	Query        string
+	QueryBuilder entities.EntitySearchQueryBuilder
+	SortBy       []entities.EntitySearchSortCriteria
+	Limit        int
+}
+
+type entitySearchResponse struct {
+	Actor struct {
+		EntitySearch entities.EntitySearch `json:"entitySearch"`
+	} `json:"actor"`
+}
+
+// searchEntities runs an entity search, following nextCursor until every page
+// has been returned or the limit is reached.  Each page is passed to fn.
+func searchEntities(ctx context.Context, client nerdGraphClient, params entitySearchParams, fn func([]entities.EntityOutlineInterface) error) error {
+	vars := map[string]interface{}{}
+
+	if params.Query != "" {
+		vars["query"] = params.Query
+	} else {
+		vars["queryBuilder"] = params.QueryBuilder
+	}
+
+	if len(params.SortBy) > 0 {
+		vars["sortBy"] = params.SortBy
+	}
+
+	seen := map[string]bool{}
+	returned := 0
+
+	for page := 1; ; page++ {
+		var resp entitySearchResponse
+		if err := client.QueryWithResponseAndContext(ctx, entitySearchQuery, vars, &resp); err != nil {
+			return err
+		}
+
+		results := resp.Actor.EntitySearch.Results
+		found := results.Entities
+
+		if params.Limit > 0 && returned+len(found) > params.Limit {
+			found = found[:params.Limit-returned]
+		}
+
+		returned += len(found)
+
+		log.WithFields(log.Fields{
+			"page":     page,
+			"entities": returned,
+			"count":    resp.Actor.EntitySearch.Count,
+		}).Debug("received entity search results")
+
+		if err := fn(found); err != nil {
+			return err
+		}
+
+		if results.NextCursor == "" || params.Limit > 0 && returned >= params.Limit {
+			return nil
+		}
+
+		if seen[results.NextCursor] {
+			return fmt.Errorf("NerdGraph returned the cursor %s more than once", results.NextCursor)
+		}
+
+		seen[results.NextCursor] = true
+		vars["cursor"] = results.NextCursor
+	}
+}
+
+// parseSortCriteria reads a list of sort criteria such as NAME and DOMAIN,
+// ignoring case.
+func parseSortCriteria(values []string) ([]entities.EntitySearchSortCriteria, error) {
+	valid := []entities.EntitySearchSortCriteria{
+		entities.EntitySearchSortCriteriaTypes.ALERT_SEVERITY,
+		entities.EntitySearchSortCriteriaTypes.DOMAIN,
+		entities.EntitySearchSortCriteriaTypes.MOST_RELEVANT,
+		entities.EntitySearchSortCriteriaTypes.NAME,
+		entities.EntitySearchSortCriteriaTypes.REPORTING,
+		entities.EntitySearchSortCriteriaTypes.TYPE,
+	}
+
+	var criteria []entities.EntitySearchSortCriteria
+
+	for _, v := range values {
+		c := entities.EntitySearchSortCriteria(strings.ToUpper(strings.TrimSpace(v)))
+
+		ok := false
+		for _, x := range valid {
+			if c == x {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
+			names := make([]string, len(valid))
+			for i, x := range valid {
+				names[i] = string(x)
+			}
+
+			return nil, fmt.Errorf("unknown sort criteria %s, must be one of %s", v, strings.Join(names, ", "))
+		}
+
+		criteria = append(criteria, c)
+	}
+
+	return criteria, nil
+}
+
+const entitySearchQuery = `query(
+	$query: String,
+	$queryBuilder: EntitySearchQueryBuilder,
+	$sortBy: [EntitySearchSortCriteria],
+	$cursor: String,
+) { actor { entitySearch(
+	query: $query,
+	queryBuilder: $queryBuilder,
+	sortBy: $sortBy,
+) {
+	count
+	results(cursor: $cursor) {
+		nextCursor
+		entities {
+			__typename
+			accountId
+			domain
+			entityType
+			guid
+			indexedAt
+			name
+			permalink
+			reporting
+			type
+			tags {
+				key
+				values
+			}
+			... on AlertableEntityOutline {
+				alertSeverity
+			}
+			... on ApmApplicationEntityOutline {
+				applicationId
+				language
+			}
+			... on BrowserApplicationEntityOutline {
+				applicationId
+			}
+			... on MobileApplicationEntityOutline {
+				applicationId
+			}
+			... on SyntheticMonitorEntityOutline {
+				monitorId
+				monitorType
+				monitoredUrl
+			}
+		}
+	}
+} } }`
@@ -0,0 +1,143 @@
+// +build unit
+
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+// fakeNerdGraphClient returns a page of entity search results for each
+// cursor.
+type fakeNerdGraphClient struct {
+	pages     map[string]string
+	variables []map[string]interface{}
+}
+
+func (f *fakeNerdGraphClient) QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error {
+	vars := map[string]interface{}{}
+	for k, v := range variables {
+		vars[k] = v
+	}
+
+	f.variables = append(f.variables, vars)
+
+	cursor, _ := variables["cursor"].(string)
+
+	body, ok := f.pages[cursor]
+	if !ok {
+		return fmt.Errorf("unexpected cursor %s", cursor)
+	}
+
+	return json.Unmarshal([]byte(body), respBody)
+}
+
+func newFakeNerdGraphClient() *fakeNerdGraphClient {
+	return &fakeNerdGraphClient{
+		pages: map[string]string{
+			"":   `{"actor":{"entitySearch":{"count":3,"results":{"nextCursor":"c1","entities":[{"__typename":"ApmApplicationEntityOutline","guid":"a","name":"A","alertSeverity":"CRITICAL"},{"__typename":"GenericEntityOutline","guid":"b","name":"B"}]}}}}`,
+			"c1": `{"actor":{"entitySearch":{"count":3,"results":{"entities":[{"__typename":"GenericEntityOutline","guid":"c","name":"C","tags":[{"key":"team","values":["x"]}]}]}}}}`,
+		},
+	}
+}
+
+func collectEntities(t *testing.T, client nerdGraphClient, params entitySearchParams) []entities.EntityOutlineInterface {
+	var found []entities.EntityOutlineInterface
+
+	err := searchEntities(context.Background(), client, params, func(page []entities.EntityOutlineInterface) error {
+		found = append(found, page...)
+		return nil
+	})
+	require.NoError(t, err)
+
+	return found
+}
+
+func TestSearchEntitiesPaginates(t *testing.T) {
+	client := newFakeNerdGraphClient()
+
+	found := collectEntities(t, client, entitySearchParams{
+		Query:  "domain = 'APM'",
+		SortBy: []entities.EntitySearchSortCriteria{entities.EntitySearchSortCriteriaTypes.NAME},
+	})
+
+	require.Len(t, found, 3)
+	assert.Equal(t, entities.EntityGUID("c"), found[2].GetGUID())
+
+	apm, ok := found[0].(*entities.ApmApplicationEntityOutline)
+	require.True(t, ok)
+	assert.Equal(t, entities.EntityAlertSeverity("CRITICAL"), apm.AlertSeverity)
+
+	require.Len(t, client.variables, 2)
+	assert.Equal(t, "domain = 'APM'", client.variables[0]["query"])
+	assert.NotContains(t, client.variables[0], "queryBuilder")
+	assert.NotContains(t, client.variables[0], "cursor")
+	assert.Equal(t, "c1", client.variables[1]["cursor"])
+}
+
+func TestSearchEntitiesLimit(t *testing.T) {
+	client := newFakeNerdGraphClient()
+
+	found := collectEntities(t, client, entitySearchParams{
+		QueryBuilder: entities.EntitySearchQueryBuilder{Name: "A"},
+		Limit:        1,
+	})
+
+	require.Len(t, found, 1)
+	assert.Len(t, client.variables, 1)
+	assert.Contains(t, client.variables[0], "queryBuilder")
+	assert.NotContains(t, client.variables[0], "sortBy")
+
+	found = collectEntities(t, newFakeNerdGraphClient(), entitySearchParams{Query: "x", Limit: 2})
+	assert.Len(t, found, 2)
+}
+
+func TestSearchEntitiesRepeatedCursor(t *testing.T) {
+	client := newFakeNerdGraphClient()
+	client.pages["c1"] = client.pages[""]
+
+	err := searchEntities(context.Background(), client, entitySearchParams{Query: "x"}, func([]entities.EntityOutlineInterface) error { return nil })
+	assert.EqualError(t, err, "NerdGraph returned the cursor c1 more than once")
+}
+
+func TestParseSortCriteria(t *testing.T) {
+	criteria, err := parseSortCriteria([]string{"name", " DOMAIN"})
+	require.NoError(t, err)
+	assert.Equal(t, []entities.EntitySearchSortCriteria{"NAME", "DOMAIN"}, criteria)
+
+	_, err = parseSortCriteria([]string{"SIZE"})
+	assert.EqualError(t, err, "unknown sort criteria SIZE, must be one of ALERT_SEVERITY, DOMAIN, MOST_RELEVANT, NAME, REPORTING, TYPE")
+}
+
+func TestBuildEntitySearchParams(t *testing.T) {
+	defer func() {
+		entityQuery, entityDomain, entityTags = "", "", nil
+	}()
+
+	entityQuery = "domain = 'APM'"
+	entityDomain = "APM"
+
+	_, err := buildEntitySearchParams()
+	assert.Error(t, err)
+
+	entityQuery = ""
+	entityTags = []string{"team:payments", "env:prod"}
+
+	params, err := buildEntitySearchParams()
+	require.NoError(t, err)
+	assert.Equal(t, entities.EntitySearchQueryBuilderDomain("APM"), params.QueryBuilder.Domain)
+	assert.Len(t, params.QueryBuilder.Tags, 2)
+
+	entityDomain = ""
+	entityTags = nil
+
+	_, err = buildEntitySearchParams()
+	assert.Error(t, err)
+}
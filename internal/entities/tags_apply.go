@@ -0,0 +1,303 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+const (
+	defaultTagApplyConcurrency = 5
+	defaultTagApplyRate        = 10
+
+	tagApplyStatusApplied   = "applied"
+	tagApplyStatusFailed    = "failed"
+	tagApplyStatusUnchanged = "unchanged"
+)
+
+// tagChange is the difference between the tags of an entity and the tags to
+// be applied to it.
+type tagChange struct {
+	GUID   string                          `json:"guid" yaml:"guid"`
+	Name   string                          `json:"name" yaml:"name"`
+	Add    []entities.TaggingTagInput      `json:"add,omitempty" yaml:"add,omitempty"`
+	Remove []entities.TaggingTagValueInput `json:"remove,omitempty" yaml:"remove,omitempty"`
+	Delete []string                        `json:"delete,omitempty" yaml:"delete,omitempty"`
+}
+
+// Empty reports whether the entity's tags already match.
+func (c tagChange) Empty() bool {
+	return len(c.Add) == 0 && len(c.Remove) == 0 && len(c.Delete) == 0
+}
+
+// String renders the change as a diff, one tag value per line.
+func (c tagChange) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s (%s)\n", c.Name, c.GUID)
+
+	for _, t := range c.Add {
+		for _, v := range t.Values {
+			fmt.Fprintf(&b, "  + %s:%s\n", t.Key, v)
+		}
+	}
+
+	for _, t := range c.Remove {
+		fmt.Fprintf(&b, "  - %s:%s\n", t.Key, t.Value)
+	}
+
+	for _, key := range c.Delete {
+		fmt.Fprintf(&b, "  - %s\n", key)
+	}
+
+	return b.String()
+}
+
+// diffTags compares an entity's tags with the tags to apply.  Each key given
+// is set to exactly the values given, so values of those keys that are not
+// given are removed.  Other keys are left alone.
+func diffTags(current []entities.EntityTag, desired []entities.TaggingTagInput) ([]entities.TaggingTagInput, []entities.TaggingTagValueInput) {
+	existing := map[string]map[string]bool{}
+	for _, t := range current {
+		if existing[t.Key] == nil {
+			existing[t.Key] = map[string]bool{}
+		}
+
+		for _, v := range t.Values {
+			existing[t.Key][v] = true
+		}
+	}
+
+	wanted := map[string]map[string]bool{}
+	for _, t := range desired {
+		if wanted[t.Key] == nil {
+			wanted[t.Key] = map[string]bool{}
+		}
+
+		for _, v := range t.Values {
+			wanted[t.Key][v] = true
+		}
+	}
+
+	var add []entities.TaggingTagInput
+	var remove []entities.TaggingTagValueInput
+
+	for _, key := range sortedKeys(wanted) {
+		var values []string
+
+		for _, v := range sortedValues(wanted[key]) {
+			if !existing[key][v] {
+				values = append(values, v)
+			}
+		}
+
+		if len(values) > 0 {
+			add = append(add, entities.TaggingTagInput{Key: key, Values: values})
+		}
+
+		for _, v := range sortedValues(existing[key]) {
+			if !wanted[key][v] {
+				remove = append(remove, entities.TaggingTagValueInput{Key: key, Value: v})
+			}
+		}
+	}
+
+	return add, remove
+}
+
+func sortedKeys(m map[string]map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedValues(m map[string]bool) []string {
+	values := make([]string, 0, len(m))
+	for v := range m {
+		values = append(values, v)
+	}
+
+	sort.Strings(values)
+
+	return values
+}
+
+// outlineTags returns the tags of an entity search result, which are not
+// available through EntityOutlineInterface.
+func outlineTags(e entities.EntityOutlineInterface) ([]entities.EntityTag, error) {
+	content, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	var outline struct {
+		Tags []entities.EntityTag `json:"tags"`
+	}
+
+	if err := json.Unmarshal(content, &outline); err != nil {
+		return nil, err
+	}
+
+	return outline.Tags, nil
+}
+
+// tagMutator is the part of the entities client used to change tags.
+type tagMutator interface {
+	TaggingAddTagsToEntity(entities.EntityGUID, []entities.TaggingTagInput) (*entities.TaggingMutationResult, error)
+	TaggingDeleteTagFromEntity(entities.EntityGUID, []string) (*entities.TaggingMutationResult, error)
+	TaggingDeleteTagValuesFromEntity(entities.EntityGUID, []entities.TaggingTagValueInput) (*entities.TaggingMutationResult, error)
+}
+
+// tagApplyResult is the outcome of applying a change to an entity.
+type tagApplyResult struct {
+	tagChange `yaml:",inline"`
+	Status    string `json:"status" yaml:"status"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// applyTagChanges applies the changes to at most concurrency entities at a
+// time, making at most rate mutations a second.  Values are added before
+// others are removed, so a key being changed is never left without values,
+// and keys no longer wanted are deleted last.
+func applyTagChanges(ctx context.Context, mutator tagMutator, changes []tagChange, concurrency int, rate int) []tagApplyResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	if rate < 1 {
+		rate = 1
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+
+	wait := func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			return nil
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		results = make([]tagApplyResult, len(changes))
+	)
+
+	for i, c := range changes {
+		results[i] = tagApplyResult{tagChange: c, Status: tagApplyStatusUnchanged}
+
+		if c.Empty() {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int, c tagChange) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := applyTagChange(mutator, c, wait); err != nil {
+				log.Debugf("unable to apply tags to %s: %s", c.GUID, err)
+
+				results[i].Status = tagApplyStatusFailed
+				results[i].Error = err.Error()
+
+				return
+			}
+
+			results[i].Status = tagApplyStatusApplied
+		}(i, c)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func applyTagChange(mutator tagMutator, c tagChange, wait func() error) error {
+	guid := entities.EntityGUID(c.GUID)
+
+	if len(c.Add) > 0 {
+		if err := wait(); err != nil {
+			return err
+		}
+
+		result, err := mutator.TaggingAddTagsToEntity(guid, c.Add)
+		if err := tagMutationError(result, err); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Remove) > 0 {
+		if err := wait(); err != nil {
+			return err
+		}
+
+		result, err := mutator.TaggingDeleteTagValuesFromEntity(guid, c.Remove)
+		if err := tagMutationError(result, err); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Delete) > 0 {
+		if err := wait(); err != nil {
+			return err
+		}
+
+		result, err := mutator.TaggingDeleteTagFromEntity(guid, c.Delete)
+		if err := tagMutationError(result, err); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tagMutationError returns the request's error, or the errors reported in
+// the mutation's result.
+func tagMutationError(result *entities.TaggingMutationResult, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if result == nil || len(result.Errors) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(result.Errors))
+	for i, e := range result.Errors {
+		messages[i] = e.Message
+	}
+
+	return errors.New(strings.Join(messages, ", "))
+}
+
+// countTagApplyResults counts the results with each status.
+func countTagApplyResults(results []tagApplyResult) map[string]int {
+	counts := map[string]int{}
+
+	for _, r := range results {
+		counts[r.Status]++
+	}
+
+	return counts
+}
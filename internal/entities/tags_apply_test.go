@@ -0,0 +1,161 @@
+// +build unit
+
+package entities
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+func TestDiffTags(t *testing.T) {
+	current := []entities.EntityTag{
+		{Key: "team", Values: []string{"orders", "payments"}},
+		{Key: "env", Values: []string{"production"}},
+		{Key: "language", Values: []string{"go"}},
+	}
+
+	add, remove := diffTags(current, []entities.TaggingTagInput{
+		{Key: "team", Values: []string{"payments", "checkout"}},
+		{Key: "env", Values: []string{"production"}},
+		{Key: "owner", Values: []string{"alice"}},
+	})
+
+	assert.Equal(t, []entities.TaggingTagInput{
+		{Key: "owner", Values: []string{"alice"}},
+		{Key: "team", Values: []string{"checkout"}},
+	}, add)
+	assert.Equal(t, []entities.TaggingTagValueInput{
+		{Key: "team", Value: "orders"},
+	}, remove)
+
+	add, remove = diffTags(current, []entities.TaggingTagInput{{Key: "env", Values: []string{"production"}}})
+	assert.Empty(t, add)
+	assert.Empty(t, remove)
+}
+
+func TestTagChangeString(t *testing.T) {
+	c := tagChange{
+		GUID:   "G1",
+		Name:   "checkout",
+		Add:    []entities.TaggingTagInput{{Key: "team", Values: []string{"payments"}}},
+		Remove: []entities.TaggingTagValueInput{{Key: "team", Value: "orders"}},
+		Delete: []string{"owner"},
+	}
+
+	assert.Equal(t, "checkout (G1)\n  + team:payments\n  - team:orders\n  - owner\n", c.String())
+	assert.False(t, c.Empty())
+	assert.True(t, tagChange{GUID: "G2"}.Empty())
+}
+
+func TestOutlineTags(t *testing.T) {
+	tags, err := outlineTags(&entities.GenericEntityOutline{
+		GUID: "G1",
+		Tags: []entities.EntityTag{{Key: "team", Values: []string{"payments"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []entities.EntityTag{{Key: "team", Values: []string{"payments"}}}, tags)
+}
+
+// fakeTagMutator records the mutations made, failing for some entities.
+type fakeTagMutator struct {
+	mu      sync.Mutex
+	added   map[entities.EntityGUID][]entities.TaggingTagInput
+	removed map[entities.EntityGUID][]entities.TaggingTagValueInput
+	deleted map[entities.EntityGUID][]string
+}
+
+func newFakeTagMutator() *fakeTagMutator {
+	return &fakeTagMutator{
+		added:   map[entities.EntityGUID][]entities.TaggingTagInput{},
+		removed: map[entities.EntityGUID][]entities.TaggingTagValueInput{},
+		deleted: map[entities.EntityGUID][]string{},
+	}
+}
+
+func (f *fakeTagMutator) TaggingAddTagsToEntity(guid entities.EntityGUID, tags []entities.TaggingTagInput) (*entities.TaggingMutationResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch guid {
+	case "error":
+		return nil, errors.New("request failed")
+	case "immutable":
+		return &entities.TaggingMutationResult{Errors: []entities.TaggingMutationError{{Message: "tag is immutable"}}}, nil
+	}
+
+	f.added[guid] = tags
+
+	return &entities.TaggingMutationResult{}, nil
+}
+
+func (f *fakeTagMutator) TaggingDeleteTagFromEntity(guid entities.EntityGUID, keys []string) (*entities.TaggingMutationResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deleted[guid] = keys
+
+	return &entities.TaggingMutationResult{}, nil
+}
+
+func (f *fakeTagMutator) TaggingDeleteTagValuesFromEntity(guid entities.EntityGUID, values []entities.TaggingTagValueInput) (*entities.TaggingMutationResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.removed[guid] = values
+
+	return &entities.TaggingMutationResult{}, nil
+}
+
+func TestApplyTagChanges(t *testing.T) {
+	add := []entities.TaggingTagInput{{Key: "team", Values: []string{"payments"}}}
+	remove := []entities.TaggingTagValueInput{{Key: "team", Value: "orders"}}
+
+	changes := []tagChange{
+		{GUID: "G1", Add: add, Remove: remove},
+		{GUID: "G2"},
+		{GUID: "G3", Remove: remove, Delete: []string{"owner"}},
+		{GUID: "error", Add: add, Remove: remove},
+		{GUID: "immutable", Add: add},
+	}
+
+	mutator := newFakeTagMutator()
+
+	results := applyTagChanges(context.Background(), mutator, changes, 2, 1000)
+	require.Len(t, results, 5)
+
+	assert.Equal(t, tagApplyStatusApplied, results[0].Status)
+	assert.Equal(t, tagApplyStatusUnchanged, results[1].Status)
+	assert.Equal(t, tagApplyStatusApplied, results[2].Status)
+	assert.Equal(t, tagApplyStatusFailed, results[3].Status)
+	assert.Equal(t, "request failed", results[3].Error)
+	assert.Equal(t, tagApplyStatusFailed, results[4].Status)
+	assert.Equal(t, "tag is immutable", results[4].Error)
+
+	assert.Equal(t, add, mutator.added["G1"])
+	assert.Equal(t, remove, mutator.removed["G1"])
+	assert.Equal(t, remove, mutator.removed["G3"])
+	assert.Equal(t, []string{"owner"}, mutator.deleted["G3"])
+
+	// Values are not removed once adding the new values has failed
+	assert.NotContains(t, mutator.removed, entities.EntityGUID("error"))
+
+	assert.Equal(t, map[string]int{"applied": 2, "unchanged": 1, "failed": 2}, countTagApplyResults(results))
+}
+
+func TestApplyTagChangesCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	changes := []tagChange{{GUID: "G1", Add: []entities.TaggingTagInput{{Key: "team", Values: []string{"payments"}}}}}
+
+	results := applyTagChanges(ctx, newFakeTagMutator(), changes, 1, 1)
+	assert.Equal(t, tagApplyStatusFailed, results[0].Status)
+	assert.Equal(t, "context canceled", results[0].Error)
+}
@@ -0,0 +1,248 @@
+package entities
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+const (
+	tagViolationMissing    = "missing"
+	tagViolationNotAllowed = "not allowed"
+
+	// tagAuditNoGroup is the group of entities without the tag grouped by.
+	tagAuditNoGroup = "(none)"
+)
+
+// tagRule is the values allowed for a tag key, either a set of values or a
+// pattern the whole value must match.
+type tagRule struct {
+	Key     string
+	Values  map[string]bool
+	Pattern *regexp.Regexp
+}
+
+func (r *tagRule) allows(value string) bool {
+	if r.Pattern != nil {
+		return r.Pattern.MatchString(value)
+	}
+
+	return r.Values[value]
+}
+
+// tagPolicy is the tags every entity is required to have, and the values
+// allowed for each key.
+type tagPolicy struct {
+	Required []string
+	Rules    map[string]*tagRule
+}
+
+// parseTagPolicy builds a policy from the required keys, the allowed values
+// given as key=value1|value2, and the allowed patterns given as key=regex.
+func parseTagPolicy(required []string, allowed []string, patterns []string) (*tagPolicy, error) {
+	policy := &tagPolicy{Rules: map[string]*tagRule{}}
+
+	for _, key := range required {
+		if key = strings.TrimSpace(key); key != "" {
+			policy.Required = append(policy.Required, key)
+		}
+	}
+
+	for _, a := range allowed {
+		key, values, err := splitTagRule(a)
+		if err != nil {
+			return nil, err
+		}
+
+		rule := &tagRule{Key: key, Values: map[string]bool{}}
+		for _, v := range strings.Split(values, "|") {
+			rule.Values[v] = true
+		}
+
+		if err := policy.addRule(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range patterns {
+		key, pattern, err := splitTagRule(p)
+		if err != nil {
+			return nil, err
+		}
+
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for tag %s: %s", key, err)
+		}
+
+		if err := policy.addRule(&tagRule{Key: key, Pattern: re}); err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+func (p *tagPolicy) addRule(rule *tagRule) error {
+	if _, ok := p.Rules[rule.Key]; ok {
+		return fmt.Errorf("the allowed values of tag %s are given more than once", rule.Key)
+	}
+
+	p.Rules[rule.Key] = rule
+
+	return nil
+}
+
+func splitTagRule(s string) (string, string, error) {
+	v := strings.SplitN(s, "=", 2)
+
+	if len(v) != 2 || v[0] == "" || v[1] == "" {
+		return "", "", fmt.Errorf("allowed tag values must be given as key=values, not %s", s)
+	}
+
+	return v[0], v[1], nil
+}
+
+// ruleKeys returns the keys with allowed values, in order.
+func (p *tagPolicy) ruleKeys() []string {
+	keys := make([]string, 0, len(p.Rules))
+	for k := range p.Rules {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// tagViolation is an entity missing a required tag, or with a value that is
+// not allowed.
+type tagViolation struct {
+	GUID      string `json:"guid" yaml:"guid"`
+	Name      string `json:"name" yaml:"name"`
+	Group     string `json:"group" yaml:"group"`
+	Key       string `json:"key" yaml:"key"`
+	Violation string `json:"violation" yaml:"violation"`
+	Value     string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// tagAudit is the result of auditing an entity's tags.
+type tagAudit struct {
+	GUID       string
+	Name       string
+	Group      string
+	Violations []tagViolation
+}
+
+// auditEntityTags checks an entity's tags against the policy.  The entity is
+// grouped by the values of the groupBy key.
+func auditEntityTags(e entities.EntityOutlineInterface, tags []entities.EntityTag, policy *tagPolicy, groupBy string) tagAudit {
+	values := map[string][]string{}
+	for _, t := range tags {
+		values[t.Key] = append(values[t.Key], t.Values...)
+	}
+
+	a := tagAudit{
+		GUID:  string(e.GetGUID()),
+		Name:  e.GetName(),
+		Group: tagAuditNoGroup,
+	}
+
+	if g := sortedCopy(values[groupBy]); len(g) > 0 {
+		a.Group = strings.Join(g, ",")
+	}
+
+	violation := func(key string, kind string, value string) tagViolation {
+		return tagViolation{GUID: a.GUID, Name: a.Name, Group: a.Group, Key: key, Violation: kind, Value: value}
+	}
+
+	for _, key := range policy.Required {
+		if len(values[key]) == 0 {
+			a.Violations = append(a.Violations, violation(key, tagViolationMissing, ""))
+		}
+	}
+
+	for _, key := range policy.ruleKeys() {
+		for _, v := range sortedCopy(values[key]) {
+			if !policy.Rules[key].allows(v) {
+				a.Violations = append(a.Violations, violation(key, tagViolationNotAllowed, v))
+			}
+		}
+	}
+
+	return a
+}
+
+// summarizeTagAudits counts the entities audited in each group, those that
+// comply with the policy and those that do not, and the entities missing
+// each required key or with values of each key that are not allowed.
+func summarizeTagAudits(audits []tagAudit, policy *tagPolicy, groupBy string) output.Table {
+	columns := []string{groupBy, "entities", "compliant", "violating"}
+
+	for _, key := range policy.Required {
+		columns = append(columns, "missing "+key)
+	}
+
+	for _, key := range policy.ruleKeys() {
+		columns = append(columns, "invalid "+key)
+	}
+
+	counts := map[string]map[string]int{}
+
+	for _, a := range audits {
+		c, ok := counts[a.Group]
+		if !ok {
+			c = map[string]int{}
+			counts[a.Group] = c
+		}
+
+		c["entities"]++
+
+		if len(a.Violations) == 0 {
+			c["compliant"]++
+			continue
+		}
+
+		c["violating"]++
+
+		// An entity with several values of a key that are not allowed is
+		// only counted once
+		seen := map[string]bool{}
+
+		for _, v := range a.Violations {
+			column := "missing " + v.Key
+			if v.Violation == tagViolationNotAllowed {
+				column = "invalid " + v.Key
+			}
+
+			if !seen[column] {
+				seen[column] = true
+				c[column]++
+			}
+		}
+	}
+
+	groups := make([]string, 0, len(counts))
+	for g := range counts {
+		groups = append(groups, g)
+	}
+
+	sort.Strings(groups)
+
+	table := output.Table{Columns: columns}
+
+	for _, g := range groups {
+		row := []interface{}{g}
+		for _, col := range columns[1:] {
+			row = append(row, counts[g][col])
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table
+}
@@ -0,0 +1,93 @@
+// +build unit
+
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+func TestParseTagPolicy(t *testing.T) {
+	policy, err := parseTagPolicy([]string{"team", " env", ""}, []string{"env=production|staging"}, []string{`owner=[a-z]+@example\.com`})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"team", "env"}, policy.Required)
+	assert.Equal(t, []string{"env", "owner"}, policy.ruleKeys())
+
+	assert.True(t, policy.Rules["env"].allows("staging"))
+	assert.False(t, policy.Rules["env"].allows("prod"))
+
+	// Patterns must match the whole value
+	assert.True(t, policy.Rules["owner"].allows("alice@example.com"))
+	assert.False(t, policy.Rules["owner"].allows("alice@example.com.evil"))
+}
+
+func TestParseTagPolicyInvalid(t *testing.T) {
+	_, err := parseTagPolicy(nil, []string{"env"}, nil)
+	assert.EqualError(t, err, "allowed tag values must be given as key=values, not env")
+
+	_, err = parseTagPolicy(nil, nil, []string{"owner=("})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid pattern for tag owner")
+
+	_, err = parseTagPolicy(nil, []string{"env=production"}, []string{"env=prod.*"})
+	assert.EqualError(t, err, "the allowed values of tag env are given more than once")
+}
+
+func TestAuditEntityTags(t *testing.T) {
+	policy, err := parseTagPolicy([]string{"team", "env", "owner"}, []string{"env=production|staging"}, nil)
+	require.NoError(t, err)
+
+	e := &entities.GenericEntityOutline{GUID: "G1", Name: "checkout"}
+
+	a := auditEntityTags(e, []entities.EntityTag{
+		{Key: "team", Values: []string{"payments"}},
+		{Key: "env", Values: []string{"production", "dev"}},
+	}, policy, "team")
+
+	assert.Equal(t, "payments", a.Group)
+	assert.Equal(t, []tagViolation{
+		{GUID: "G1", Name: "checkout", Group: "payments", Key: "owner", Violation: tagViolationMissing},
+		{GUID: "G1", Name: "checkout", Group: "payments", Key: "env", Violation: tagViolationNotAllowed, Value: "dev"},
+	}, a.Violations)
+
+	a = auditEntityTags(e, []entities.EntityTag{
+		{Key: "team", Values: []string{"payments"}},
+		{Key: "env", Values: []string{"staging"}},
+		{Key: "owner", Values: []string{"alice"}},
+	}, policy, "team")
+	assert.Empty(t, a.Violations)
+
+	a = auditEntityTags(e, nil, policy, "team")
+	assert.Equal(t, tagAuditNoGroup, a.Group)
+	assert.Len(t, a.Violations, 3)
+}
+
+func TestSummarizeTagAudits(t *testing.T) {
+	policy, err := parseTagPolicy([]string{"env"}, []string{"env=production"}, nil)
+	require.NoError(t, err)
+
+	audits := []tagAudit{
+		{Group: "payments"},
+		{Group: "payments", Violations: []tagViolation{{Key: "env", Violation: tagViolationMissing}}},
+		{Group: "orders", Violations: []tagViolation{
+			{Key: "env", Violation: tagViolationNotAllowed, Value: "dev"},
+			{Key: "env", Violation: tagViolationNotAllowed, Value: "test"},
+		}},
+		{Group: tagAuditNoGroup},
+	}
+
+	assert.Equal(t, output.Table{
+		Columns: []string{"team", "entities", "compliant", "violating", "missing env", "invalid env"},
+		Rows: [][]interface{}{
+			{tagAuditNoGroup, 1, 1, 0, 0, 0},
+			{"orders", 1, 0, 1, 0, 1},
+			{"payments", 2, 1, 1, 1, 0},
+		},
+	}, summarizeTagAudits(audits, policy, "team"))
+}
@@ -0,0 +1,394 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+const (
+	tagOperationCreate  = "create"
+	tagOperationReplace = "replace"
+	tagOperationDelete  = "delete"
+)
+
+// tagsFile is the desired tags of a set of entities, as kept in a YAML file:
+//
+//	entities:
+//	  - guid: MXxBUE18QVBQTElDQVRJT058MQ
+//	    tags: [team:payments, env:production]
+//	  - name: checkout
+//	    tags: [team:payments]
+//	  - query: "domain = 'APM' AND name LIKE 'orders%'"
+//	    tags: [team:orders]
+type tagsFile struct {
+	Entities []tagsFileEntry `yaml:"entities"`
+}
+
+// tagsFileEntry selects entities by exactly one of GUID, name or entity
+// search query, and gives their tags as key:value pairs.
+type tagsFileEntry struct {
+	GUID  string   `yaml:"guid,omitempty"`
+	Name  string   `yaml:"name,omitempty"`
+	Query string   `yaml:"query,omitempty"`
+	Tags  []string `yaml:"tags"`
+}
+
+func (e tagsFileEntry) selector() string {
+	switch {
+	case e.GUID != "":
+		return "guid " + e.GUID
+	case e.Name != "":
+		return "name " + e.Name
+	default:
+		return "query " + e.Query
+	}
+}
+
+// readTagsFile reads and checks a tags file.
+func readTagsFile(path string) (*tagsFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f tagsFile
+	if err := yaml.UnmarshalStrict(content, &f); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	if len(f.Entities) == 0 {
+		return nil, fmt.Errorf("%s: no entities given", path)
+	}
+
+	for i, e := range f.Entities {
+		selectors := 0
+		for _, s := range []string{e.GUID, e.Name, e.Query} {
+			if s != "" {
+				selectors++
+			}
+		}
+
+		if selectors != 1 {
+			return nil, fmt.Errorf("%s: entity %d must have exactly one of guid, name or query", path, i+1)
+		}
+
+		if _, err := assembleTagsInput(e.Tags); err != nil {
+			return nil, fmt.Errorf("%s: entity %d: %s", path, i+1, err)
+		}
+	}
+
+	return &f, nil
+}
+
+// tagTarget is an entity managed by a tags file, with its desired and
+// current tags.
+type tagTarget struct {
+	GUID    string
+	Name    string
+	Desired []entities.TaggingTagInput
+	Current []entities.EntityTagWithMetadata
+}
+
+// resolveTagsFile finds the entities selected by each entry of the file and
+// fetches their current tags.  An entity selected by several entries gets
+// the tags of all of them, but may not be given different values for the
+// same key.  GUIDs and names that match no entity are an error, while a
+// query that matches none is not.
+func resolveTagsFile(ctx context.Context, client nerdGraphClient, f *tagsFile) ([]*tagTarget, error) {
+	var (
+		targets []*tagTarget
+		byGUID  = map[string]*tagTarget{}
+		sources = map[string]map[string]string{}
+	)
+
+	for _, entry := range f.Entities {
+		desired, err := assembleTagsInput(entry.Tags)
+		if err != nil {
+			return nil, err
+		}
+
+		guids, err := selectEntities(ctx, client, entry)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, guid := range guids {
+			t, ok := byGUID[guid]
+			if !ok {
+				t = &tagTarget{GUID: guid}
+				byGUID[guid] = t
+				sources[guid] = map[string]string{}
+				targets = append(targets, t)
+			}
+
+			for _, tag := range desired {
+				if previous, ok := sources[guid][tag.Key]; ok {
+					return nil, fmt.Errorf("tag %s of entity %s is given by both %s and %s", tag.Key, guid, previous, entry.selector())
+				}
+
+				sources[guid][tag.Key] = entry.selector()
+				t.Desired = append(t.Desired, tag)
+			}
+		}
+	}
+
+	guids := make([]string, len(targets))
+	for i, t := range targets {
+		guids[i] = t.GUID
+	}
+
+	found, err := getTagsWithMetadata(ctx, client, guids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range targets {
+		e, ok := found[t.GUID]
+		if !ok {
+			return nil, fmt.Errorf("no entity found with GUID %s", t.GUID)
+		}
+
+		t.Name = e.Name
+		t.Current = e.TagsWithMetadata
+	}
+
+	return targets, nil
+}
+
+// selectEntities returns the GUIDs of the entities selected by an entry.
+func selectEntities(ctx context.Context, client nerdGraphClient, entry tagsFileEntry) ([]string, error) {
+	if entry.GUID != "" {
+		return []string{entry.GUID}, nil
+	}
+
+	query := entry.Query
+	if entry.Name != "" {
+		query = fmt.Sprintf("name = '%s'", strings.ReplaceAll(entry.Name, "'", `\'`))
+	}
+
+	var guids []string
+
+	err := searchEntities(ctx, client, entitySearchParams{Query: query}, func(page []entities.EntityOutlineInterface) error {
+		for _, e := range page {
+			guids = append(guids, string(e.GetGUID()))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(guids) == 0 && entry.Name != "" {
+		return nil, fmt.Errorf("no entity found with name %s", entry.Name)
+	}
+
+	return guids, nil
+}
+
+type tagsWithMetadataEntity struct {
+	GUID             string                           `json:"guid"`
+	Name             string                           `json:"name"`
+	TagsWithMetadata []entities.EntityTagWithMetadata `json:"tagsWithMetadata"`
+}
+
+type tagsWithMetadataResponse struct {
+	Actor struct {
+		Entities []tagsWithMetadataEntity `json:"entities"`
+	} `json:"actor"`
+}
+
+// getTagsWithMetadata fetches the tags of the entities, including whether
+// each value can be changed, in batches of the most NerdGraph allows.
+func getTagsWithMetadata(ctx context.Context, client nerdGraphClient, guids []string) (map[string]tagsWithMetadataEntity, error) {
+	found := map[string]tagsWithMetadataEntity{}
+
+	for start := 0; start < len(guids); start += maxEntitiesPerRequest {
+		end := start + maxEntitiesPerRequest
+		if end > len(guids) {
+			end = len(guids)
+		}
+
+		var resp tagsWithMetadataResponse
+		if err := client.QueryWithResponseAndContext(ctx, tagsWithMetadataQuery, map[string]interface{}{"guids": guids[start:end]}, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, e := range resp.Actor.Entities {
+			found[e.GUID] = e
+		}
+	}
+
+	return found, nil
+}
+
+// tagOperation is a change to one tag key of an entity.
+type tagOperation struct {
+	GUID      string   `json:"guid" yaml:"guid"`
+	Name      string   `json:"name" yaml:"name"`
+	Operation string   `json:"operation" yaml:"operation"`
+	Key       string   `json:"key" yaml:"key"`
+	Current   []string `json:"current,omitempty" yaml:"current,omitempty"`
+	Desired   []string `json:"desired,omitempty" yaml:"desired,omitempty"`
+}
+
+// String renders the operation as a line of a plan.
+func (o tagOperation) String() string {
+	switch o.Operation {
+	case tagOperationCreate:
+		return fmt.Sprintf("  + %s: %s", o.Key, strings.Join(o.Desired, ", "))
+	case tagOperationDelete:
+		return fmt.Sprintf("  - %s: %s", o.Key, strings.Join(o.Current, ", "))
+	default:
+		return fmt.Sprintf("  ~ %s: %s -> %s", o.Key, strings.Join(o.Current, ", "), strings.Join(o.Desired, ", "))
+	}
+}
+
+// planTagTarget finds the change needed to give the entity its desired tags,
+// and describes it as an operation on each tag key.  With prune, keys that
+// are not given are deleted, except keys with values that cannot be changed,
+// such as those New Relic adds to every entity.
+func planTagTarget(t *tagTarget, prune bool) (tagChange, []tagOperation) {
+	current := map[string][]string{}
+	mutable := map[string]bool{}
+
+	var tags []entities.EntityTag
+
+	for _, tag := range t.Current {
+		mutable[tag.Key] = true
+
+		for _, v := range tag.Values {
+			current[tag.Key] = append(current[tag.Key], v.Value)
+			mutable[tag.Key] = mutable[tag.Key] && v.Mutable
+		}
+
+		tags = append(tags, entities.EntityTag{Key: tag.Key, Values: current[tag.Key]})
+	}
+
+	change := tagChange{GUID: t.GUID, Name: t.Name}
+	change.Add, change.Remove = diffTags(tags, t.Desired)
+
+	changed := map[string]bool{}
+	for _, a := range change.Add {
+		changed[a.Key] = true
+	}
+
+	for _, r := range change.Remove {
+		changed[r.Key] = true
+	}
+
+	desired := map[string][]string{}
+	for _, tag := range t.Desired {
+		desired[tag.Key] = append(desired[tag.Key], tag.Values...)
+	}
+
+	var ops []tagOperation
+
+	for _, key := range sortedValues(changed) {
+		op := tagOperation{
+			GUID:      t.GUID,
+			Name:      t.Name,
+			Operation: tagOperationReplace,
+			Key:       key,
+			Current:   sortedCopy(current[key]),
+			Desired:   sortedCopy(desired[key]),
+		}
+
+		if len(op.Current) == 0 {
+			op.Operation = tagOperationCreate
+		}
+
+		ops = append(ops, op)
+	}
+
+	if !prune {
+		return change, ops
+	}
+
+	unmanaged := map[string]bool{}
+	for key := range current {
+		if _, ok := desired[key]; !ok && mutable[key] {
+			unmanaged[key] = true
+		}
+	}
+
+	for _, key := range sortedValues(unmanaged) {
+		change.Delete = append(change.Delete, key)
+
+		ops = append(ops, tagOperation{
+			GUID:      t.GUID,
+			Name:      t.Name,
+			Operation: tagOperationDelete,
+			Key:       key,
+			Current:   sortedCopy(current[key]),
+		})
+	}
+
+	return change, ops
+}
+
+// planTagsFile plans the changes to every entity managed by a tags file.
+func planTagsFile(targets []*tagTarget, prune bool) ([]tagChange, []tagOperation) {
+	var (
+		changes []tagChange
+		ops     = []tagOperation{}
+	)
+
+	for _, t := range targets {
+		change, o := planTagTarget(t, prune)
+
+		changes = append(changes, change)
+		ops = append(ops, o...)
+	}
+
+	return changes, ops
+}
+
+// countTagOperations counts the operations of each kind.
+func countTagOperations(ops []tagOperation) map[string]int {
+	counts := map[string]int{}
+
+	for _, o := range ops {
+		counts[o.Operation]++
+	}
+
+	return counts
+}
+
+// tagPlanSummary describes the number of operations of each kind.
+func tagPlanSummary(ops []tagOperation) string {
+	counts := countTagOperations(ops)
+
+	return fmt.Sprintf("%d to create, %d to replace, %d to delete",
+		counts[tagOperationCreate], counts[tagOperationReplace], counts[tagOperationDelete])
+}
+
+func sortedCopy(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	s := append([]string{}, values...)
+	sort.Strings(s)
+
+	return s
+}
+
+const tagsWithMetadataQuery = `query($guids: [EntityGuid]!) { actor { entities(guids: $guids) {
+	guid
+	name
+	tagsWithMetadata {
+		key
+		values {
+			mutable
+			value
+		}
+	}
+} } }`
@@ -0,0 +1,210 @@
+// +build unit
+
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/entities"
+)
+
+func writeTagsFile(t *testing.T, dir string, content string) string {
+	path := filepath.Join(dir, "tags.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func TestReadTagsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "newrelic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeTagsFile(t, dir, `
+entities:
+  - guid: G1
+    tags: [team:payments, env:production]
+  - name: checkout
+    tags: [team:payments]
+  - query: "domain = 'APM'"
+    tags: []
+`)
+
+	f, err := readTagsFile(path)
+	require.NoError(t, err)
+	require.Len(t, f.Entities, 3)
+	assert.Equal(t, "G1", f.Entities[0].GUID)
+	assert.Equal(t, []string{"team:payments", "env:production"}, f.Entities[0].Tags)
+	assert.Equal(t, "name checkout", f.Entities[1].selector())
+	assert.Equal(t, "query domain = 'APM'", f.Entities[2].selector())
+}
+
+func TestReadTagsFileInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "newrelic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cases := map[string]string{
+		"entities:\n  - tags: [team:x]\n":                         "exactly one of guid, name or query",
+		"entities:\n  - guid: G1\n    name: x\n    tags: [a:b]\n": "exactly one of guid, name or query",
+		"entities:\n  - guid: G1\n    tags: [team]\n":             "colon separated",
+		"entities:\n  - guid: G1\n    tag: [team:x]\n":            "field tag not found",
+		"entities: []\n": "no entities given",
+	}
+
+	for content, message := range cases {
+		_, err := readTagsFile(writeTagsFile(t, dir, content))
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), message, content)
+	}
+}
+
+// tagsFileNerdGraphClient answers entity searches by query, and returns the
+// tags of the entities it knows when fetched by GUID.
+type tagsFileNerdGraphClient struct {
+	searches map[string][]string
+	entities map[string]string
+}
+
+func (c *tagsFileNerdGraphClient) QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error {
+	if q, ok := variables["query"].(string); ok {
+		var found []string
+		for _, guid := range c.searches[q] {
+			found = append(found, fmt.Sprintf(`{"__typename":"GenericEntityOutline","guid":%q}`, guid))
+		}
+
+		body := fmt.Sprintf(`{"actor":{"entitySearch":{"results":{"entities":[%s]}}}}`, strings.Join(found, ","))
+
+		return json.Unmarshal([]byte(body), respBody)
+	}
+
+	var found []string
+	for _, guid := range variables["guids"].([]string) {
+		if e, ok := c.entities[guid]; ok {
+			found = append(found, e)
+		}
+	}
+
+	body := fmt.Sprintf(`{"actor":{"entities":[%s]}}`, strings.Join(found, ","))
+
+	return json.Unmarshal([]byte(body), respBody)
+}
+
+func TestResolveTagsFile(t *testing.T) {
+	client := &tagsFileNerdGraphClient{
+		searches: map[string][]string{
+			"name = 'checkout'": {"G1"},
+			"domain = 'APM'":    {"G1", "G2"},
+		},
+		entities: map[string]string{
+			"G1": `{"guid":"G1","name":"checkout","tagsWithMetadata":[{"key":"team","values":[{"value":"orders","mutable":true}]}]}`,
+			"G2": `{"guid":"G2","name":"cart"}`,
+		},
+	}
+
+	targets, err := resolveTagsFile(context.Background(), client, &tagsFile{Entities: []tagsFileEntry{
+		{Name: "checkout", Tags: []string{"team:payments"}},
+		{Query: "domain = 'APM'", Tags: []string{"env:production"}},
+		{Query: "domain = 'INFRA'", Tags: []string{"env:production"}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+
+	assert.Equal(t, "G1", targets[0].GUID)
+	assert.Equal(t, "checkout", targets[0].Name)
+	assert.Equal(t, []entities.TaggingTagInput{
+		{Key: "team", Values: []string{"payments"}},
+		{Key: "env", Values: []string{"production"}},
+	}, targets[0].Desired)
+	assert.Len(t, targets[0].Current, 1)
+
+	assert.Equal(t, "G2", targets[1].GUID)
+	assert.Equal(t, []entities.TaggingTagInput{{Key: "env", Values: []string{"production"}}}, targets[1].Desired)
+}
+
+func TestResolveTagsFileErrors(t *testing.T) {
+	client := &tagsFileNerdGraphClient{
+		searches: map[string][]string{"domain = 'APM'": {"G1"}},
+		entities: map[string]string{"G1": `{"guid":"G1","name":"checkout"}`},
+	}
+
+	_, err := resolveTagsFile(context.Background(), client, &tagsFile{Entities: []tagsFileEntry{
+		{GUID: "G1", Tags: []string{"team:payments"}},
+		{Query: "domain = 'APM'", Tags: []string{"team:orders"}},
+	}})
+	require.Error(t, err)
+	assert.Equal(t, "tag team of entity G1 is given by both guid G1 and query domain = 'APM'", err.Error())
+
+	_, err = resolveTagsFile(context.Background(), client, &tagsFile{Entities: []tagsFileEntry{{Name: "cart", Tags: []string{"team:x"}}}})
+	require.Error(t, err)
+	assert.Equal(t, "no entity found with name cart", err.Error())
+
+	_, err = resolveTagsFile(context.Background(), client, &tagsFile{Entities: []tagsFileEntry{{GUID: "G9", Tags: []string{"team:x"}}}})
+	require.Error(t, err)
+	assert.Equal(t, "no entity found with GUID G9", err.Error())
+}
+
+func TestPlanTagTarget(t *testing.T) {
+	target := &tagTarget{
+		GUID: "G1",
+		Name: "checkout",
+		Desired: []entities.TaggingTagInput{
+			{Key: "team", Values: []string{"payments"}},
+			{Key: "env", Values: []string{"production"}},
+			{Key: "tier", Values: []string{"1"}},
+		},
+		Current: []entities.EntityTagWithMetadata{
+			{Key: "team", Values: []entities.EntityTagValueWithMetadata{{Value: "orders", Mutable: true}}},
+			{Key: "env", Values: []entities.EntityTagValueWithMetadata{{Value: "production", Mutable: true}}},
+			{Key: "owner", Values: []entities.EntityTagValueWithMetadata{{Value: "alice", Mutable: true}}},
+			{Key: "accountId", Values: []entities.EntityTagValueWithMetadata{{Value: "1", Mutable: false}}},
+		},
+	}
+
+	change, ops := planTagTarget(target, false)
+	assert.Equal(t, []entities.TaggingTagInput{
+		{Key: "team", Values: []string{"payments"}},
+		{Key: "tier", Values: []string{"1"}},
+	}, change.Add)
+	assert.Equal(t, []entities.TaggingTagValueInput{{Key: "team", Value: "orders"}}, change.Remove)
+	assert.Empty(t, change.Delete)
+	assert.Equal(t, []tagOperation{
+		{GUID: "G1", Name: "checkout", Operation: tagOperationReplace, Key: "team", Current: []string{"orders"}, Desired: []string{"payments"}},
+		{GUID: "G1", Name: "checkout", Operation: tagOperationCreate, Key: "tier", Desired: []string{"1"}},
+	}, ops)
+
+	change, ops = planTagTarget(target, true)
+	assert.Equal(t, []string{"owner"}, change.Delete)
+	require.Len(t, ops, 3)
+	assert.Equal(t, tagOperation{GUID: "G1", Name: "checkout", Operation: tagOperationDelete, Key: "owner", Current: []string{"alice"}}, ops[2])
+
+	assert.Equal(t, "  ~ team: orders -> payments", ops[0].String())
+	assert.Equal(t, "  + tier: 1", ops[1].String())
+	assert.Equal(t, "  - owner: alice", ops[2].String())
+	assert.Equal(t, "1 to create, 1 to replace, 1 to delete", tagPlanSummary(ops))
+}
+
+func TestPlanTagsFileNoChanges(t *testing.T) {
+	changes, ops := planTagsFile([]*tagTarget{{
+		GUID:    "G1",
+		Desired: []entities.TaggingTagInput{{Key: "team", Values: []string{"payments"}}},
+		Current: []entities.EntityTagWithMetadata{
+			{Key: "team", Values: []entities.EntityTagValueWithMetadata{{Value: "payments", Mutable: true}}},
+			{Key: "account", Values: []entities.EntityTagValueWithMetadata{{Value: "Prod"}}},
+		},
+	}}, true)
+
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].Empty())
+	assert.Empty(t, ops)
+}
@@ -57,12 +57,14 @@ var Command = &cobra.Command{
 				log.Fatal(err)
 			}
 
-			i := NewRecipeInstaller(ic, nrClient)
+			client.WithRecipeServiceClient(func(rsClient *newrelic.NewRelic) {
+				i := NewRecipeInstaller(ic, nrClient, &rsClient.NerdGraph)
 
-			// Run the install.
-			if err := i.Install(); err != nil {
-				log.Fatalf("Could not install New Relic: %s, check the install log for details: %s", err, config.DefaultLogFile)
-			}
+				// Run the install.
+				if err := i.Install(); err != nil {
+					log.Fatalf("Could not install New Relic: %s, check the install log for details: %s", err, config.DefaultLogFile)
+				}
+			})
 		})
 	},
 }
@@ -35,8 +35,8 @@ type RecipeInstaller struct {
 	progressIndicator ux.ProgressIndicator
 }
 
-func NewRecipeInstaller(ic InstallerContext, nrClient *newrelic.NewRelic) *RecipeInstaller {
-	rf := recipes.NewServiceRecipeFetcher(&nrClient.NerdGraph)
+func NewRecipeInstaller(ic InstallerContext, nrClient *newrelic.NewRelic, recipeClient recipes.NerdGraphClient) *RecipeInstaller {
+	rf := recipes.NewServiceRecipeFetcher(recipeClient)
 	pf := discovery.NewRegexProcessFilterer(rf)
 	ff := recipes.NewRecipeFileFetcher()
 	ers := []execution.StatusReporter{
@@ -1,9 +1,12 @@
 package nerdgraph
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -11,12 +14,19 @@ import (
 	"github.com/newrelic/newrelic-cli/internal/client"
 	"github.com/newrelic/newrelic-cli/internal/output"
 	"github.com/newrelic/newrelic-cli/internal/utils"
-	"github.com/newrelic/newrelic-client-go/newrelic"
-	ng "github.com/newrelic/newrelic-client-go/pkg/nerdgraph"
 )
 
 var (
-	variables string
+	variables         string
+	queryFile         string
+	variablesFile     string
+	variableOverrides []string
+	operationName     string
+	failOnPartial     bool
+	paginatePath      string
+	cursorVariable    string
+	streamPages       bool
+	requestTimeout    time.Duration
 )
 
 var cmdQuery = &cobra.Command{
@@ -24,54 +34,207 @@ var cmdQuery = &cobra.Command{
 	Short: "Execute a raw GraphQL query request to the NerdGraph API",
 	Long: `Execute a raw GraphQL query request to the NerdGraph API
 
-The query command accepts a single argument in the form of a GraphQL query as a string.
-This command accepts an optional flag, --variables, which should be a JSON string where the
-keys are the variables to be referenced in the GraphQL query.
+The query command accepts a single argument in the form of a GraphQL query or mutation
+as a string, or reads the GraphQL document from the file given with the --query-file
+flag.  When the document contains several operations, the --operationName flag
+selects the one to execute.
+
+Variables referenced in the document are read from the --variables-file flag, a JSON
+or YAML file, and from the --variables flag, a JSON string.  The --var flag sets a
+single variable as name=value, where values that are valid JSON, such as numbers and
+booleans, are used as such and anything else as a string.  The type can be given
+explicitly as name:type=value, with a type of string, int, float, bool or json.  When
+a variable is given more than once, --var takes precedence over --variables, which
+takes precedence over --variables-file.
+
+A query argument, --query-file or --variables-file of - is read from stdin.
+
+The complete response document is printed, including the data returned by every root
+field, the errors array and any extensions.  When the response contains errors and no
+data, the command exits with a non-zero status.  When some of the requested data was
+returned along with errors, the errors are logged as a warning, unless the
+--fail-on-partial flag is provided, in which case the command exits with a non-zero
+status as well.
+
+The --paginate flag follows the cursor of fields such as entity search results.  It
+takes the path below data of the object containing nextCursor, and re-issues the query
+with the cursor passed in the query's cursor variable until nextCursor is null.  The
+cursor variable is the one whose name contains "cursor", or the one given with the
+--cursor-variable flag.  The lists within the paginated object are concatenated into
+a single response, or with the --stream flag, each of their items is written as a
+line of JSON as soon as its page is returned.
+
+Each request fails when no complete response is received within the --timeout flag,
+30 seconds by default.
 `,
-	Example: `newrelic nerdgraph query 'query($guid: EntityGuid!) { actor { entity(guid: $guid) { guid name domain entityType } } }' --variables '{"guid": "<GUID>"}'`,
+	Example: `newrelic nerdgraph query 'query($guid: EntityGuid!) { actor { entity(guid: $guid) { guid name domain entityType } } }' --variables '{"guid": "<GUID>"}'
+newrelic nerdgraph query --query-file entity.graphql --variables-file variables.yaml --var guid=<GUID>
+newrelic nerdgraph query --query-file operations.graphql --operationName EntitySearch --var query:string="domain = 'APM'"
+cat entity.graphql | newrelic nerdgraph query - --var guid=<GUID>
+newrelic nerdgraph query --query-file entity-search.graphql --var query="domain = 'APM'" --paginate actor.entitySearch.results --stream`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		argsCount := len(args)
+		if len(args) > 1 {
+			return errors.New("command expects only 1 argument")
+		}
 
-		if argsCount < 1 {
-			return errors.New("missing graph query argument")
+		return nil
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if (len(args) == 0) == (queryFile == "") {
+			return errors.New("either a graph query argument or the --query-file flag is required")
 		}
 
-		if argsCount > 1 {
-			return errors.New("command expects only 1 argument")
+		fromStdin := 0
+		for _, p := range []string{queryFile, variablesFile} {
+			if p == stdinPath {
+				fromStdin++
+			}
+		}
+
+		if len(args) > 0 && args[0] == stdinPath {
+			fromStdin++
+		}
+
+		if fromStdin > 1 {
+			return errors.New("only one of the query and the variables can be read from stdin")
+		}
+
+		if streamPages && paginatePath == "" {
+			return errors.New("--stream can only be used with --paginate")
 		}
 
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		client.WithClient(func(nrClient *newrelic.NewRelic) {
-			var variablesParsed map[string]interface{}
-
-			err := json.Unmarshal([]byte(variables), &variablesParsed)
-			if err != nil {
-				log.Fatal(err)
-			}
+		req, err := buildRequest(args)
+		utils.LogIfFatal(err)
 
-			query := args[0]
+		client.WithNerdGraphEndpoint(func(endpoint *client.NerdGraphEndpoint) {
+			r := newRequester(endpoint, requestTimeout)
 
-			result, err := nrClient.NerdGraph.Query(query, variablesParsed)
-			if err != nil {
-				log.Fatal(err)
+			if paginatePath != "" {
+				utils.LogIfFatal(runPaginated(cmd.Context(), r, req))
+				return
 			}
 
-			reqBodyBytes := new(bytes.Buffer)
-
-			encoder := json.NewEncoder(reqBodyBytes)
-			err = encoder.Encode(ng.QueryResponse{
-				Actor: result.(ng.QueryResponse).Actor,
-			})
+			result, err := r.Do(cmd.Context(), req)
 			utils.LogIfFatal(err)
 
-			utils.LogIfFatal(output.Print(reqBodyBytes))
+			utils.LogIfFatal(printResponse(result))
+			utils.LogIfFatal(checkResponse(result, failOnPartial))
 		})
 	},
 }
 
+// buildRequest assembles the request from the query argument and flags.
+func buildRequest(args []string) (graphQLRequest, error) {
+	path := queryFile
+	if path == "" && args[0] == stdinPath {
+		path = stdinPath
+	}
+
+	document := ""
+	if path != "" {
+		content, err := readInput(path)
+		if err != nil {
+			return graphQLRequest{}, fmt.Errorf("unable to read query: %s", err)
+		}
+
+		document = string(content)
+	} else {
+		document = args[0]
+	}
+
+	if strings.TrimSpace(document) == "" {
+		return graphQLRequest{}, errors.New("the query is empty")
+	}
+
+	fileVariables := map[string]interface{}{}
+	if variablesFile != "" {
+		content, err := readInput(variablesFile)
+		if err != nil {
+			return graphQLRequest{}, fmt.Errorf("unable to read variables: %s", err)
+		}
+
+		if fileVariables, err = parseVariablesFile(content); err != nil {
+			return graphQLRequest{}, err
+		}
+	}
+
+	var inlineVariables map[string]interface{}
+	if err := unmarshalJSON([]byte(variables), &inlineVariables); err != nil {
+		return graphQLRequest{}, fmt.Errorf("unable to parse --variables: %s", err)
+	}
+
+	overrides, err := parseVariableOverrides(variableOverrides)
+	if err != nil {
+		return graphQLRequest{}, err
+	}
+
+	return graphQLRequest{
+		Query:         document,
+		Variables:     mergeVariables(fileVariables, inlineVariables, overrides),
+		OperationName: operationName,
+	}, nil
+}
+
+// runPaginated issues the request until every page has been returned, and
+// prints the concatenated response or streams the items of each page.
+func runPaginated(ctx context.Context, doer graphQLDoer, req graphQLRequest) error {
+	p, err := newPaginator(doer, paginatePath, req.Query, cursorVariable, failOnPartial)
+	if err != nil {
+		return err
+	}
+
+	if streamPages {
+		w := &ndjsonPageWriter{writer: os.Stdout}
+		return p.Run(ctx, req, w.Add)
+	}
+
+	c := &pageConcatenator{paginator: p}
+	if err := p.Run(ctx, req, c.Add); err != nil {
+		return err
+	}
+
+	return output.Print(c.Document())
+}
+
+// printResponse prints the complete response document.  JSON output reproduces
+// the document as it was received.
+func printResponse(result *graphQLResponse) error {
+	if output.CurrentFormat() == output.FormatJSON {
+		return output.Print(result.Raw)
+	}
+
+	return output.Print(result.Document())
+}
+
+// checkResponse returns an error when the response contains GraphQL errors,
+// unless data was returned for some of the requested fields and partial data
+// is acceptable.
+func checkResponse(result *graphQLResponse, failOnPartial bool) error {
+	if !result.HasErrors() {
+		return nil
+	}
+
+	if result.IsPartial() && !failOnPartial {
+		log.Warnf("the response contains partial data: %s", result.ErrorSummary())
+		return nil
+	}
+
+	return errors.New(result.ErrorSummary())
+}
+
 func init() {
 	Command.AddCommand(cmdQuery)
 	cmdQuery.Flags().StringVar(&variables, "variables", "{}", "the variables to pass to the GraphQL query, represented as a JSON string")
+	cmdQuery.Flags().StringVar(&queryFile, "query-file", "", "a file containing the GraphQL document to execute, or - for stdin")
+	cmdQuery.Flags().StringVar(&variablesFile, "variables-file", "", "a JSON or YAML file of variables to pass to the GraphQL query, or - for stdin")
+	cmdQuery.Flags().StringArrayVar(&variableOverrides, "var", []string{}, "a variable to pass to the GraphQL query, as name=value or name:type=value, may be repeated")
+	cmdQuery.Flags().StringVar(&operationName, "operationName", "", "the operation to execute when the document contains several")
+	cmdQuery.Flags().StringVar(&paginatePath, "paginate", "", "the path below data of an object with a nextCursor to follow, such as actor.entitySearch.results")
+	cmdQuery.Flags().StringVar(&cursorVariable, "cursor-variable", "", "the variable the cursor is passed in when paginating, detected from the query by default")
+	cmdQuery.Flags().BoolVar(&streamPages, "stream", false, "write the items of each page as lines of JSON as they are returned, instead of a single response")
+	cmdQuery.Flags().DurationVar(&requestTimeout, "timeout", defaultRequestTimeout, "how long to wait for each response from NerdGraph")
+	cmdQuery.Flags().BoolVar(&failOnPartial, "fail-on-partial", false, "exit with a non-zero status when the response contains errors along with partial data")
 }
@@ -3,9 +3,13 @@
 package nerdgraph
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/newrelic/newrelic-cli/internal/testcobra"
 )
@@ -16,3 +20,27 @@ func TestQuery(t *testing.T) {
 	testcobra.CheckCobraMetadata(t, cmdQuery)
 	testcobra.CheckCobraRequiredFlags(t, cmdQuery, []string{})
 }
+
+func TestBuildRequest(t *testing.T) {
+	dir := t.TempDir()
+
+	queryPath := filepath.Join(dir, "entity.graphql")
+	require.NoError(t, ioutil.WriteFile(queryPath, []byte("query Entity($guid: EntityGuid!) { actor { entity(guid: $guid) { name } } }\nquery Other { actor { user { name } } }"), 0600))
+
+	variablesPath := filepath.Join(dir, "variables.yaml")
+	require.NoError(t, ioutil.WriteFile(variablesPath, []byte("guid: from-file\nlimit: 5\n"), 0600))
+
+	queryFile, variablesFile, variables, operationName = queryPath, variablesPath, `{"limit": 10}`, "Entity"
+	variableOverrides = []string{"guid=from-flag"}
+	defer func() {
+		queryFile, variablesFile, variables, operationName, variableOverrides = "", "", "{}", "", []string{}
+	}()
+
+	req, err := buildRequest(nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, req.Query, "query Other")
+	assert.Equal(t, "Entity", req.OperationName)
+	assert.Equal(t, "from-flag", req.Variables["guid"])
+	assert.Equal(t, json.Number("10"), req.Variables["limit"])
+}
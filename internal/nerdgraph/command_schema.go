@@ -0,0 +1,211 @@
+package nerdgraph
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/config"
+	"github.com/newrelic/newrelic-cli/internal/credentials"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/pkg/region"
+)
+
+var (
+	schemaSearchLimit int
+	schemaRegion      string
+)
+
+var cmdSchema = &cobra.Command{
+	Use:   "schema",
+	Short: "Explore the NerdGraph schema",
+	Long: `Explore the NerdGraph schema
+
+The schema commands fetch the NerdGraph schema by introspection and keep a copy in
+the configuration directory, one per region, which can then be searched and browsed
+without making further requests.  Run the fetch command again to pick up changes to
+the schema.
+`,
+	Example: `newrelic nerdgraph schema fetch
+newrelic nerdgraph schema search entitySearch
+newrelic nerdgraph schema show EntitySearchQueryBuilder`,
+}
+
+var cmdSchemaFetch = &cobra.Command{
+	Use:   "fetch",
+	Short: "Fetch and cache the NerdGraph schema",
+	Long: `Fetch and cache the NerdGraph schema
+
+The fetch command runs an introspection query against NerdGraph and stores the
+schema in the configuration directory, keyed by the region of the profile in use.
+`,
+	Example: `newrelic nerdgraph schema fetch`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithNerdGraphEndpoint(func(endpoint *client.NerdGraphEndpoint) {
+			s, err := fetchSchema(cmd.Context(), newRequester(endpoint, defaultRequestTimeout))
+			utils.LogIfFatal(err)
+
+			path, err := schemaCache.Save(&cachedSchema{
+				Region:    endpoint.Region,
+				FetchedAt: time.Now().UTC(),
+				Schema:    s,
+			})
+			utils.LogIfFatal(err)
+
+			log.Infof("saved %d types of the %s region's schema to %s", len(s.Types), endpoint.Region, path)
+		})
+	},
+}
+
+var cmdSchemaSearch = &cobra.Command{
+	Use:   "search <term>",
+	Short: "Search the cached NerdGraph schema",
+	Long: `Search the cached NerdGraph schema
+
+The search command lists the types, fields, arguments and enum values whose name or
+description contains the given term, ignoring case.  Matches on names are listed
+first, followed by matches on descriptions.  The schema must first be fetched with
+'nerdgraph schema fetch'.
+`,
+	Example: `newrelic nerdgraph schema search tagging
+newrelic nerdgraph schema search "golden metrics" --limit 10 --format json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		withCachedSchema(func(s *schema) {
+			results := searchSchema(s, args[0], schemaSearchLimit)
+			if len(results) == 0 {
+				log.Infof("nothing in the schema matches %s", args[0])
+				return
+			}
+
+			utils.LogIfFatal(output.Print(results))
+		})
+	},
+}
+
+var cmdSchemaShow = &cobra.Command{
+	Use:   "show <Type>",
+	Short: "Show a type of the cached NerdGraph schema",
+	Long: `Show a type of the cached NerdGraph schema
+
+The show command prints a type from the cached schema, with its fields and their
+arguments, input fields or enum values, and any deprecations.  Text output uses the
+GraphQL schema definition language, with descriptions as comments.  The schema must
+first be fetched with 'nerdgraph schema fetch'.
+`,
+	Example: `newrelic nerdgraph schema show Actor
+newrelic nerdgraph schema show EntityAlertSeverity --format yaml`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		withCachedSchema(func(s *schema) {
+			t, ok := s.FindType(args[0])
+			if !ok {
+				utils.LogIfFatal(unknownTypeError(s, args[0]))
+			}
+
+			if output.CurrentFormat() == output.FormatText {
+				utils.LogIfFatal(output.Print(renderSDL(t)))
+				return
+			}
+
+			utils.LogIfFatal(output.Print(t))
+		})
+	},
+}
+
+// withCachedSchema loads the schema cached for the region given with
+// --region, or else the region of the default profile.  No credentials are
+// needed, so that cached schemas can be used offline, such as in CI.
+func withCachedSchema(f func(s *schema)) {
+	name, err := cachedSchemaRegion(schemaCache, schemaRegion, profileRegion())
+	utils.LogIfFatal(err)
+
+	cached, err := schemaCache.Load(name)
+	utils.LogIfFatal(err)
+
+	log.Debugf("using the %s region's schema fetched at %s", cached.Region, cached.FetchedAt.Format(time.RFC3339))
+
+	f(cached.Schema)
+}
+
+// profileRegion returns the region of the default profile, if there is one.
+func profileRegion() string {
+	creds, err := credentials.LoadCredentials(config.DefaultConfigDirectory)
+	if err != nil {
+		return ""
+	}
+
+	if p := creds.Default(); p != nil {
+		return p.Region
+	}
+
+	return ""
+}
+
+// cachedSchemaRegion returns the region to use the cached schema of, either
+// the region given or that of the profile.  Without either, the only region
+// cached is used, or the default region when there are several.
+func cachedSchemaRegion(store *schemaStore, given string, profile string) (string, error) {
+	for _, r := range []string{given, profile} {
+		if r == "" {
+			continue
+		}
+
+		name, err := region.Parse(r)
+		if err != nil {
+			return "", err
+		}
+
+		return name.String(), nil
+	}
+
+	cached, err := store.Regions()
+	if err != nil {
+		return "", err
+	}
+
+	if len(cached) == 1 {
+		return cached[0], nil
+	}
+
+	return region.Default.String(), nil
+}
+
+// unknownTypeError suggests types whose names contain the one requested.
+func unknownTypeError(s *schema, name string) error {
+	var similar []string
+
+	for _, t := range s.Types {
+		if strings.Contains(strings.ToLower(t.Name), strings.ToLower(name)) {
+			similar = append(similar, t.Name)
+		}
+
+		if len(similar) == 5 {
+			break
+		}
+	}
+
+	if len(similar) == 0 {
+		return fmt.Errorf("no type named %s in the schema", name)
+	}
+
+	return fmt.Errorf("no type named %s in the schema, did you mean: %s", name, strings.Join(similar, ", "))
+}
+
+func init() {
+	Command.AddCommand(cmdSchema)
+
+	cmdSchema.AddCommand(cmdSchemaFetch)
+
+	cmdSchema.AddCommand(cmdSchemaSearch)
+	cmdSchemaSearch.Flags().IntVarP(&schemaSearchLimit, "limit", "l", 50, "the maximum number of matches to list, or 0 for all")
+	cmdSchemaSearch.Flags().StringVar(&schemaRegion, "region", "", "the region whose cached schema to search, instead of the profile's region")
+
+	cmdSchema.AddCommand(cmdSchemaShow)
+	cmdSchemaShow.Flags().StringVar(&schemaRegion, "region", "", "the region whose cached schema to show, instead of the profile's region")
+}
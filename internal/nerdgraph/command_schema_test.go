@@ -0,0 +1,18 @@
+// +build unit
+
+package nerdgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestSchema(t *testing.T) {
+	assert.Equal(t, "schema", cmdSchema.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdSchema)
+	testcobra.CheckCobraRequiredFlags(t, cmdSchema, []string{})
+}
@@ -0,0 +1,162 @@
+package nerdgraph
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+)
+
+var (
+	validateSchemaFile string
+	validateStrict     bool
+)
+
+var cmdValidate = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a GraphQL document against the cached NerdGraph schema",
+	Long: `Check a GraphQL document against the cached NerdGraph schema
+
+The validate command checks a GraphQL document locally, without contacting
+NerdGraph, which makes it suitable for checking queries kept in source control as
+part of CI.  The document is checked against the schema cached by 'nerdgraph schema
+fetch', or against the schema file given with --schema, which may be a file saved by
+the fetch command or the response to an introspection query.
+
+No credentials are needed to use the cached schema.  The schema of the region given
+with --region is used, or else that of the default profile's region.  Without
+either, the only schema cached is used, or the US region's when there are several.
+
+The following are reported as errors, with the line and column they were found at:
+
+  syntax              the document is not valid GraphQL
+  operation           operations are ambiguous, or --operationName names none
+  unknown-type        a type named in the document does not exist
+  unknown-field       a field does not exist on its parent type
+  unknown-argument    an argument does not exist on its field
+  unknown-fragment    a fragment spread names no fragment in the document
+  missing-argument    a required argument is not given
+  argument-type       a value or variable does not match the argument's type
+  selection           a selection of subfields is missing or not allowed
+  undefined-variable  a variable is used but not defined by the operation
+  duplicate-variable  a variable is defined more than once
+  missing-variable    a required variable is not given a value
+  variable-type       a variable's value does not match its type
+
+and the following as warnings:
+
+  deprecated          a deprecated field or enum value is used
+  unused-variable     a variable is defined but never used
+  unused-fragment     a fragment is defined but never used
+
+Required variables and the types of their values are only checked when variables
+are given with --variables-file, --variables or --var, which are read in the same
+way as by 'nerdgraph query'.  When --operationName is set, only the variables of
+that operation are checked.
+
+The command exits with a non-zero status when errors are found, or when warnings
+are found and --strict is set.
+`,
+	Example: `newrelic nerdgraph validate --query-file entity.graphql
+newrelic nerdgraph validate --query-file entity.graphql --variables-file variables.json --strict
+newrelic nerdgraph validate --query-file entity.graphql --schema schema.json --format json
+newrelic nerdgraph validate --query-file entity.graphql --region EU`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if queryFile == stdinPath && variablesFile == stdinPath {
+			return errors.New("only one of the query and the variables can be read from stdin")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := buildRequest(args)
+		utils.LogIfFatal(err)
+
+		var vars map[string]interface{}
+		if variablesFile != "" || variables != "{}" || len(variableOverrides) > 0 {
+			vars = req.Variables
+		}
+
+		withValidationSchema(func(s *schema) {
+			issues := newValidator(s).Validate(queryFile, req.Query, operationName, vars)
+
+			if output.CurrentFormat() == output.FormatText {
+				for _, i := range issues {
+					fmt.Println(i)
+				}
+			} else {
+				utils.LogIfFatal(output.Print(issues))
+			}
+
+			errorCount, warningCount := countValidationIssues(issues)
+			if errorCount > 0 || validateStrict && warningCount > 0 {
+				log.Fatalf("found %d errors and %d warnings", errorCount, warningCount)
+			}
+		})
+	},
+}
+
+// withValidationSchema loads the schema given with --schema, falling back to
+// the cached schema.
+func withValidationSchema(f func(s *schema)) {
+	if validateSchemaFile == "" {
+		withCachedSchema(f)
+		return
+	}
+
+	content, err := ioutil.ReadFile(validateSchemaFile)
+	utils.LogIfFatal(err)
+
+	s, err := parseSchemaFile(content)
+	if err != nil {
+		log.Fatalf("%s: %s", validateSchemaFile, err)
+	}
+
+	f(s)
+}
+
+// parseSchemaFile reads a schema saved by the fetch command, or the response
+// to an introspection query with or without its data envelope.
+func parseSchemaFile(content []byte) (*schema, error) {
+	var doc struct {
+		cachedSchema
+		SchemaField *schema `json:"__schema"`
+		Data        *struct {
+			Schema *schema `json:"__schema"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("unable to read the schema: %s", err)
+	}
+
+	switch {
+	case doc.Schema != nil:
+		return doc.Schema, nil
+	case doc.SchemaField != nil:
+		return doc.SchemaField, nil
+	case doc.Data != nil && doc.Data.Schema != nil:
+		return doc.Data.Schema, nil
+	}
+
+	return nil, errors.New("the file does not contain a schema")
+}
+
+func init() {
+	Command.AddCommand(cmdValidate)
+	cmdValidate.Flags().StringVar(&queryFile, "query-file", "", "a file containing the GraphQL document to check, or - for stdin")
+	cmdValidate.Flags().StringVar(&variablesFile, "variables-file", "", "a JSON or YAML file of variables to check, or - for stdin")
+	cmdValidate.Flags().StringVar(&variables, "variables", "{}", "variables to check, represented as a JSON string")
+	cmdValidate.Flags().StringArrayVar(&variableOverrides, "var", []string{}, "a variable to check, as name=value or name:type=value, may be repeated")
+	cmdValidate.Flags().StringVar(&operationName, "operationName", "", "the operation whose variables are checked when the document contains several")
+	cmdValidate.Flags().StringVar(&validateSchemaFile, "schema", "", "a schema file to check against instead of the cached schema")
+	cmdValidate.Flags().StringVar(&schemaRegion, "region", "", "the region whose cached schema to check against, instead of the profile's region")
+	cmdValidate.Flags().BoolVar(&validateStrict, "strict", false, "exit with a non-zero status when warnings are found")
+	utils.LogIfError(cmdValidate.MarkFlagRequired("query-file"))
+}
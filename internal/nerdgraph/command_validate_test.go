@@ -0,0 +1,18 @@
+// +build unit
+
+package nerdgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, "validate", cmdValidate.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdValidate)
+	testcobra.CheckCobraRequiredFlags(t, cmdValidate, []string{"query-file"})
+}
@@ -0,0 +1,805 @@
+package nerdgraph
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// gqlDocument is a parsed GraphQL executable document: the operations and
+// fragments a client sends, as opposed to a schema definition.
+type gqlDocument struct {
+	Operations []*gqlOperation
+	Fragments  []*gqlFragment
+}
+
+type gqlOperation struct {
+	Type         string
+	Name         string
+	Variables    []*gqlVariableDefinition
+	Directives   []*gqlDirective
+	SelectionSet []*gqlSelection
+	Pos          int
+}
+
+type gqlFragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*gqlDirective
+	SelectionSet  []*gqlSelection
+	Pos           int
+	TypePos       int
+}
+
+type gqlVariableDefinition struct {
+	Name    string
+	Type    *gqlType
+	Default *gqlValue
+	Pos     int
+}
+
+// gqlType is a type reference within a document, such as [String!]!.
+type gqlType struct {
+	Name    string
+	Elem    *gqlType
+	NonNull bool
+	Pos     int
+}
+
+func (t *gqlType) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+
+	if t.NonNull {
+		s += "!"
+	}
+
+	return s
+}
+
+const (
+	gqlSelectionField          = "field"
+	gqlSelectionFragmentSpread = "fragment spread"
+	gqlSelectionInlineFragment = "inline fragment"
+)
+
+// gqlSelection is a field, fragment spread or inline fragment.
+type gqlSelection struct {
+	Kind          string
+	Alias         string
+	Name          string
+	Arguments     []*gqlArgument
+	Directives    []*gqlDirective
+	SelectionSet  []*gqlSelection
+	TypeCondition string
+	Pos           int
+}
+
+type gqlArgument struct {
+	Name  string
+	Value *gqlValue
+	Pos   int
+}
+
+type gqlDirective struct {
+	Name      string
+	Arguments []*gqlArgument
+	Pos       int
+}
+
+const (
+	gqlValueVariable = "variable"
+	gqlValueInt      = "Int"
+	gqlValueFloat    = "Float"
+	gqlValueString   = "String"
+	gqlValueBoolean  = "Boolean"
+	gqlValueNull     = "null"
+	gqlValueEnum     = "enum"
+	gqlValueList     = "list"
+	gqlValueObject   = "object"
+)
+
+// gqlValue is a literal or variable given as an argument.
+type gqlValue struct {
+	Kind   string
+	Raw    string
+	List   []*gqlValue
+	Fields []*gqlArgument
+	Pos    int
+}
+
+// gqlSyntaxError is an error found while parsing a document.
+type gqlSyntaxError struct {
+	Pos     int
+	Message string
+}
+
+func (e *gqlSyntaxError) Error() string {
+	return e.Message
+}
+
+const (
+	gqlTokenEOF         = "end of document"
+	gqlTokenPunctuator  = "punctuator"
+	gqlTokenName        = "name"
+	gqlTokenInt         = "integer"
+	gqlTokenFloat       = "float"
+	gqlTokenString      = "string"
+	gqlTokenBlockString = "block string"
+)
+
+type gqlToken struct {
+	Kind  string
+	Value string
+	Pos   int
+}
+
+func (t gqlToken) String() string {
+	switch t.Kind {
+	case gqlTokenEOF, gqlTokenString, gqlTokenBlockString:
+		return t.Kind
+	}
+
+	return fmt.Sprintf("%q", t.Value)
+}
+
+// lexGraphQL splits a document into tokens, skipping whitespace, commas and
+// comments.
+func lexGraphQL(source string) ([]gqlToken, error) {
+	var tokens []gqlToken
+
+	for i := 0; i < len(source); {
+		c := source[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case strings.HasPrefix(source[i:], "\ufeff"):
+			i += len("\ufeff")
+		case c == '#':
+			for i < len(source) && source[i] != '\n' && source[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "..."):
+			tokens = append(tokens, gqlToken{Kind: gqlTokenPunctuator, Value: "...", Pos: i})
+			i += 3
+		case strings.ContainsRune("!$&()[]{}:=@|", rune(c)):
+			tokens = append(tokens, gqlToken{Kind: gqlTokenPunctuator, Value: string(c), Pos: i})
+			i++
+		case c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z':
+			start := i
+			for i < len(source) && isGraphQLNameChar(source[i]) {
+				i++
+			}
+			tokens = append(tokens, gqlToken{Kind: gqlTokenName, Value: source[start:i], Pos: start})
+		case c == '-' || c >= '0' && c <= '9':
+			token, end, err := lexGraphQLNumber(source, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i = end
+		case strings.HasPrefix(source[i:], `"""`):
+			token, end, err := lexGraphQLBlockString(source, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i = end
+		case c == '"':
+			token, end, err := lexGraphQLString(source, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i = end
+		default:
+			r, _ := utf8.DecodeRuneInString(source[i:])
+			return nil, &gqlSyntaxError{Pos: i, Message: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, gqlToken{Kind: gqlTokenEOF, Pos: len(source)}), nil
+}
+
+func isGraphQLNameChar(c byte) bool {
+	return c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
+
+func lexGraphQLNumber(source string, start int) (gqlToken, int, error) {
+	i := start
+	kind := gqlTokenInt
+
+	digits := func() int {
+		n := 0
+		for i < len(source) && source[i] >= '0' && source[i] <= '9' {
+			i++
+			n++
+		}
+		return n
+	}
+
+	if source[i] == '-' {
+		i++
+	}
+
+	if digits() == 0 {
+		return gqlToken{}, 0, &gqlSyntaxError{Pos: start, Message: "invalid number, expected a digit"}
+	}
+
+	if i < len(source) && source[i] == '.' {
+		kind = gqlTokenFloat
+		i++
+		if digits() == 0 {
+			return gqlToken{}, 0, &gqlSyntaxError{Pos: i, Message: "invalid number, expected a digit after the decimal point"}
+		}
+	}
+
+	if i < len(source) && (source[i] == 'e' || source[i] == 'E') {
+		kind = gqlTokenFloat
+		i++
+		if i < len(source) && (source[i] == '+' || source[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return gqlToken{}, 0, &gqlSyntaxError{Pos: i, Message: "invalid number, expected a digit in the exponent"}
+		}
+	}
+
+	if i < len(source) && (isGraphQLNameChar(source[i]) || source[i] == '.') {
+		return gqlToken{}, 0, &gqlSyntaxError{Pos: i, Message: fmt.Sprintf("invalid number, unexpected %q", source[i])}
+	}
+
+	return gqlToken{Kind: kind, Value: source[start:i], Pos: start}, i, nil
+}
+
+var gqlStringEscapes = map[byte]string{'"': `"`, '\\': `\`, '/': "/", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t"}
+
+func lexGraphQLString(source string, start int) (gqlToken, int, error) {
+	var b strings.Builder
+
+	for i := start + 1; i < len(source); {
+		c := source[i]
+
+		switch c {
+		case '"':
+			return gqlToken{Kind: gqlTokenString, Value: b.String(), Pos: start}, i + 1, nil
+		case '\n', '\r':
+			return gqlToken{}, 0, &gqlSyntaxError{Pos: i, Message: "unterminated string"}
+		case '\\':
+			if i+1 >= len(source) {
+				return gqlToken{}, 0, &gqlSyntaxError{Pos: i, Message: "unterminated string"}
+			}
+
+			if e, ok := gqlStringEscapes[source[i+1]]; ok {
+				b.WriteString(e)
+				i += 2
+				continue
+			}
+
+			if source[i+1] == 'u' && i+6 <= len(source) {
+				var r rune
+				if _, err := fmt.Sscanf(source[i+2:i+6], "%04x", &r); err == nil {
+					b.WriteRune(r)
+					i += 6
+					continue
+				}
+			}
+
+			return gqlToken{}, 0, &gqlSyntaxError{Pos: i, Message: "invalid escape sequence in string"}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return gqlToken{}, 0, &gqlSyntaxError{Pos: start, Message: "unterminated string"}
+}
+
+func lexGraphQLBlockString(source string, start int) (gqlToken, int, error) {
+	var b strings.Builder
+
+	for i := start + 3; i < len(source); {
+		switch {
+		case strings.HasPrefix(source[i:], `\"""`):
+			b.WriteString(`"""`)
+			i += 4
+		case strings.HasPrefix(source[i:], `"""`):
+			return gqlToken{Kind: gqlTokenBlockString, Value: b.String(), Pos: start}, i + 3, nil
+		default:
+			b.WriteByte(source[i])
+			i++
+		}
+	}
+
+	return gqlToken{}, 0, &gqlSyntaxError{Pos: start, Message: "unterminated block string"}
+}
+
+// gqlParser is a recursive descent parser for executable documents.
+type gqlParser struct {
+	tokens []gqlToken
+	pos    int
+}
+
+// parseGraphQL parses an executable document, stopping at the first syntax
+// error.
+func parseGraphQL(source string) (*gqlDocument, error) {
+	tokens, err := lexGraphQL(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &gqlParser{tokens: tokens}
+
+	return p.document()
+}
+
+func (p *gqlParser) peek() gqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *gqlParser) next() gqlToken {
+	t := p.tokens[p.pos]
+	if t.Kind != gqlTokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *gqlParser) is(value string) bool {
+	t := p.peek()
+	return (t.Kind == gqlTokenPunctuator || t.Kind == gqlTokenName) && t.Value == value
+}
+
+func (p *gqlParser) skip(value string) bool {
+	if p.is(value) {
+		p.next()
+		return true
+	}
+
+	return false
+}
+
+func (p *gqlParser) unexpected(expected string) error {
+	t := p.peek()
+	return &gqlSyntaxError{Pos: t.Pos, Message: fmt.Sprintf("expected %s, found %s", expected, t)}
+}
+
+func (p *gqlParser) expect(value string) (gqlToken, error) {
+	if !p.is(value) {
+		return gqlToken{}, p.unexpected(fmt.Sprintf("%q", value))
+	}
+
+	return p.next(), nil
+}
+
+func (p *gqlParser) name() (gqlToken, error) {
+	if p.peek().Kind != gqlTokenName {
+		return gqlToken{}, p.unexpected("a name")
+	}
+
+	return p.next(), nil
+}
+
+func (p *gqlParser) document() (*gqlDocument, error) {
+	doc := &gqlDocument{}
+
+	if p.peek().Kind == gqlTokenEOF {
+		return nil, p.unexpected("an operation or fragment")
+	}
+
+	for p.peek().Kind != gqlTokenEOF {
+		switch {
+		case p.is("{"), p.is("query"), p.is("mutation"), p.is("subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.is("fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			doc.Fragments = append(doc.Fragments, f)
+		default:
+			return nil, p.unexpected("an operation or fragment")
+		}
+	}
+
+	return doc, nil
+}
+
+func (p *gqlParser) operation() (*gqlOperation, error) {
+	op := &gqlOperation{Type: "query", Pos: p.peek().Pos}
+
+	if !p.is("{") {
+		op.Type = p.next().Value
+
+		if p.peek().Kind == gqlTokenName {
+			op.Name = p.next().Value
+		}
+
+		if p.is("(") {
+			vars, err := p.variableDefinitions()
+			if err != nil {
+				return nil, err
+			}
+			op.Variables = vars
+		}
+
+		directives, err := p.directives()
+		if err != nil {
+			return nil, err
+		}
+		op.Directives = directives
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.SelectionSet = selections
+
+	return op, nil
+}
+
+func (p *gqlParser) fragment() (*gqlFragment, error) {
+	start := p.next()
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if name.Value == "on" {
+		return nil, &gqlSyntaxError{Pos: name.Pos, Message: "a fragment cannot be named on"}
+	}
+
+	if _, err = p.expect("on"); err != nil {
+		return nil, err
+	}
+
+	typeName, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	directives, err := p.directives()
+	if err != nil {
+		return nil, err
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return &gqlFragment{
+		Name:          name.Value,
+		TypeCondition: typeName.Value,
+		Directives:    directives,
+		SelectionSet:  selections,
+		Pos:           start.Pos,
+		TypePos:       typeName.Pos,
+	}, nil
+}
+
+func (p *gqlParser) variableDefinitions() ([]*gqlVariableDefinition, error) {
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var defs []*gqlVariableDefinition
+
+	for !p.skip(")") {
+		start, err := p.expect("$")
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		t, err := p.typeReference()
+		if err != nil {
+			return nil, err
+		}
+
+		def := &gqlVariableDefinition{Name: name.Value, Type: t, Pos: start.Pos}
+
+		if p.skip("=") {
+			if def.Default, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err = p.directives(); err != nil {
+			return nil, err
+		}
+
+		defs = append(defs, def)
+	}
+
+	if len(defs) == 0 {
+		return nil, &gqlSyntaxError{Pos: p.tokens[p.pos-1].Pos, Message: "expected a variable definition, found \")\""}
+	}
+
+	return defs, nil
+}
+
+func (p *gqlParser) typeReference() (*gqlType, error) {
+	var t *gqlType
+
+	if start := p.peek(); p.skip("[") {
+		elem, err := p.typeReference()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = p.expect("]"); err != nil {
+			return nil, err
+		}
+
+		t = &gqlType{Elem: elem, Pos: start.Pos}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, p.unexpected("a type")
+		}
+
+		t = &gqlType{Name: name.Value, Pos: name.Pos}
+	}
+
+	t.NonNull = p.skip("!")
+
+	return t, nil
+}
+
+func (p *gqlParser) directives() ([]*gqlDirective, error) {
+	var directives []*gqlDirective
+
+	for p.is("@") {
+		start := p.next()
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		args, err := p.arguments(false)
+		if err != nil {
+			return nil, err
+		}
+
+		directives = append(directives, &gqlDirective{Name: name.Value, Arguments: args, Pos: start.Pos})
+	}
+
+	return directives, nil
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlSelection, error) {
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []*gqlSelection
+
+	for !p.skip("}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+
+		selections = append(selections, s)
+	}
+
+	if len(selections) == 0 {
+		return nil, &gqlSyntaxError{Pos: p.tokens[p.pos-1].Pos, Message: "expected a selection, found \"}\""}
+	}
+
+	return selections, nil
+}
+
+func (p *gqlParser) selection() (*gqlSelection, error) {
+	if start := p.peek(); p.skip("...") {
+		if p.peek().Kind == gqlTokenName && !p.is("on") {
+			name := p.next()
+
+			directives, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+
+			return &gqlSelection{Kind: gqlSelectionFragmentSpread, Name: name.Value, Directives: directives, Pos: name.Pos}, nil
+		}
+
+		s := &gqlSelection{Kind: gqlSelectionInlineFragment, Pos: start.Pos}
+
+		if p.skip("on") {
+			typeName, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+
+			s.TypeCondition = typeName.Value
+		}
+
+		var err error
+		if s.Directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+
+		if s.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, p.unexpected("a field")
+	}
+
+	s := &gqlSelection{Kind: gqlSelectionField, Name: name.Value, Pos: name.Pos}
+
+	if p.skip(":") {
+		alias := name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+
+		s.Alias, s.Name, s.Pos = alias.Value, name.Value, name.Pos
+	}
+
+	if s.Arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+
+	if s.Directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+
+	if p.is("{") {
+		if s.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (p *gqlParser) arguments(constant bool) ([]*gqlArgument, error) {
+	if !p.skip("(") {
+		return nil, nil
+	}
+
+	var args []*gqlArgument
+
+	for !p.skip(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		v, err := p.value(constant)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, &gqlArgument{Name: name.Value, Value: v, Pos: name.Pos})
+	}
+
+	if len(args) == 0 {
+		return nil, &gqlSyntaxError{Pos: p.tokens[p.pos-1].Pos, Message: "expected an argument, found \")\""}
+	}
+
+	return args, nil
+}
+
+// value parses a literal or variable.  Variables are not allowed in constant
+// values, such as the defaults of variable definitions.
+func (p *gqlParser) value(constant bool) (*gqlValue, error) {
+	t := p.peek()
+
+	switch t.Kind {
+	case gqlTokenInt:
+		p.next()
+		return &gqlValue{Kind: gqlValueInt, Raw: t.Value, Pos: t.Pos}, nil
+	case gqlTokenFloat:
+		p.next()
+		return &gqlValue{Kind: gqlValueFloat, Raw: t.Value, Pos: t.Pos}, nil
+	case gqlTokenString, gqlTokenBlockString:
+		p.next()
+		return &gqlValue{Kind: gqlValueString, Raw: t.Value, Pos: t.Pos}, nil
+	case gqlTokenName:
+		p.next()
+		switch t.Value {
+		case "true", "false":
+			return &gqlValue{Kind: gqlValueBoolean, Raw: t.Value, Pos: t.Pos}, nil
+		case "null":
+			return &gqlValue{Kind: gqlValueNull, Raw: t.Value, Pos: t.Pos}, nil
+		}
+		return &gqlValue{Kind: gqlValueEnum, Raw: t.Value, Pos: t.Pos}, nil
+	}
+
+	switch {
+	case p.is("$") && !constant:
+		p.next()
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		return &gqlValue{Kind: gqlValueVariable, Raw: name.Value, Pos: t.Pos}, nil
+	case p.skip("["):
+		v := &gqlValue{Kind: gqlValueList, Pos: t.Pos}
+
+		for !p.skip("]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+
+			v.List = append(v.List, item)
+		}
+
+		return v, nil
+	case p.skip("{"):
+		v := &gqlValue{Kind: gqlValueObject, Pos: t.Pos}
+
+		for !p.skip("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err = p.expect(":"); err != nil {
+				return nil, err
+			}
+
+			field, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+
+			v.Fields = append(v.Fields, &gqlArgument{Name: name.Value, Value: field, Pos: name.Pos})
+		}
+
+		return v, nil
+	}
+
+	if p.is("$") {
+		return nil, &gqlSyntaxError{Pos: t.Pos, Message: "variables are not allowed in default values"}
+	}
+
+	return nil, p.unexpected("a value")
+}
+
+// gqlLineAndColumn converts an offset into a line and column, both starting
+// at 1.  Columns count characters rather than bytes.
+func gqlLineAndColumn(source string, pos int) (int, int) {
+	if pos > len(source) {
+		pos = len(source)
+	}
+
+	line := 1 + strings.Count(source[:pos], "\n")
+	lineStart := strings.LastIndex(source[:pos], "\n") + 1
+
+	return line, 1 + utf8.RuneCountInString(source[lineStart:pos])
+}
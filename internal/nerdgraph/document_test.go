@@ -0,0 +1,99 @@
+// +build unit
+
+package nerdgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGraphQL(t *testing.T) {
+	doc, err := parseGraphQL(`
+# Look up an entity
+query Entity($guid: EntityGuid!, $sort: [EntitySearchSortCriteria!] = [NAME]) @cached {
+  actor {
+    e: entity(guid: $guid) { guid ...names }
+    entitySearch(query: "name = 'x'", sortBy: $sort) @include(if: true) {
+      results { ... on EntitySearchResult { nextCursor } }
+    }
+  }
+}
+
+fragment names on Entity { name }
+`)
+	require.NoError(t, err)
+
+	require.Len(t, doc.Operations, 1)
+	op := doc.Operations[0]
+	assert.Equal(t, "query", op.Type)
+	assert.Equal(t, "Entity", op.Name)
+	require.Len(t, op.Variables, 2)
+	assert.Equal(t, "EntityGuid!", op.Variables[0].Type.String())
+	assert.Equal(t, "[EntitySearchSortCriteria!]", op.Variables[1].Type.String())
+	assert.Equal(t, gqlValueList, op.Variables[1].Default.Kind)
+	require.Len(t, op.Directives, 1)
+
+	actor := op.SelectionSet[0]
+	require.Len(t, actor.SelectionSet, 2)
+
+	entity := actor.SelectionSet[0]
+	assert.Equal(t, "e", entity.Alias)
+	assert.Equal(t, "entity", entity.Name)
+	assert.Equal(t, gqlValueVariable, entity.Arguments[0].Value.Kind)
+	assert.Equal(t, gqlSelectionFragmentSpread, entity.SelectionSet[1].Kind)
+
+	search := actor.SelectionSet[1]
+	assert.Equal(t, "name = 'x'", search.Arguments[0].Value.Raw)
+	assert.Equal(t, "include", search.Directives[0].Name)
+	assert.Equal(t, gqlSelectionInlineFragment, search.SelectionSet[0].SelectionSet[0].Kind)
+
+	require.Len(t, doc.Fragments, 1)
+	assert.Equal(t, "Entity", doc.Fragments[0].TypeCondition)
+}
+
+func TestParseGraphQLShorthand(t *testing.T) {
+	doc, err := parseGraphQL(`{ actor { user { name } } }`)
+	require.NoError(t, err)
+
+	require.Len(t, doc.Operations, 1)
+	assert.Equal(t, "query", doc.Operations[0].Type)
+	assert.Equal(t, "", doc.Operations[0].Name)
+}
+
+func TestParseGraphQLErrors(t *testing.T) {
+	cases := map[string]string{
+		`{ actor { `:                             "end of document",
+		`query($x: ) { a }`:                      "expected",
+		`{ a(x: "unterminated) }`:                "unterminated string",
+		`query Q($x: Int = $y) { a }`:            "variable",
+		`fragment on on Entity { name }`:         "on",
+		`{ a(x: 1.) }`:                           "number",
+		`{ a } }`:                                "}",
+		`mutation { a(input: {b: 1, }) { c } } `: "",
+	}
+
+	for source, message := range cases {
+		_, err := parseGraphQL(source)
+		if message == "" {
+			assert.NoError(t, err, source)
+			continue
+		}
+
+		require.Error(t, err, source)
+		assert.Contains(t, err.Error(), message, source)
+	}
+}
+
+func TestGQLLineAndColumn(t *testing.T) {
+	source := "query {\n  actor\n}"
+
+	line, column := gqlLineAndColumn(source, 0)
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 1, column)
+
+	line, column = gqlLineAndColumn(source, 10)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, 3, column)
+}
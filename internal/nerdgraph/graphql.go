@@ -0,0 +1,220 @@
+package nerdgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+)
+
+// graphQLRequest is the body of a request to the NerdGraph API.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// graphQLError is a single entry of the errors array of a GraphQL response.
+type graphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// graphQLResponse is a GraphQL response document.  The raw body is kept so
+// that it can be printed exactly as it was received.
+type graphQLResponse struct {
+	Raw        []byte
+	Data       interface{}
+	Errors     []graphQLError
+	Extensions interface{}
+}
+
+// Document returns the response as a generic value for printing in formats
+// other than JSON.
+func (r *graphQLResponse) Document() map[string]interface{} {
+	doc := map[string]interface{}{}
+	_ = unmarshalJSON(r.Raw, &doc)
+
+	return normalizeNumbers(doc).(map[string]interface{})
+}
+
+// HasErrors reports whether the response contains GraphQL errors.
+func (r *graphQLResponse) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// IsPartial reports whether the response contains GraphQL errors along with
+// data for at least one of the requested root fields.
+func (r *graphQLResponse) IsPartial() bool {
+	if !r.HasErrors() {
+		return false
+	}
+
+	data, ok := r.Data.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, v := range data {
+		if v != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrorSummary joins the messages of the response's errors, along with the
+// path each occurred at.
+func (r *graphQLResponse) ErrorSummary() string {
+	messages := make([]string, len(r.Errors))
+
+	for i, e := range r.Errors {
+		messages[i] = e.Message
+
+		if len(e.Path) > 0 {
+			path := make([]string, len(e.Path))
+			for j, p := range e.Path {
+				path[j] = fmt.Sprint(p)
+			}
+
+			messages[i] = fmt.Sprintf("%s (at %s)", e.Message, strings.Join(path, "."))
+		}
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// parseGraphQLResponse decodes a GraphQL response document.
+func parseGraphQLResponse(body []byte) (*graphQLResponse, error) {
+	var doc struct {
+		Data       interface{}    `json:"data"`
+		Errors     []graphQLError `json:"errors"`
+		Extensions interface{}    `json:"extensions"`
+	}
+
+	if err := unmarshalJSON(body, &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse the NerdGraph response: %s", err)
+	}
+
+	return &graphQLResponse{
+		Raw:        body,
+		Data:       doc.Data,
+		Errors:     doc.Errors,
+		Extensions: doc.Extensions,
+	}, nil
+}
+
+// unmarshalJSON decodes JSON keeping numbers as json.Number, so that large
+// integers such as account IDs and timestamps are reproduced exactly.
+func unmarshalJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+
+	return nil
+}
+
+// normalizeNumbers replaces the json.Number values within a decoded document
+// with integers where possible, or floats otherwise, so that they are printed
+// as numbers by the YAML and text formatters.
+func normalizeNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeNumbers(e)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+	}
+
+	return v
+}
+
+// defaultRequestTimeout is how long to wait for a NerdGraph response, the same
+// as the client's default.
+const defaultRequestTimeout = 30 * time.Second
+
+// requester sends requests directly to the NerdGraph API.  Unlike the client's
+// NerdGraph API, which discards everything outside of data when errors are
+// returned, it returns the complete response document.
+type requester struct {
+	endpoint   *client.NerdGraphEndpoint
+	httpClient *http.Client
+}
+
+// newRequester returns a requester that gives up on a request when no complete
+// response has been received within the timeout.
+func newRequester(endpoint *client.NerdGraphEndpoint, timeout time.Duration) *requester {
+	return &requester{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Do sends a request and returns the response document.  Responses with GraphQL
+// errors are returned without an error, while failures to reach NerdGraph or
+// responses that are not GraphQL documents return one.
+func (r *requester) Do(ctx context.Context, req graphQLRequest) (*graphQLResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Api-Key", r.endpoint.APIKey)
+	httpReq.Header.Set("User-Agent", r.endpoint.UserAgent)
+	httpReq.Header.Set("NewRelic-Requesting-Services", "newrelic-cli")
+
+	resp, err := r.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("NerdGraph request was not authorized (%s), check the API key of your profile", resp.Status)
+	}
+
+	result, err := parseGraphQLResponse(respBody)
+	if err != nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return nil, fmt.Errorf("unexpected response from NerdGraph: %s", resp.Status)
+	}
+
+	return result, err
+}
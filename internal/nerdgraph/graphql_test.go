@@ -0,0 +1,142 @@
+// +build unit
+
+package nerdgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+)
+
+func TestParseGraphQLResponse(t *testing.T) {
+	body := []byte(`{"data":{"actor":null,"taggingAddTagsToEntity":{"errors":[]}},"errors":[{"message":"Access denied","path":["actor","account",0]}],"extensions":{"nrOnly":{"cost":12}}}`)
+
+	result, err := parseGraphQLResponse(body)
+	require.NoError(t, err)
+
+	assert.Equal(t, body, result.Raw)
+	assert.True(t, result.HasErrors())
+	assert.True(t, result.IsPartial())
+	assert.Equal(t, "Access denied (at actor.account.0)", result.ErrorSummary())
+	assert.NotNil(t, result.Extensions)
+
+	doc := result.Document()
+	assert.Contains(t, doc, "data")
+	assert.Contains(t, doc, "errors")
+	assert.Equal(t, int64(12), doc["extensions"].(map[string]interface{})["nrOnly"].(map[string]interface{})["cost"])
+}
+
+func TestParseGraphQLResponseInvalid(t *testing.T) {
+	_, err := parseGraphQLResponse([]byte(`<html>`))
+	assert.Error(t, err)
+}
+
+func TestIsPartial(t *testing.T) {
+	cases := []struct {
+		body    string
+		partial bool
+	}{
+		{`{"data":{"actor":{"user":{"name":"x"}}}}`, false},
+		{`{"data":null,"errors":[{"message":"bad"}]}`, false},
+		{`{"data":{"actor":null},"errors":[{"message":"bad"}]}`, false},
+		{`{"data":{"actor":{"user":null}},"errors":[{"message":"bad"}]}`, true},
+	}
+
+	for _, c := range cases {
+		result, err := parseGraphQLResponse([]byte(c.body))
+		require.NoError(t, err)
+		assert.Equal(t, c.partial, result.IsPartial(), c.body)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	ok, _ := parseGraphQLResponse([]byte(`{"data":{"actor":{}}}`))
+	assert.NoError(t, checkResponse(ok, true))
+
+	failed, _ := parseGraphQLResponse([]byte(`{"data":null,"errors":[{"message":"bad"}]}`))
+	assert.EqualError(t, checkResponse(failed, false), "bad")
+
+	partial, _ := parseGraphQLResponse([]byte(`{"data":{"actor":{"user":null}},"errors":[{"message":"bad"}]}`))
+	assert.NoError(t, checkResponse(partial, false))
+	assert.EqualError(t, checkResponse(partial, true), "bad")
+}
+
+func TestRequesterDo(t *testing.T) {
+	var received graphQLRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.Header.Get("Api-Key"))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"Field 'x' doesn't exist"}]}`))
+	}))
+	defer server.Close()
+
+	r := newRequester(&client.NerdGraphEndpoint{URL: server.URL, APIKey: "test-key"}, defaultRequestTimeout)
+
+	result, err := r.Do(context.Background(), graphQLRequest{
+		Query:     "mutation { x }",
+		Variables: map[string]interface{}{"a": "b"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "mutation { x }", received.Query)
+	assert.Equal(t, "b", received.Variables["a"])
+	assert.True(t, result.HasErrors())
+	assert.False(t, result.IsPartial())
+}
+
+func TestRequesterDoHTTPErrors(t *testing.T) {
+	status := http.StatusUnauthorized
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`bad gateway`))
+	}))
+	defer server.Close()
+
+	r := newRequester(&client.NerdGraphEndpoint{URL: server.URL}, defaultRequestTimeout)
+
+	_, err := r.Do(context.Background(), graphQLRequest{Query: "{ actor { user { name } } }"})
+	assert.Contains(t, err.Error(), "not authorized")
+
+	status = http.StatusBadGateway
+	_, err = r.Do(context.Background(), graphQLRequest{Query: "{ actor { user { name } } }"})
+	assert.EqualError(t, err, "unexpected response from NerdGraph: 502 Bad Gateway")
+}
+
+func TestRequesterDoTimeout(t *testing.T) {
+	done := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	r := newRequester(&client.NerdGraphEndpoint{URL: server.URL}, 50*time.Millisecond)
+
+	_, err := r.Do(context.Background(), graphQLRequest{Query: "{ actor { user { name } } }"})
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r = newRequester(&client.NerdGraphEndpoint{URL: server.URL}, defaultRequestTimeout)
+
+	_, err = r.Do(ctx, graphQLRequest{Query: "{ actor { user { name } } }"})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
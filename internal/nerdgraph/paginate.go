@@ -0,0 +1,233 @@
+package nerdgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variableDefinitionRegex matches the variable definitions of a GraphQL
+// document, such as $cursor: String.  Variables are only followed by a colon
+// where they are defined.
+var variableDefinitionRegex = regexp.MustCompile(`\$([_A-Za-z][_0-9A-Za-z]*)\s*:`)
+
+// graphQLDoer sends a request to NerdGraph.
+type graphQLDoer interface {
+	Do(ctx context.Context, req graphQLRequest) (*graphQLResponse, error)
+}
+
+// paginator re-issues a query for as long as the object at path in the
+// response returns a nextCursor, passing it back in the cursor variable.
+type paginator struct {
+	doer           graphQLDoer
+	path           []string
+	cursorVariable string
+	failOnPartial  bool
+}
+
+// newPaginator returns a paginator for the object at the given dotted path
+// below data.  When cursorVariable is empty, the variable is detected from
+// the query's variable definitions.
+func newPaginator(doer graphQLDoer, path string, document string, cursorVariable string, failOnPartial bool) (*paginator, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "data.")
+	if p == "" {
+		return nil, fmt.Errorf("invalid path to paginate: %s", path)
+	}
+
+	variable, err := detectCursorVariable(document, cursorVariable)
+	if err != nil {
+		return nil, err
+	}
+
+	return &paginator{
+		doer:           doer,
+		path:           strings.Split(p, "."),
+		cursorVariable: variable,
+		failOnPartial:  failOnPartial,
+	}, nil
+}
+
+// detectCursorVariable returns the variable the cursor is passed in, which is
+// either the given name, or the only variable of the document whose name
+// contains cursor.
+func detectCursorVariable(document string, name string) (string, error) {
+	var candidates []string
+	declared := map[string]bool{}
+
+	for _, m := range variableDefinitionRegex.FindAllStringSubmatch(document, -1) {
+		if declared[m[1]] {
+			continue
+		}
+
+		declared[m[1]] = true
+		if strings.Contains(strings.ToLower(m[1]), "cursor") {
+			candidates = append(candidates, m[1])
+		}
+	}
+
+	if name != "" {
+		name = strings.TrimPrefix(name, "$")
+		if !declared[name] {
+			return "", fmt.Errorf("the query does not declare the variable $%s", name)
+		}
+
+		return name, nil
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", errors.New("unable to find the cursor variable, declare one such as $cursor: String and pass it to the paginated field")
+	case 1:
+		return candidates[0], nil
+	}
+
+	return "", fmt.Errorf("the query declares several cursor variables (%s), use --cursor-variable to select one", strings.Join(candidates, ", "))
+}
+
+// Run issues the query until the last page has been returned, calling page
+// with each response and the paginated object within it.
+func (p *paginator) Run(ctx context.Context, req graphQLRequest, page func(result *graphQLResponse, object map[string]interface{}) error) error {
+	seen := map[string]bool{}
+
+	for {
+		result, err := p.doer.Do(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if err = checkResponse(result, p.failOnPartial); err != nil {
+			return err
+		}
+
+		object, err := p.object(result.Data)
+		if err != nil {
+			return err
+		}
+
+		if err = page(result, object); err != nil {
+			return err
+		}
+
+		cursor, _ := object["nextCursor"].(string)
+		if cursor == "" {
+			return nil
+		}
+
+		if seen[cursor] {
+			return fmt.Errorf("NerdGraph returned the cursor %s more than once", cursor)
+		}
+
+		seen[cursor] = true
+		req = p.nextRequest(req, cursor)
+	}
+}
+
+// object returns the paginated object within the data of a response.
+func (p *paginator) object(data interface{}) (map[string]interface{}, error) {
+	current := data
+
+	for _, key := range p.path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		current = m[key]
+	}
+
+	object, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the response does not contain an object at %s", strings.Join(p.path, "."))
+	}
+
+	return object, nil
+}
+
+func (p *paginator) nextRequest(req graphQLRequest, cursor string) graphQLRequest {
+	vars := make(map[string]interface{}, len(req.Variables)+1)
+	for k, v := range req.Variables {
+		vars[k] = v
+	}
+
+	vars[p.cursorVariable] = cursor
+	req.Variables = vars
+
+	return req
+}
+
+// pageConcatenator combines the pages of a paginated query into the document
+// of the first page, with the lists of the paginated object concatenated.
+type pageConcatenator struct {
+	paginator *paginator
+	document  map[string]interface{}
+	object    map[string]interface{}
+}
+
+func (c *pageConcatenator) Add(result *graphQLResponse, object map[string]interface{}) error {
+	if c.document == nil {
+		c.document = result.Document()
+
+		first, err := c.paginator.object(c.document["data"])
+		if err != nil {
+			return err
+		}
+
+		c.object = first
+	} else {
+		for k, v := range object {
+			if list, ok := normalizeNumbers(v).([]interface{}); ok {
+				existing, _ := c.object[k].([]interface{})
+				c.object[k] = append(existing, list...)
+			}
+		}
+	}
+
+	c.object["nextCursor"] = nil
+
+	return nil
+}
+
+// Document returns the combined document.
+func (c *pageConcatenator) Document() map[string]interface{} {
+	return c.document
+}
+
+// ndjsonPageWriter writes each item of the lists of every page as a line of
+// JSON, as soon as the page is returned.
+type ndjsonPageWriter struct {
+	writer io.Writer
+}
+
+func (w *ndjsonPageWriter) Add(result *graphQLResponse, object map[string]interface{}) error {
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		list, ok := object[k].([]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, item := range list {
+			line, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintln(w.writer, string(line)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// +build unit
+
+package nerdgraph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const entitySearchQuery = `query($query: String, $cursor: String) {
+  actor { entitySearch(query: $query) { results(cursor: $cursor) { nextCursor entities { guid } } } }
+}`
+
+// fakeDoer returns a page of entities for each cursor.
+type fakeDoer struct {
+	pages    map[string]string
+	requests []graphQLRequest
+}
+
+func (f *fakeDoer) Do(ctx context.Context, req graphQLRequest) (*graphQLResponse, error) {
+	f.requests = append(f.requests, req)
+
+	cursor, _ := req.Variables["cursor"].(string)
+
+	body, ok := f.pages[cursor]
+	if !ok {
+		return nil, fmt.Errorf("unexpected cursor %s", cursor)
+	}
+
+	return parseGraphQLResponse([]byte(body))
+}
+
+func newFakeDoer() *fakeDoer {
+	return &fakeDoer{
+		pages: map[string]string{
+			"":   `{"data":{"actor":{"entitySearch":{"results":{"nextCursor":"c1","entities":[{"guid":"a"},{"guid":"b"}]}}}},"extensions":{"cost":1}}`,
+			"c1": `{"data":{"actor":{"entitySearch":{"results":{"nextCursor":"c2","entities":[{"guid":"c"}]}}}}}`,
+			"c2": `{"data":{"actor":{"entitySearch":{"results":{"nextCursor":null,"entities":[{"guid":"d"}]}}}}}`,
+		},
+	}
+}
+
+func TestDetectCursorVariable(t *testing.T) {
+	v, err := detectCursorVariable(entitySearchQuery, "")
+	require.NoError(t, err)
+	assert.Equal(t, "cursor", v)
+
+	v, err = detectCursorVariable(entitySearchQuery, "$query")
+	require.NoError(t, err)
+	assert.Equal(t, "query", v)
+
+	_, err = detectCursorVariable(entitySearchQuery, "missing")
+	assert.Error(t, err)
+
+	_, err = detectCursorVariable(`query($query: String) { x }`, "")
+	assert.Error(t, err)
+
+	_, err = detectCursorVariable(`query($cursor: String, $nextCursor: String) { x }`, "")
+	assert.Error(t, err)
+}
+
+func TestPaginatorConcatenates(t *testing.T) {
+	doer := newFakeDoer()
+
+	p, err := newPaginator(doer, "actor.entitySearch.results", entitySearchQuery, "", false)
+	require.NoError(t, err)
+
+	req := graphQLRequest{Query: entitySearchQuery, Variables: map[string]interface{}{"query": "domain = 'APM'"}}
+
+	c := &pageConcatenator{paginator: p}
+	require.NoError(t, p.Run(context.Background(), req, c.Add))
+
+	require.Len(t, doer.requests, 3)
+	assert.Nil(t, doer.requests[0].Variables["cursor"])
+	assert.Equal(t, "c2", doer.requests[2].Variables["cursor"])
+	assert.Equal(t, "domain = 'APM'", doer.requests[2].Variables["query"])
+
+	doc := c.Document()
+	results, err := p.object(doc["data"])
+	require.NoError(t, err)
+
+	assert.Nil(t, results["nextCursor"])
+	assert.Len(t, results["entities"], 4)
+	assert.Contains(t, doc, "extensions")
+}
+
+func TestPaginatorStreams(t *testing.T) {
+	p, err := newPaginator(newFakeDoer(), "data.actor.entitySearch.results", entitySearchQuery, "", false)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w := &ndjsonPageWriter{writer: &buf}
+	require.NoError(t, p.Run(context.Background(), graphQLRequest{Query: entitySearchQuery}, w.Add))
+
+	assert.Equal(t, "{\"guid\":\"a\"}\n{\"guid\":\"b\"}\n{\"guid\":\"c\"}\n{\"guid\":\"d\"}\n", buf.String())
+}
+
+func TestPaginatorErrors(t *testing.T) {
+	doer := newFakeDoer()
+	doer.pages["c1"] = `{"data":null,"errors":[{"message":"cursor expired"}]}`
+
+	p, err := newPaginator(doer, "actor.entitySearch.results", entitySearchQuery, "", false)
+	require.NoError(t, err)
+
+	err = p.Run(context.Background(), graphQLRequest{Query: entitySearchQuery}, func(*graphQLResponse, map[string]interface{}) error { return nil })
+	assert.EqualError(t, err, "cursor expired")
+
+	p, err = newPaginator(newFakeDoer(), "actor.entitySearch.missing", entitySearchQuery, "", false)
+	require.NoError(t, err)
+
+	err = p.Run(context.Background(), graphQLRequest{Query: entitySearchQuery}, func(*graphQLResponse, map[string]interface{}) error { return nil })
+	assert.EqualError(t, err, "the response does not contain an object at actor.entitySearch.missing")
+
+	doer = newFakeDoer()
+	doer.pages["c1"] = doer.pages[""]
+
+	p, err = newPaginator(doer, "actor.entitySearch.results", entitySearchQuery, "", false)
+	require.NoError(t, err)
+
+	err = p.Run(context.Background(), graphQLRequest{Query: entitySearchQuery}, func(*graphQLResponse, map[string]interface{}) error { return nil })
+	assert.EqualError(t, err, "NerdGraph returned the cursor c1 more than once")
+}
@@ -0,0 +1,475 @@
+package nerdgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-cli/internal/config"
+)
+
+// introspectionQuery fetches the complete schema, including deprecated fields
+// and enum values.
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) { name description args { ...InputValue } type { ...TypeRef } isDeprecated deprecationReason }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue { name description type { ...TypeRef } defaultValue }
+
+fragment TypeRef on __Type { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } } }`
+
+// schemaCache is the store of schemas in the configuration directory.
+var schemaCache = newSchemaStore(config.DefaultConfigDirectory)
+
+// schema is the result of an introspection query.
+type schema struct {
+	QueryType        *schemaTypeName `json:"queryType"`
+	MutationType     *schemaTypeName `json:"mutationType"`
+	SubscriptionType *schemaTypeName `json:"subscriptionType"`
+	Types            []*schemaType   `json:"types"`
+
+	index map[string]*schemaType
+}
+
+type schemaTypeName struct {
+	Name string `json:"name"`
+}
+
+// schemaType is a named type of the schema.
+type schemaType struct {
+	Kind          string             `json:"kind" yaml:"kind"`
+	Name          string             `json:"name" yaml:"name"`
+	Description   string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Fields        []schemaField      `json:"fields,omitempty" yaml:"fields,omitempty"`
+	InputFields   []schemaInputValue `json:"inputFields,omitempty" yaml:"inputFields,omitempty"`
+	Interfaces    []schemaTypeRef    `json:"interfaces,omitempty" yaml:"interfaces,omitempty"`
+	EnumValues    []schemaEnumValue  `json:"enumValues,omitempty" yaml:"enumValues,omitempty"`
+	PossibleTypes []schemaTypeRef    `json:"possibleTypes,omitempty" yaml:"possibleTypes,omitempty"`
+}
+
+type schemaField struct {
+	Name              string             `json:"name" yaml:"name"`
+	Description       string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Args              []schemaInputValue `json:"args,omitempty" yaml:"args,omitempty"`
+	Type              schemaTypeRef      `json:"type" yaml:"type"`
+	IsDeprecated      bool               `json:"isDeprecated,omitempty" yaml:"isDeprecated,omitempty"`
+	DeprecationReason string             `json:"deprecationReason,omitempty" yaml:"deprecationReason,omitempty"`
+}
+
+type schemaInputValue struct {
+	Name         string        `json:"name" yaml:"name"`
+	Description  string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Type         schemaTypeRef `json:"type" yaml:"type"`
+	DefaultValue *string       `json:"defaultValue,omitempty" yaml:"defaultValue,omitempty"`
+}
+
+type schemaEnumValue struct {
+	Name              string `json:"name" yaml:"name"`
+	Description       string `json:"description,omitempty" yaml:"description,omitempty"`
+	IsDeprecated      bool   `json:"isDeprecated,omitempty" yaml:"isDeprecated,omitempty"`
+	DeprecationReason string `json:"deprecationReason,omitempty" yaml:"deprecationReason,omitempty"`
+}
+
+// schemaTypeRef is a reference to a type, which may be wrapped in lists and
+// non-null modifiers.
+type schemaTypeRef struct {
+	Kind   string         `json:"kind" yaml:"kind"`
+	Name   string         `json:"name,omitempty" yaml:"name,omitempty"`
+	OfType *schemaTypeRef `json:"ofType,omitempty" yaml:"ofType,omitempty"`
+}
+
+// String returns the reference in GraphQL notation, such as [String!]!.
+func (r schemaTypeRef) String() string {
+	switch {
+	case r.Kind == "NON_NULL" && r.OfType != nil:
+		return r.OfType.String() + "!"
+	case r.Kind == "LIST" && r.OfType != nil:
+		return "[" + r.OfType.String() + "]"
+	}
+
+	return r.Name
+}
+
+// NamedType returns the name of the type with its modifiers removed.
+func (r schemaTypeRef) NamedType() string {
+	if r.OfType != nil {
+		return r.OfType.NamedType()
+	}
+
+	return r.Name
+}
+
+// Type returns the named type.
+func (s *schema) Type(name string) (*schemaType, bool) {
+	if s.index == nil {
+		s.index = make(map[string]*schemaType, len(s.Types))
+		for _, t := range s.Types {
+			s.index[t.Name] = t
+		}
+	}
+
+	t, ok := s.index[name]
+
+	return t, ok
+}
+
+// FindType returns the named type, case-insensitively if there is no exact
+// match.
+func (s *schema) FindType(name string) (*schemaType, bool) {
+	if t, ok := s.Type(name); ok {
+		return t, true
+	}
+
+	for _, t := range s.Types {
+		if strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// Field returns the named field of a type.
+func (t *schemaType) Field(name string) (*schemaField, bool) {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// cachedSchema is a schema stored locally along with when it was fetched.
+type cachedSchema struct {
+	Region    string    `json:"region"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Schema    *schema   `json:"schema"`
+}
+
+type schemaStore struct {
+	dir string
+}
+
+func newSchemaStore(dir string) *schemaStore {
+	return &schemaStore{dir: dir}
+}
+
+func (s *schemaStore) path(region string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("nerdgraph_schema_%s.json", strings.ToLower(region)))
+}
+
+// Load returns the schema cached for the region.
+func (s *schemaStore) Load(region string) (*cachedSchema, error) {
+	content, err := ioutil.ReadFile(s.path(region))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no NerdGraph schema has been fetched for the %s region, run newrelic nerdgraph schema fetch", region)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var cached cachedSchema
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return nil, fmt.Errorf("unable to read the cached schema %s: %s", s.path(region), err)
+	}
+
+	if cached.Schema == nil {
+		return nil, fmt.Errorf("the cached schema %s is empty, run newrelic nerdgraph schema fetch", s.path(region))
+	}
+
+	return &cached, nil
+}
+
+// Regions returns the regions a schema has been cached for.
+func (s *schemaStore) Regions() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "nerdgraph_schema_*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var regions []string
+
+	for _, p := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(p), "nerdgraph_schema_"), ".json")
+		regions = append(regions, strings.ToUpper(name))
+	}
+
+	sort.Strings(regions)
+
+	return regions, nil
+}
+
+// Save caches the schema for the region, returning the path it was saved to.
+func (s *schemaStore) Save(cached *cachedSchema) (string, error) {
+	content, err := json.Marshal(cached)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	path := s.path(cached.Region)
+
+	return path, ioutil.WriteFile(path, content, 0600)
+}
+
+// fetchSchema runs the introspection query.
+func fetchSchema(ctx context.Context, doer graphQLDoer) (*schema, error) {
+	result, err := doer.Do(ctx, graphQLRequest{Query: introspectionQuery})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkResponse(result, true); err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Data struct {
+			Schema *schema `json:"__schema"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(result.Raw, &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse the NerdGraph schema: %s", err)
+	}
+
+	if doc.Data.Schema == nil || len(doc.Data.Schema.Types) == 0 {
+		return nil, errors.New("NerdGraph did not return a schema")
+	}
+
+	return doc.Data.Schema, nil
+}
+
+// schemaSearchResult is a type, field, argument or enum value matching a
+// search term.
+type schemaSearchResult struct {
+	Kind        string `json:"kind" yaml:"kind"`
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type,omitempty" yaml:"type,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	score int
+}
+
+// searchSchema returns the types, fields, arguments and enum values whose
+// name or description contains the term, case-insensitively.  Matches on
+// names are listed before matches on descriptions.  Introspection types
+// are not searched.
+func searchSchema(s *schema, term string, limit int) []schemaSearchResult {
+	term = strings.ToLower(term)
+	results := []schemaSearchResult{}
+
+	add := func(r schemaSearchResult, name string) {
+		switch {
+		case strings.EqualFold(name, term):
+			r.score = 3
+		case strings.Contains(strings.ToLower(name), term):
+			r.score = 2
+		case strings.Contains(strings.ToLower(r.Description), term):
+			r.score = 1
+		default:
+			return
+		}
+
+		r.Description = firstLine(r.Description)
+		results = append(results, r)
+	}
+
+	for _, t := range s.Types {
+		if strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+
+		add(schemaSearchResult{Kind: strings.ToLower(t.Kind), Name: t.Name, Description: t.Description}, t.Name)
+
+		for _, f := range t.Fields {
+			add(schemaSearchResult{
+				Kind:        "field",
+				Name:        t.Name + "." + f.Name,
+				Type:        f.Type.String(),
+				Deprecated:  f.IsDeprecated,
+				Description: f.Description,
+			}, f.Name)
+
+			for _, a := range f.Args {
+				add(schemaSearchResult{
+					Kind:        "argument",
+					Name:        t.Name + "." + f.Name + "(" + a.Name + ")",
+					Type:        a.Type.String(),
+					Description: a.Description,
+				}, a.Name)
+			}
+		}
+
+		for _, f := range t.InputFields {
+			add(schemaSearchResult{
+				Kind:        "input field",
+				Name:        t.Name + "." + f.Name,
+				Type:        f.Type.String(),
+				Description: f.Description,
+			}, f.Name)
+		}
+
+		for _, v := range t.EnumValues {
+			add(schemaSearchResult{
+				Kind:        "enum value",
+				Name:        t.Name + "." + v.Name,
+				Deprecated:  v.IsDeprecated,
+				Description: v.Description,
+			}, v.Name)
+		}
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		if results[a].score != results[b].score {
+			return results[a].score > results[b].score
+		}
+
+		return results[a].Name < results[b].Name
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// renderSDL renders a type in the GraphQL schema definition language, with
+// descriptions as comments.
+func renderSDL(t *schemaType) string {
+	var b strings.Builder
+
+	writeDescription(&b, "", t.Description)
+
+	switch t.Kind {
+	case "SCALAR":
+		fmt.Fprintf(&b, "scalar %s\n", t.Name)
+	case "UNION":
+		names := make([]string, len(t.PossibleTypes))
+		for i, p := range t.PossibleTypes {
+			names[i] = p.Name
+		}
+
+		fmt.Fprintf(&b, "union %s = %s\n", t.Name, strings.Join(names, " | "))
+	case "ENUM":
+		fmt.Fprintf(&b, "enum %s {\n", t.Name)
+		for _, v := range t.EnumValues {
+			writeDescription(&b, "  ", v.Description)
+			fmt.Fprintf(&b, "  %s%s\n", v.Name, deprecation(v.IsDeprecated, v.DeprecationReason))
+		}
+		b.WriteString("}\n")
+	case "INPUT_OBJECT":
+		fmt.Fprintf(&b, "input %s {\n", t.Name)
+		for _, f := range t.InputFields {
+			writeDescription(&b, "  ", f.Description)
+			fmt.Fprintf(&b, "  %s\n", inputValueSDL(f))
+		}
+		b.WriteString("}\n")
+	default:
+		keyword := "type"
+		if t.Kind == "INTERFACE" {
+			keyword = "interface"
+		}
+
+		fmt.Fprintf(&b, "%s %s", keyword, t.Name)
+
+		if len(t.Interfaces) > 0 {
+			names := make([]string, len(t.Interfaces))
+			for i, iface := range t.Interfaces {
+				names[i] = iface.Name
+			}
+
+			fmt.Fprintf(&b, " implements %s", strings.Join(names, " & "))
+		}
+
+		b.WriteString(" {\n")
+		for _, f := range t.Fields {
+			writeDescription(&b, "  ", f.Description)
+
+			args := ""
+			if len(f.Args) > 0 {
+				values := make([]string, len(f.Args))
+				for i, a := range f.Args {
+					values[i] = inputValueSDL(a)
+				}
+
+				args = "(" + strings.Join(values, ", ") + ")"
+			}
+
+			fmt.Fprintf(&b, "  %s%s: %s%s\n", f.Name, args, f.Type, deprecation(f.IsDeprecated, f.DeprecationReason))
+		}
+		b.WriteString("}\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func inputValueSDL(v schemaInputValue) string {
+	if v.DefaultValue != nil {
+		return fmt.Sprintf("%s: %s = %s", v.Name, v.Type, *v.DefaultValue)
+	}
+
+	return fmt.Sprintf("%s: %s", v.Name, v.Type)
+}
+
+func deprecation(deprecated bool, reason string) string {
+	if !deprecated {
+		return ""
+	}
+
+	if reason == "" {
+		return " @deprecated"
+	}
+
+	return fmt.Sprintf(" @deprecated(reason: %q)", reason)
+}
+
+func writeDescription(b *strings.Builder, indent string, description string) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return
+	}
+
+	for _, line := range strings.Split(description, "\n") {
+		fmt.Fprintln(b, strings.TrimRight(indent+"# "+line, " \t"))
+	}
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+
+	return s
+}
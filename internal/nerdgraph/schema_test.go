@@ -0,0 +1,246 @@
+// +build unit
+
+package nerdgraph
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSchemaJSON is a small subset of the NerdGraph schema.
+const testSchemaJSON = `{
+  "queryType": {"name": "Query"},
+  "mutationType": {"name": "Mutation"},
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "fields": [
+      {"name": "actor", "description": "The actor is the user making the request.", "type": {"kind": "OBJECT", "name": "Actor"}}
+    ]},
+    {"kind": "OBJECT", "name": "Mutation", "fields": [
+      {"name": "taggingAddTagsToEntity", "description": "Add tags to an entity.", "args": [
+        {"name": "guid", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "EntityGuid"}}},
+        {"name": "tags", "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "TaggingTagInput"}}}}}
+      ], "type": {"kind": "OBJECT", "name": "TaggingMutationResult"}}
+    ]},
+    {"kind": "OBJECT", "name": "Actor", "description": "The Actor object.", "fields": [
+      {"name": "entity", "description": "Fetch a single entity.\n\nOnly entities the user can see are returned.", "args": [
+        {"name": "guid", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "EntityGuid"}}}
+      ], "type": {"kind": "INTERFACE", "name": "Entity"}},
+      {"name": "entitySearch", "description": "Search for entities.", "args": [
+        {"name": "query", "type": {"kind": "SCALAR", "name": "String"}},
+        {"name": "sortBy", "type": {"kind": "LIST", "ofType": {"kind": "ENUM", "name": "EntitySearchSortCriteria"}}, "defaultValue": "[NAME]"}
+      ], "type": {"kind": "OBJECT", "name": "EntitySearch"}},
+      {"name": "entityTags", "description": "Tags of an entity.", "type": {"kind": "SCALAR", "name": "String"}, "isDeprecated": true, "deprecationReason": "Use entity.tags"}
+    ]},
+    {"kind": "ENUM", "name": "EntitySearchSortCriteria", "enumValues": [
+      {"name": "NAME", "description": "Sort by name."},
+      {"name": "REPORTING", "isDeprecated": true}
+    ]},
+    {"kind": "INPUT_OBJECT", "name": "TaggingTagInput", "description": "A tag.", "inputFields": [
+      {"name": "key", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+      {"name": "values", "type": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}}
+    ]},
+    {"kind": "INTERFACE", "name": "Entity", "fields": [
+      {"name": "guid", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "EntityGuid"}}},
+      {"name": "name", "type": {"kind": "SCALAR", "name": "String"}}
+    ], "possibleTypes": [{"kind": "OBJECT", "name": "ApmApplicationEntity"}]},
+    {"kind": "OBJECT", "name": "ApmApplicationEntity", "fields": [
+      {"name": "guid", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "EntityGuid"}}},
+      {"name": "name", "type": {"kind": "SCALAR", "name": "String"}},
+      {"name": "language", "type": {"kind": "SCALAR", "name": "String"}}
+    ], "interfaces": [{"kind": "INTERFACE", "name": "Entity"}]},
+    {"kind": "OBJECT", "name": "EntitySearch", "fields": [
+      {"name": "count", "type": {"kind": "SCALAR", "name": "Int"}},
+      {"name": "results", "args": [{"name": "cursor", "type": {"kind": "SCALAR", "name": "String"}}], "type": {"kind": "OBJECT", "name": "EntitySearchResult"}}
+    ]},
+    {"kind": "OBJECT", "name": "EntitySearchResult", "fields": [
+      {"name": "nextCursor", "type": {"kind": "SCALAR", "name": "String"}},
+      {"name": "entities", "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "INTERFACE", "name": "Entity"}}}}}
+    ]},
+    {"kind": "OBJECT", "name": "TaggingMutationResult", "fields": [
+      {"name": "errors", "type": {"kind": "LIST", "ofType": {"kind": "SCALAR", "name": "String"}}}
+    ]},
+    {"kind": "UNION", "name": "SearchResult", "possibleTypes": [{"kind": "OBJECT", "name": "Actor"}, {"kind": "OBJECT", "name": "EntitySearch"}]},
+    {"kind": "SCALAR", "name": "EntityGuid", "description": "An entity GUID."},
+    {"kind": "SCALAR", "name": "String"},
+    {"kind": "SCALAR", "name": "Int"},
+    {"kind": "SCALAR", "name": "Float"},
+    {"kind": "SCALAR", "name": "Boolean"},
+    {"kind": "SCALAR", "name": "ID"},
+    {"kind": "OBJECT", "name": "__Type", "fields": [{"name": "name", "type": {"kind": "SCALAR", "name": "String"}}]}
+  ]
+}`
+
+func loadTestSchema(t *testing.T) *schema {
+	var s schema
+	require.NoError(t, json.Unmarshal([]byte(testSchemaJSON), &s))
+
+	return &s
+}
+
+func TestSchemaTypeRef(t *testing.T) {
+	s := loadTestSchema(t)
+
+	mutation, ok := s.Type("Mutation")
+	require.True(t, ok)
+
+	f, ok := mutation.Field("taggingAddTagsToEntity")
+	require.True(t, ok)
+
+	assert.Equal(t, "EntityGuid!", f.Args[0].Type.String())
+	assert.Equal(t, "[TaggingTagInput!]!", f.Args[1].Type.String())
+	assert.Equal(t, "TaggingTagInput", f.Args[1].Type.NamedType())
+
+	_, ok = s.Type("actor")
+	assert.False(t, ok)
+
+	_, ok = s.FindType("actor")
+	assert.True(t, ok)
+
+	_, ok = s.Type("Missing")
+	assert.False(t, ok)
+}
+
+func TestSearchSchema(t *testing.T) {
+	s := loadTestSchema(t)
+
+	results := searchSchema(s, "entity", 0)
+	require.NotEmpty(t, results)
+
+	// Exact name matches come first, then names containing the term
+	assert.Equal(t, "Actor.entity", results[0].Name)
+	assert.Equal(t, "field", results[0].Kind)
+	assert.Equal(t, "Fetch a single entity.", results[0].Description)
+
+	names := map[string]schemaSearchResult{}
+	for _, r := range results {
+		names[r.Name] = r
+	}
+
+	assert.Contains(t, names, "Entity")
+	assert.Contains(t, names, "Mutation.taggingAddTagsToEntity")
+	assert.Contains(t, names, "EntityGuid")
+	assert.True(t, names["Actor.entityTags"].Deprecated)
+	assert.NotContains(t, names, "__Type")
+
+	assert.Len(t, searchSchema(s, "entity", 2), 2)
+
+	// Descriptions are searched too
+	described := searchSchema(s, "user making", 0)
+	require.Len(t, described, 1)
+	assert.Equal(t, "Query.actor", described[0].Name)
+}
+
+func TestRenderSDL(t *testing.T) {
+	s := loadTestSchema(t)
+
+	actor, _ := s.Type("Actor")
+	assert.Equal(t, `# The Actor object.
+type Actor {
+  # Fetch a single entity.
+  #
+  # Only entities the user can see are returned.
+  entity(guid: EntityGuid!): Entity
+  # Search for entities.
+  entitySearch(query: String, sortBy: [EntitySearchSortCriteria] = [NAME]): EntitySearch
+  # Tags of an entity.
+  entityTags: String @deprecated(reason: "Use entity.tags")
+}`, renderSDL(actor))
+
+	enum, _ := s.Type("EntitySearchSortCriteria")
+	assert.Equal(t, `enum EntitySearchSortCriteria {
+  # Sort by name.
+  NAME
+  REPORTING @deprecated
+}`, renderSDL(enum))
+
+	input, _ := s.Type("TaggingTagInput")
+	assert.Equal(t, `# A tag.
+input TaggingTagInput {
+  key: String!
+  values: [String!]
+}`, renderSDL(input))
+
+	app, _ := s.Type("ApmApplicationEntity")
+	assert.Contains(t, renderSDL(app), "type ApmApplicationEntity implements Entity {")
+
+	union, _ := s.Type("SearchResult")
+	assert.Equal(t, "union SearchResult = Actor | EntitySearch", renderSDL(union))
+}
+
+func TestSchemaStore(t *testing.T) {
+	store := newSchemaStore(t.TempDir())
+
+	_, err := store.Load("US")
+	assert.Error(t, err)
+
+	path, err := store.Save(&cachedSchema{Region: "US", FetchedAt: time.Now(), Schema: loadTestSchema(t)})
+	require.NoError(t, err)
+	assert.Contains(t, path, "nerdgraph_schema_us.json")
+
+	cached, err := store.Load("US")
+	require.NoError(t, err)
+	assert.Len(t, cached.Schema.Types, len(loadTestSchema(t).Types))
+
+	_, err = store.Load("EU")
+	assert.Error(t, err)
+}
+
+func TestCachedSchemaRegion(t *testing.T) {
+	store := newSchemaStore(t.TempDir())
+
+	name, err := cachedSchemaRegion(store, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "US", name)
+
+	_, err = store.Save(&cachedSchema{Region: "EU", FetchedAt: time.Now(), Schema: loadTestSchema(t)})
+	require.NoError(t, err)
+
+	// The only region cached is used without a region configured
+	name, err = cachedSchemaRegion(store, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "EU", name)
+
+	name, err = cachedSchemaRegion(store, "", "us")
+	require.NoError(t, err)
+	assert.Equal(t, "US", name)
+
+	name, err = cachedSchemaRegion(store, "eu", "US")
+	require.NoError(t, err)
+	assert.Equal(t, "EU", name)
+
+	_, err = cachedSchemaRegion(store, "mars", "")
+	assert.Error(t, err)
+
+	_, err = store.Save(&cachedSchema{Region: "US", FetchedAt: time.Now(), Schema: loadTestSchema(t)})
+	require.NoError(t, err)
+
+	regions, err := store.Regions()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"EU", "US"}, regions)
+
+	name, err = cachedSchemaRegion(store, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "US", name)
+}
+
+type staticDoer struct {
+	body string
+}
+
+func (d staticDoer) Do(ctx context.Context, req graphQLRequest) (*graphQLResponse, error) {
+	return parseGraphQLResponse([]byte(d.body))
+}
+
+func TestFetchSchema(t *testing.T) {
+	s, err := fetchSchema(context.Background(), staticDoer{body: `{"data":{"__schema":` + testSchemaJSON + `}}`})
+	require.NoError(t, err)
+	assert.Equal(t, "Query", s.QueryType.Name)
+
+	_, err = fetchSchema(context.Background(), staticDoer{body: `{"data":null,"errors":[{"message":"introspection disabled"}]}`})
+	assert.EqualError(t, err, "introspection disabled")
+}
@@ -0,0 +1,880 @@
+package nerdgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	validationSeverityError   = "error"
+	validationSeverityWarning = "warning"
+
+	validationRuleSyntax           = "syntax"
+	validationRuleUnknownType      = "unknown-type"
+	validationRuleUnknownField     = "unknown-field"
+	validationRuleUnknownArgument  = "unknown-argument"
+	validationRuleUnknownFragment  = "unknown-fragment"
+	validationRuleMissingArgument  = "missing-argument"
+	validationRuleArgumentType     = "argument-type"
+	validationRuleSelection        = "selection"
+	validationRuleUndefinedVar     = "undefined-variable"
+	validationRuleUnusedVar        = "unused-variable"
+	validationRuleMissingVar       = "missing-variable"
+	validationRuleVariableType     = "variable-type"
+	validationRuleDeprecated       = "deprecated"
+	validationRuleOperation        = "operation"
+	validationRuleUnusedFragment   = "unused-fragment"
+	validationRuleDuplicateVarName = "duplicate-variable"
+)
+
+// validationIssue is a problem found in a GraphQL document.  Lines and
+// columns start at 1.
+type validationIssue struct {
+	Source   string `json:"source,omitempty" yaml:"source,omitempty"`
+	Line     int    `json:"line" yaml:"line"`
+	Column   int    `json:"column" yaml:"column"`
+	Severity string `json:"severity" yaml:"severity"`
+	Rule     string `json:"rule" yaml:"rule"`
+	Message  string `json:"message" yaml:"message"`
+
+	pos int
+}
+
+func (i validationIssue) String() string {
+	location := fmt.Sprintf("%d:%d", i.Line, i.Column)
+	if i.Source != "" {
+		location = i.Source + ":" + location
+	}
+
+	return fmt.Sprintf("%s: %s: %s [%s]", location, i.Severity, i.Message, i.Rule)
+}
+
+// variableUsage is a variable passed where a value of the expected type is
+// required.  Usages within directives other than @include and @skip have no
+// known expected type.
+type variableUsage struct {
+	name               string
+	expected           *schemaTypeRef
+	locationHasDefault bool
+	pos                int
+}
+
+// validator checks GraphQL documents against a schema without contacting
+// NerdGraph.
+type validator struct {
+	schema *schema
+
+	issues []validationIssue
+}
+
+func newValidator(s *schema) *validator {
+	return &validator{schema: s}
+}
+
+// Validate checks a document.  When variables is not nil, the variables of the
+// selected operation, or of every operation when operationName is empty, are
+// checked for missing required values and values of the wrong type.
+func (v *validator) Validate(source string, document string, operationName string, variables map[string]interface{}) []validationIssue {
+	v.issues = nil
+
+	doc, err := parseGraphQL(document)
+	if err != nil {
+		pos := 0
+		if syntaxErr, ok := err.(*gqlSyntaxError); ok {
+			pos = syntaxErr.Pos
+		}
+
+		v.report(pos, validationSeverityError, validationRuleSyntax, err.Error())
+	} else {
+		v.validateDocument(doc, operationName, variables)
+	}
+
+	issues := v.issues
+	for i := range issues {
+		issues[i].Source = source
+		issues[i].Line, issues[i].Column = gqlLineAndColumn(document, issues[i].pos)
+	}
+
+	sort.SliceStable(issues, func(a, b int) bool {
+		return issues[a].pos < issues[b].pos
+	})
+
+	return issues
+}
+
+func (v *validator) report(pos int, severity string, rule string, format string, args ...interface{}) {
+	v.issues = append(v.issues, validationIssue{
+		Severity: severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+		pos:      pos,
+	})
+}
+
+func (v *validator) validateDocument(doc *gqlDocument, operationName string, variables map[string]interface{}) {
+	fragments := map[string]*gqlFragment{}
+	for _, f := range doc.Fragments {
+		if _, ok := fragments[f.Name]; ok {
+			v.report(f.Pos, validationSeverityError, validationRuleOperation, "there can be only one fragment named %s", f.Name)
+			continue
+		}
+
+		fragments[f.Name] = f
+	}
+
+	names := map[string]bool{}
+	selected := false
+
+	for _, op := range doc.Operations {
+		if op.Name == "" && len(doc.Operations) > 1 {
+			v.report(op.Pos, validationSeverityError, validationRuleOperation, "an anonymous operation must be the only operation in the document")
+		}
+
+		if op.Name != "" && names[op.Name] {
+			v.report(op.Pos, validationSeverityError, validationRuleOperation, "there can be only one operation named %s", op.Name)
+		}
+
+		names[op.Name] = true
+
+		checkVariables := variables != nil && (operationName == "" || op.Name == operationName)
+		if op.Name == operationName {
+			selected = true
+		}
+
+		v.validateOperation(op, fragments, variables, checkVariables)
+	}
+
+	if operationName != "" && !selected {
+		v.report(0, validationSeverityError, validationRuleOperation, "the document has no operation named %s", operationName)
+	}
+
+	used := map[string]bool{}
+	for _, f := range doc.Fragments {
+		v.validateFragment(f, fragments)
+	}
+
+	for _, op := range doc.Operations {
+		collectFragmentSpreads(op.SelectionSet, fragments, used)
+	}
+
+	for _, f := range doc.Fragments {
+		if !used[f.Name] {
+			v.report(f.Pos, validationSeverityWarning, validationRuleUnusedFragment, "fragment %s is never used", f.Name)
+		}
+	}
+}
+
+func (v *validator) validateFragment(f *gqlFragment, fragments map[string]*gqlFragment) {
+	t, ok := v.schema.Type(f.TypeCondition)
+	if !ok {
+		v.report(f.TypePos, validationSeverityError, validationRuleUnknownType, "unknown type %s", f.TypeCondition)
+		return
+	}
+
+	if !isCompositeKind(t.Kind) {
+		v.report(f.TypePos, validationSeverityError, validationRuleSelection, "fragment %s cannot be on %s type %s", f.Name, strings.ToLower(t.Kind), t.Name)
+		return
+	}
+
+	v.walkSelections(t, f.SelectionSet, fragments, true, nil, map[string]bool{})
+}
+
+func (v *validator) validateOperation(op *gqlOperation, fragments map[string]*gqlFragment, variables map[string]interface{}, checkVariables bool) {
+	root := v.rootType(op.Type)
+	if root == "" {
+		v.report(op.Pos, validationSeverityError, validationRuleOperation, "the schema does not support %s operations", op.Type)
+		return
+	}
+
+	rootType, ok := v.schema.Type(root)
+	if !ok {
+		v.report(op.Pos, validationSeverityError, validationRuleUnknownType, "unknown type %s", root)
+		return
+	}
+
+	defined := map[string]*gqlVariableDefinition{}
+	for _, def := range op.Variables {
+		if _, ok := defined[def.Name]; ok {
+			v.report(def.Pos, validationSeverityError, validationRuleDuplicateVarName, "there can be only one variable named $%s", def.Name)
+			continue
+		}
+
+		defined[def.Name] = def
+		v.validateVariableDefinition(def)
+	}
+
+	var usages []variableUsage
+	v.walkDirectives(op.Directives, &usages)
+	v.walkSelections(rootType, op.SelectionSet, fragments, true, &usages, map[string]bool{})
+
+	used := map[string]bool{}
+	for _, u := range usages {
+		used[u.name] = true
+
+		def, ok := defined[u.name]
+		if !ok {
+			v.report(u.pos, validationSeverityError, validationRuleUndefinedVar, "variable $%s is not defined by %s", u.name, operationLabel(op))
+			continue
+		}
+
+		if u.expected == nil {
+			continue
+		}
+
+		hasDefault := def.Default != nil && def.Default.Kind != gqlValueNull
+		if !variableTypeAllowed(def.Type, *u.expected, hasDefault, u.locationHasDefault) {
+			v.report(u.pos, validationSeverityError, validationRuleArgumentType, "variable $%s of type %s cannot be used where %s is expected", u.name, def.Type, u.expected)
+		}
+	}
+
+	for _, def := range op.Variables {
+		if !used[def.Name] {
+			v.report(def.Pos, validationSeverityWarning, validationRuleUnusedVar, "variable $%s is never used in %s", def.Name, operationLabel(op))
+		}
+	}
+
+	if !checkVariables {
+		return
+	}
+
+	for _, def := range op.Variables {
+		value, provided := variables[def.Name]
+
+		if !provided || value == nil {
+			if def.Type.NonNull && def.Default == nil {
+				v.report(def.Pos, validationSeverityError, validationRuleMissingVar, "required variable $%s of type %s has no value", def.Name, def.Type)
+			}
+
+			continue
+		}
+
+		if err := v.checkVariableValue(value, def.Type, "$"+def.Name); err != nil {
+			v.report(def.Pos, validationSeverityError, validationRuleVariableType, "%s", err)
+		}
+	}
+}
+
+func (v *validator) rootType(operationType string) string {
+	var name *schemaTypeName
+
+	switch operationType {
+	case "query":
+		name = v.schema.QueryType
+	case "mutation":
+		name = v.schema.MutationType
+	case "subscription":
+		name = v.schema.SubscriptionType
+	}
+
+	if name == nil {
+		return ""
+	}
+
+	return name.Name
+}
+
+func (v *validator) validateVariableDefinition(def *gqlVariableDefinition) {
+	named := def.Type
+	for named.Elem != nil {
+		named = named.Elem
+	}
+
+	t, ok := v.schema.Type(named.Name)
+	if !ok {
+		v.report(named.Pos, validationSeverityError, validationRuleUnknownType, "unknown type %s", named.Name)
+		return
+	}
+
+	if t.Kind != "SCALAR" && t.Kind != "ENUM" && t.Kind != "INPUT_OBJECT" {
+		v.report(named.Pos, validationSeverityError, validationRuleVariableType, "variable $%s cannot be of %s type %s", def.Name, strings.ToLower(t.Kind), t.Name)
+		return
+	}
+
+	if def.Default != nil {
+		v.checkValue(def.Default, gqlTypeToRef(def.Type), false, "the default value of $"+def.Name, nil)
+	}
+}
+
+// walkSelections validates a selection set against its parent type.  Fragment
+// spreads are followed only to collect variable usages, as fragments are
+// validated against their own type condition.
+func (v *validator) walkSelections(parent *schemaType, selections []*gqlSelection, fragments map[string]*gqlFragment, report bool, usages *[]variableUsage, visited map[string]bool) {
+	for _, s := range selections {
+		switch s.Kind {
+		case gqlSelectionFragmentSpread:
+			v.walkDirectives(s.Directives, usages)
+
+			f, ok := fragments[s.Name]
+			if !ok {
+				if report {
+					v.report(s.Pos, validationSeverityError, validationRuleUnknownFragment, "unknown fragment %s", s.Name)
+				}
+				continue
+			}
+
+			if usages != nil && !visited[s.Name] {
+				visited[s.Name] = true
+
+				if t, ok := v.schema.Type(f.TypeCondition); ok {
+					v.walkSelections(t, f.SelectionSet, fragments, false, usages, visited)
+				}
+			}
+		case gqlSelectionInlineFragment:
+			v.walkDirectives(s.Directives, usages)
+
+			t := parent
+			if s.TypeCondition != "" {
+				var ok bool
+				if t, ok = v.schema.Type(s.TypeCondition); !ok {
+					if report {
+						v.report(s.Pos, validationSeverityError, validationRuleUnknownType, "unknown type %s", s.TypeCondition)
+					}
+					continue
+				}
+			}
+
+			v.walkSelections(t, s.SelectionSet, fragments, report, usages, visited)
+		default:
+			v.walkField(parent, s, fragments, report, usages, visited)
+		}
+	}
+}
+
+func (v *validator) walkField(parent *schemaType, s *gqlSelection, fragments map[string]*gqlFragment, report bool, usages *[]variableUsage, visited map[string]bool) {
+	v.walkDirectives(s.Directives, usages)
+
+	// Meta fields are available on every type, and the introspection fields on
+	// the query type
+	if s.Name == "__typename" || strings.HasPrefix(s.Name, "__") && v.schema.QueryType != nil && parent.Name == v.schema.QueryType.Name {
+		return
+	}
+
+	field, ok := parent.Field(s.Name)
+	if !ok {
+		if report {
+			if parent.Kind == "UNION" {
+				v.report(s.Pos, validationSeverityError, validationRuleUnknownField, "cannot query field %s on union %s, use an inline fragment on one of its types", s.Name, parent.Name)
+			} else {
+				v.report(s.Pos, validationSeverityError, validationRuleUnknownField, "unknown field %s on type %s", s.Name, parent.Name)
+			}
+		}
+
+		// The arguments may still use variables
+		for _, a := range s.Arguments {
+			v.checkValue(a.Value, schemaTypeRef{}, false, "", usages)
+		}
+
+		return
+	}
+
+	if report && field.IsDeprecated {
+		v.report(s.Pos, validationSeverityWarning, validationRuleDeprecated, "field %s.%s is deprecated%s", parent.Name, field.Name, deprecationSuffix(field.DeprecationReason))
+	}
+
+	v.walkArguments(parent.Name+"."+field.Name, field.Args, s.Arguments, s.Pos, report, usages)
+
+	named, ok := v.schema.Type(field.Type.NamedType())
+	if !ok {
+		return
+	}
+
+	if !isCompositeKind(named.Kind) {
+		if len(s.SelectionSet) > 0 && report {
+			v.report(s.Pos, validationSeverityError, validationRuleSelection, "field %s of type %s cannot have a selection of subfields", s.Name, field.Type)
+		}
+
+		return
+	}
+
+	if len(s.SelectionSet) == 0 {
+		if report {
+			v.report(s.Pos, validationSeverityError, validationRuleSelection, "field %s of type %s must have a selection of subfields", s.Name, field.Type)
+		}
+
+		return
+	}
+
+	v.walkSelections(named, s.SelectionSet, fragments, report, usages, visited)
+}
+
+// walkArguments checks the arguments given to a field against its definition.
+// Issues are only reported when report is set, while variable usages are
+// always collected.
+func (v *validator) walkArguments(fieldName string, defs []schemaInputValue, args []*gqlArgument, fieldPos int, report bool, usages *[]variableUsage) {
+	given := map[string]bool{}
+
+	saved := v.issues
+	for _, a := range args {
+		given[a.Name] = true
+
+		def := findInputValue(defs, a.Name)
+		if def == nil {
+			v.report(a.Pos, validationSeverityError, validationRuleUnknownArgument, "unknown argument %s on field %s", a.Name, fieldName)
+			v.checkValue(a.Value, schemaTypeRef{}, false, "", usages)
+			continue
+		}
+
+		v.checkValue(a.Value, def.Type, def.DefaultValue != nil, fmt.Sprintf("argument %s of %s", a.Name, fieldName), usages)
+	}
+
+	for _, def := range defs {
+		if def.Type.Kind == "NON_NULL" && def.DefaultValue == nil && !given[def.Name] {
+			v.report(fieldPos, validationSeverityError, validationRuleMissingArgument, "field %s is missing the required argument %s of type %s", fieldName, def.Name, def.Type)
+		}
+	}
+
+	if !report {
+		v.issues = saved
+	}
+}
+
+// walkDirectives collects the variables used by directives.  The arguments of
+// @include and @skip must be booleans.
+func (v *validator) walkDirectives(directives []*gqlDirective, usages *[]variableUsage) {
+	boolean := schemaTypeRef{Kind: "NON_NULL", OfType: &schemaTypeRef{Kind: "SCALAR", Name: "Boolean"}}
+
+	for _, d := range directives {
+		for _, a := range d.Arguments {
+			if (d.Name == "include" || d.Name == "skip") && a.Name == "if" {
+				v.checkValue(a.Value, boolean, false, fmt.Sprintf("argument if of @%s", d.Name), usages)
+				continue
+			}
+
+			v.checkValue(a.Value, schemaTypeRef{}, false, "", usages)
+		}
+	}
+}
+
+// checkValue checks a value against the expected type, recording variables
+// as usages to be checked against their definitions.  An expected type
+// without a kind accepts any value.
+func (v *validator) checkValue(value *gqlValue, expected schemaTypeRef, locationHasDefault bool, context string, usages *[]variableUsage) {
+	if value.Kind == gqlValueVariable {
+		if usages != nil {
+			u := variableUsage{name: value.Raw, locationHasDefault: locationHasDefault, pos: value.Pos}
+			if expected.Kind != "" {
+				e := expected
+				u.expected = &e
+			}
+
+			*usages = append(*usages, u)
+		}
+
+		return
+	}
+
+	if expected.Kind == "" {
+		for _, item := range value.List {
+			v.checkValue(item, expected, false, context, usages)
+		}
+
+		for _, f := range value.Fields {
+			v.checkValue(f.Value, expected, false, context, usages)
+		}
+
+		return
+	}
+
+	if expected.Kind == "NON_NULL" {
+		if value.Kind == gqlValueNull {
+			v.report(value.Pos, validationSeverityError, validationRuleArgumentType, "%s expects %s, found null", context, expected)
+			return
+		}
+
+		v.checkValue(value, *expected.OfType, false, context, usages)
+		return
+	}
+
+	if value.Kind == gqlValueNull {
+		return
+	}
+
+	if expected.Kind == "LIST" {
+		if value.Kind != gqlValueList {
+			// A single value is accepted where a list is expected
+			v.checkValue(value, *expected.OfType, false, context, usages)
+			return
+		}
+
+		for _, item := range value.List {
+			v.checkValue(item, *expected.OfType, false, context, usages)
+		}
+
+		return
+	}
+
+	t, ok := v.schema.Type(expected.Name)
+	if !ok {
+		return
+	}
+
+	mismatch := func() {
+		v.report(value.Pos, validationSeverityError, validationRuleArgumentType, "%s expects %s, found %s", context, expected.Name, describeValue(value))
+	}
+
+	switch t.Kind {
+	case "SCALAR":
+		if !literalMatchesScalar(value, t.Name) {
+			mismatch()
+		}
+	case "ENUM":
+		if value.Kind != gqlValueEnum {
+			mismatch()
+			return
+		}
+
+		enumValue := findEnumValue(t.EnumValues, value.Raw)
+		if enumValue == nil {
+			v.report(value.Pos, validationSeverityError, validationRuleArgumentType, "%s expects %s, which has no value %s", context, t.Name, value.Raw)
+			return
+		}
+
+		if enumValue.IsDeprecated {
+			v.report(value.Pos, validationSeverityWarning, validationRuleDeprecated, "enum value %s.%s is deprecated%s", t.Name, enumValue.Name, deprecationSuffix(enumValue.DeprecationReason))
+		}
+	case "INPUT_OBJECT":
+		if value.Kind != gqlValueObject {
+			mismatch()
+			return
+		}
+
+		given := map[string]bool{}
+		for _, f := range value.Fields {
+			given[f.Name] = true
+
+			def := findInputValue(t.InputFields, f.Name)
+			if def == nil {
+				v.report(f.Pos, validationSeverityError, validationRuleArgumentType, "unknown field %s of input type %s in %s", f.Name, t.Name, context)
+				v.checkValue(f.Value, schemaTypeRef{}, false, context, usages)
+				continue
+			}
+
+			v.checkValue(f.Value, def.Type, def.DefaultValue != nil, fmt.Sprintf("field %s of %s", f.Name, t.Name), usages)
+		}
+
+		for _, def := range t.InputFields {
+			if def.Type.Kind == "NON_NULL" && def.DefaultValue == nil && !given[def.Name] {
+				v.report(value.Pos, validationSeverityError, validationRuleArgumentType, "%s is missing the required field %s of %s", context, def.Name, t.Name)
+			}
+		}
+	}
+}
+
+// checkVariableValue checks a value given for a variable, as decoded from
+// JSON or YAML, against the variable's type.
+func (v *validator) checkVariableValue(value interface{}, t *gqlType, path string) error {
+	if value == nil {
+		if t.NonNull {
+			return fmt.Errorf("%s of type %s cannot be null", path, t)
+		}
+
+		return nil
+	}
+
+	if t.Elem != nil {
+		list, ok := value.([]interface{})
+		if !ok {
+			return v.checkVariableValue(value, t.Elem, path)
+		}
+
+		for i, item := range list {
+			if err := v.checkVariableValue(item, t.Elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	named, ok := v.schema.Type(t.Name)
+	if !ok {
+		return nil
+	}
+
+	mismatch := fmt.Errorf("%s expects %s, found %s", path, t.Name, describeJSONValue(value))
+
+	switch named.Kind {
+	case "SCALAR":
+		if !jsonMatchesScalar(value, named.Name) {
+			return mismatch
+		}
+	case "ENUM":
+		s, ok := value.(string)
+		if !ok {
+			return mismatch
+		}
+
+		if findEnumValue(named.EnumValues, s) == nil {
+			return fmt.Errorf("%s expects %s, which has no value %s", path, named.Name, s)
+		}
+	case "INPUT_OBJECT":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return mismatch
+		}
+
+		keys := make([]string, 0, len(object))
+		for k := range object {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			def := findInputValue(named.InputFields, k)
+			if def == nil {
+				return fmt.Errorf("%s has unknown field %s of input type %s", path, k, named.Name)
+			}
+
+			if err := v.checkVariableValue(object[k], refToGQLType(def.Type), path+"."+k); err != nil {
+				return err
+			}
+		}
+
+		for _, def := range named.InputFields {
+			if _, ok := object[def.Name]; !ok && def.Type.Kind == "NON_NULL" && def.DefaultValue == nil {
+				return fmt.Errorf("%s is missing the required field %s of %s", path, def.Name, named.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// variableTypeAllowed reports whether a variable of the given type can be
+// used where the expected type is required.  A nullable variable may be used
+// in a non-null position when either has a default value.
+func variableTypeAllowed(variable *gqlType, expected schemaTypeRef, variableHasDefault bool, locationHasDefault bool) bool {
+	if expected.Kind == "NON_NULL" && !variable.NonNull {
+		if !variableHasDefault && !locationHasDefault {
+			return false
+		}
+
+		return gqlTypeCompatible(variable, *expected.OfType)
+	}
+
+	return gqlTypeCompatible(variable, expected)
+}
+
+func gqlTypeCompatible(variable *gqlType, expected schemaTypeRef) bool {
+	if expected.Kind == "NON_NULL" {
+		if !variable.NonNull {
+			return false
+		}
+
+		nullable := *variable
+		nullable.NonNull = false
+
+		return gqlTypeCompatible(&nullable, *expected.OfType)
+	}
+
+	if variable.NonNull {
+		nullable := *variable
+		nullable.NonNull = false
+
+		return gqlTypeCompatible(&nullable, expected)
+	}
+
+	if expected.Kind == "LIST" {
+		return variable.Elem != nil && gqlTypeCompatible(variable.Elem, *expected.OfType)
+	}
+
+	return variable.Elem == nil && variable.Name == expected.Name
+}
+
+func gqlTypeToRef(t *gqlType) schemaTypeRef {
+	var ref schemaTypeRef
+
+	if t.Elem != nil {
+		elem := gqlTypeToRef(t.Elem)
+		ref = schemaTypeRef{Kind: "LIST", OfType: &elem}
+	} else {
+		ref = schemaTypeRef{Kind: "NAMED", Name: t.Name}
+	}
+
+	if t.NonNull {
+		return schemaTypeRef{Kind: "NON_NULL", OfType: &ref}
+	}
+
+	return ref
+}
+
+func refToGQLType(ref schemaTypeRef) *gqlType {
+	switch {
+	case ref.Kind == "NON_NULL" && ref.OfType != nil:
+		t := refToGQLType(*ref.OfType)
+		t.NonNull = true
+		return t
+	case ref.Kind == "LIST" && ref.OfType != nil:
+		return &gqlType{Elem: refToGQLType(*ref.OfType)}
+	}
+
+	return &gqlType{Name: ref.Name}
+}
+
+func literalMatchesScalar(value *gqlValue, scalar string) bool {
+	switch scalar {
+	case "Int":
+		return value.Kind == gqlValueInt
+	case "Float":
+		return value.Kind == gqlValueInt || value.Kind == gqlValueFloat
+	case "String":
+		return value.Kind == gqlValueString
+	case "Boolean":
+		return value.Kind == gqlValueBoolean
+	case "ID":
+		return value.Kind == gqlValueString || value.Kind == gqlValueInt
+	}
+
+	// Custom scalars define their own input formats
+	return true
+}
+
+func jsonMatchesScalar(value interface{}, scalar string) bool {
+	switch scalar {
+	case "Int":
+		f, ok := jsonNumber(value)
+		return ok && f == math.Trunc(f)
+	case "Float":
+		_, ok := jsonNumber(value)
+		return ok
+	case "String":
+		_, ok := value.(string)
+		return ok
+	case "Boolean":
+		_, ok := value.(bool)
+		return ok
+	case "ID":
+		if _, ok := value.(string); ok {
+			return true
+		}
+
+		f, ok := jsonNumber(value)
+		return ok && f == math.Trunc(f)
+	}
+
+	return true
+}
+
+func jsonNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func describeValue(value *gqlValue) string {
+	switch value.Kind {
+	case gqlValueString:
+		return fmt.Sprintf("the string %q", value.Raw)
+	case gqlValueEnum:
+		return "the enum value " + value.Raw
+	case gqlValueList, gqlValueObject:
+		return "a " + value.Kind
+	}
+
+	return value.Kind + " " + value.Raw
+}
+
+func describeJSONValue(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return fmt.Sprintf("the string %q", value)
+	case bool:
+		return fmt.Sprintf("the boolean %v", value)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+
+	return fmt.Sprintf("the number %v", value)
+}
+
+func findInputValue(values []schemaInputValue, name string) *schemaInputValue {
+	for i := range values {
+		if values[i].Name == name {
+			return &values[i]
+		}
+	}
+
+	return nil
+}
+
+func findEnumValue(values []schemaEnumValue, name string) *schemaEnumValue {
+	for i := range values {
+		if values[i].Name == name {
+			return &values[i]
+		}
+	}
+
+	return nil
+}
+
+func collectFragmentSpreads(selections []*gqlSelection, fragments map[string]*gqlFragment, used map[string]bool) {
+	for _, s := range selections {
+		if s.Kind == gqlSelectionFragmentSpread {
+			if used[s.Name] {
+				continue
+			}
+
+			used[s.Name] = true
+
+			if f, ok := fragments[s.Name]; ok {
+				collectFragmentSpreads(f.SelectionSet, fragments, used)
+			}
+
+			continue
+		}
+
+		collectFragmentSpreads(s.SelectionSet, fragments, used)
+	}
+}
+
+func isCompositeKind(kind string) bool {
+	return kind == "OBJECT" || kind == "INTERFACE" || kind == "UNION"
+}
+
+func operationLabel(op *gqlOperation) string {
+	if op.Name == "" {
+		return "the anonymous " + op.Type
+	}
+
+	return op.Type + " " + op.Name
+}
+
+func deprecationSuffix(reason string) string {
+	if reason == "" {
+		return ""
+	}
+
+	return ": " + reason
+}
+
+func countValidationIssues(issues []validationIssue) (int, int) {
+	errorCount, warningCount := 0, 0
+
+	for _, i := range issues {
+		if i.Severity == validationSeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+
+	return errorCount, warningCount
+}
@@ -0,0 +1,188 @@
+// +build unit
+
+package nerdgraph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validate(t *testing.T, document string, operationName string, variables map[string]interface{}) []string {
+	issues := newValidator(loadTestSchema(t)).Validate("", document, operationName, variables)
+
+	found := make([]string, len(issues))
+	for i, issue := range issues {
+		found[i] = issue.String()
+	}
+
+	return found
+}
+
+func TestValidateValidDocument(t *testing.T) {
+	issues := validate(t, `
+query Entities($query: String, $cursor: String, $sort: [EntitySearchSortCriteria]) {
+  actor {
+    entitySearch(query: $query, sortBy: $sort) {
+      count
+      results(cursor: $cursor) {
+        nextCursor
+        entities { __typename ...entity ... on ApmApplicationEntity { language } }
+      }
+    }
+  }
+}
+
+fragment entity on Entity { guid name }
+
+mutation Tag($guid: EntityGuid!) {
+  taggingAddTagsToEntity(guid: $guid, tags: [{key: "team", values: ["a", "b"]}]) { errors }
+}
+`, "", nil)
+
+	assert.Empty(t, issues)
+}
+
+func TestValidateFields(t *testing.T) {
+	issues := validate(t, `{
+  actor {
+    entitySearch(query: 1, sort: NAME) { count { total } results }
+    entityTags
+    missing
+  }
+}`, "", nil)
+
+	assert.Equal(t, []string{
+		"3:25: error: argument query of Actor.entitySearch expects String, found Int 1 [argument-type]",
+		"3:28: error: unknown argument sort on field Actor.entitySearch [unknown-argument]",
+		"3:42: error: field count of type Int cannot have a selection of subfields [selection]",
+		"3:58: error: field results of type EntitySearchResult must have a selection of subfields [selection]",
+		"4:5: warning: field Actor.entityTags is deprecated: Use entity.tags [deprecated]",
+		"5:5: error: unknown field missing on type Actor [unknown-field]",
+	}, issues)
+}
+
+func TestValidateArguments(t *testing.T) {
+	issues := validate(t, `mutation {
+  taggingAddTagsToEntity(tags: {key: "team", value: "a"}) { errors }
+  m: taggingAddTagsToEntity(guid: null, tags: [{values: "a"}]) { errors }
+}
+query { actor { entitySearch(sortBy: [NAME, REPORTING, SIZE]) { count } } }`, "", nil)
+
+	assert.Equal(t, []string{
+		"1:1: error: an anonymous operation must be the only operation in the document [operation]",
+		"2:3: error: field Mutation.taggingAddTagsToEntity is missing the required argument guid of type EntityGuid! [missing-argument]",
+		"2:46: error: unknown field value of input type TaggingTagInput in argument tags of Mutation.taggingAddTagsToEntity [argument-type]",
+		"3:35: error: argument guid of Mutation.taggingAddTagsToEntity expects EntityGuid!, found null [argument-type]",
+		"3:48: error: argument tags of Mutation.taggingAddTagsToEntity is missing the required field key of TaggingTagInput [argument-type]",
+		"5:1: error: an anonymous operation must be the only operation in the document [operation]",
+		"5:45: warning: enum value EntitySearchSortCriteria.REPORTING is deprecated [deprecated]",
+		"5:56: error: argument sortBy of Actor.entitySearch expects EntitySearchSortCriteria, which has no value SIZE [argument-type]",
+	}, issues)
+}
+
+func TestValidateVariables(t *testing.T) {
+	issues := validate(t, `query Q($guid: EntityGuid, $unused: Int, $query: Int, $result: Actor) {
+  actor { entity(guid: $guid) { guid } entitySearch(query: $query) { count @skip(if: $skip) } }
+}`, "", nil)
+
+	assert.Equal(t, []string{
+		"1:28: warning: variable $unused is never used in query Q [unused-variable]",
+		"1:55: warning: variable $result is never used in query Q [unused-variable]",
+		"1:64: error: variable $result cannot be of object type Actor [variable-type]",
+		"2:24: error: variable $guid of type EntityGuid cannot be used where EntityGuid! is expected [argument-type]",
+		"2:60: error: variable $query of type Int cannot be used where String is expected [argument-type]",
+		"2:86: error: variable $skip is not defined by query Q [undefined-variable]",
+	}, issues)
+}
+
+func TestValidateFragments(t *testing.T) {
+	issues := validate(t, `query Q($cursor: String) { actor { entitySearch { ...search ...missing } } }
+fragment search on EntitySearch { results(cursor: $cursor) { nextCursor } }
+fragment unused on Entity { guid }
+fragment scalar on String { length }
+fragment broken on Nope { x }`, "", nil)
+
+	assert.Equal(t, []string{
+		"1:64: error: unknown fragment missing [unknown-fragment]",
+		"3:1: warning: fragment unused is never used [unused-fragment]",
+		"4:1: warning: fragment scalar is never used [unused-fragment]",
+		"4:20: error: fragment scalar cannot be on scalar type String [selection]",
+		"5:1: warning: fragment broken is never used [unused-fragment]",
+		"5:20: error: unknown type Nope [unknown-type]",
+	}, issues)
+}
+
+func TestValidateUnion(t *testing.T) {
+	s := loadTestSchema(t)
+	union, ok := s.Type("SearchResult")
+	require.True(t, ok)
+
+	v := newValidator(s)
+	v.walkSelections(union, []*gqlSelection{{Kind: gqlSelectionField, Name: "count"}}, nil, true, nil, map[string]bool{})
+
+	require.Len(t, v.issues, 1)
+	assert.Equal(t, "cannot query field count on union SearchResult, use an inline fragment on one of its types", v.issues[0].Message)
+}
+
+func TestValidateVariableValues(t *testing.T) {
+	document := `query Q($guid: EntityGuid!, $sort: [EntitySearchSortCriteria!], $limit: Int, $query: String = "x") {
+  actor { entity(guid: $guid) { guid } entitySearch(query: $query, sortBy: $sort) { count } }
+}
+mutation M($tags: [TaggingTagInput!]!, $guid: EntityGuid!) { taggingAddTagsToEntity(guid: $guid, tags: $tags) { errors } }`
+
+	issues := validate(t, document, "Q", map[string]interface{}{
+		"sort":  []interface{}{"NAME", "SIZE"},
+		"limit": json.Number("1.5"),
+	})
+
+	assert.Equal(t, []string{
+		"1:9: error: required variable $guid of type EntityGuid! has no value [missing-variable]",
+		"1:29: error: $sort[1] expects EntitySearchSortCriteria, which has no value SIZE [variable-type]",
+		"1:65: warning: variable $limit is never used in query Q [unused-variable]",
+		"1:65: error: $limit expects Int, found the number 1.5 [variable-type]",
+	}, issues)
+
+	issues = validate(t, document, "M", map[string]interface{}{
+		"guid": "abc",
+		"tags": map[string]interface{}{"values": []interface{}{"a", "b"}},
+	})
+
+	assert.Equal(t, []string{
+		"1:65: warning: variable $limit is never used in query Q [unused-variable]",
+		"4:12: error: $tags is missing the required field key of TaggingTagInput [variable-type]",
+	}, issues)
+
+	issues = validate(t, document, "Missing", map[string]interface{}{})
+	assert.Contains(t, issues, "1:1: error: the document has no operation named Missing [operation]")
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	issues := newValidator(loadTestSchema(t)).Validate("q.graphql", "{\n  actor {\n", "", nil)
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, "q.graphql", issues[0].Source)
+	assert.Equal(t, validationRuleSyntax, issues[0].Rule)
+	assert.Equal(t, 3, issues[0].Line)
+
+	errorCount, warningCount := countValidationIssues(issues)
+	assert.Equal(t, 1, errorCount)
+	assert.Equal(t, 0, warningCount)
+}
+
+func TestParseSchemaFile(t *testing.T) {
+	for _, content := range []string{
+		`{"region": "US", "schema": ` + testSchemaJSON + `}`,
+		`{"__schema": ` + testSchemaJSON + `}`,
+		`{"data": {"__schema": ` + testSchemaJSON + `}}`,
+	} {
+		s, err := parseSchemaFile([]byte(content))
+		require.NoError(t, err)
+		assert.Equal(t, "Query", s.QueryType.Name)
+	}
+
+	_, err := parseSchemaFile([]byte(`{"data": {}}`))
+	assert.EqualError(t, err, "the file does not contain a schema")
+}
@@ -0,0 +1,135 @@
+package nerdgraph
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/newrelic/newrelic-cli/internal/pipe"
+)
+
+// stdinPath is the file name that refers to stdin.
+const stdinPath = "-"
+
+// readInput returns the content of a file, or of stdin when the path is -.
+func readInput(path string) ([]byte, error) {
+	if path == stdinPath {
+		return pipe.ReadAll()
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// parseVariablesFile decodes a JSON or YAML object of variables.
+func parseVariablesFile(content []byte) (map[string]interface{}, error) {
+	var decoded interface{}
+	if err := yaml.Unmarshal(content, &decoded); err != nil {
+		return nil, fmt.Errorf("unable to parse variables file: %s", err)
+	}
+
+	if decoded == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	vars, ok := normalizeYAML(decoded).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("variables file must contain an object, found %T", decoded)
+	}
+
+	return vars, nil
+}
+
+// normalizeYAML converts the maps decoded from YAML, which are keyed by
+// interface{}, into maps keyed by string that can be encoded as JSON.
+func normalizeYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[fmt.Sprint(k)] = normalizeYAML(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeYAML(e)
+		}
+	}
+
+	return v
+}
+
+// parseVariableOverrides converts a list of name=value or name:type=value
+// pairs into variables.  Without a type, values that are valid JSON, such as
+// numbers, booleans, null, lists and objects, are used as such, and anything
+// else is used as a string.  The type may be one of string, int, float, bool
+// or json.
+func parseVariableOverrides(pairs []string) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+
+	for _, p := range pairs {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("variables must be specified as name=value or name:type=value pairs: %s", p)
+		}
+
+		name, valueType := kv[0], ""
+		if i := strings.Index(name, ":"); i >= 0 {
+			name, valueType = name[:i], name[i+1:]
+		}
+
+		value, err := parseVariableValue(kv[1], valueType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for variable %s: %s", name, err)
+		}
+
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+func parseVariableValue(value string, valueType string) (interface{}, error) {
+	switch valueType {
+	case "":
+		var v interface{}
+		if err := unmarshalJSON([]byte(value), &v); err == nil {
+			return v, nil
+		}
+
+		return value, nil
+	case "string":
+		return value, nil
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "json":
+		var v interface{}
+		if err := unmarshalJSON([]byte(value), &v); err != nil {
+			return nil, err
+		}
+
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("unknown type %s, please use one of: string, int, float, bool, json", valueType)
+}
+
+// mergeVariables combines sets of variables, with later sets taking
+// precedence over earlier ones.
+func mergeVariables(sets ...map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+
+	for _, s := range sets {
+		for k, v := range s {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
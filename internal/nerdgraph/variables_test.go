@@ -0,0 +1,89 @@
+// +build unit
+
+package nerdgraph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVariablesFile(t *testing.T) {
+	yamlVars, err := parseVariablesFile([]byte(`
+guid: MXxBUE18QVBQTElDQVRJT058MQ
+limit: 10
+filter:
+  tags:
+    - key: team
+      values: [api]
+`))
+	require.NoError(t, err)
+
+	jsonVars, err := parseVariablesFile([]byte(`{"guid": "MXxBUE18QVBQTElDQVRJT058MQ", "limit": 10, "filter": {"tags": [{"key": "team", "values": ["api"]}]}}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, jsonVars, yamlVars)
+
+	encoded, err := json.Marshal(yamlVars)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"guid": "MXxBUE18QVBQTElDQVRJT058MQ", "limit": 10, "filter": {"tags": [{"key": "team", "values": ["api"]}]}}`, string(encoded))
+
+	empty, err := parseVariablesFile([]byte(``))
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	_, err = parseVariablesFile([]byte(`- a`))
+	assert.Error(t, err)
+}
+
+func TestParseVariableOverrides(t *testing.T) {
+	vars, err := parseVariableOverrides([]string{
+		"guid=MXxBUE18QVBQTElDQVRJT058MQ",
+		"limit=10",
+		"enabled=true",
+		"types=[\"APPLICATION\"]",
+		"name:string=123",
+		"count:int=5",
+		"ratio:float=0.5",
+		"flag:bool=false",
+		"query=domain = 'APM'",
+		"trailing=10abc",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "MXxBUE18QVBQTElDQVRJT058MQ", vars["guid"])
+	assert.Equal(t, json.Number("10"), vars["limit"])
+	assert.Equal(t, true, vars["enabled"])
+	assert.Equal(t, []interface{}{"APPLICATION"}, vars["types"])
+	assert.Equal(t, "123", vars["name"])
+	assert.Equal(t, int64(5), vars["count"])
+	assert.Equal(t, 0.5, vars["ratio"])
+	assert.Equal(t, false, vars["flag"])
+	assert.Equal(t, "domain = 'APM'", vars["query"])
+	assert.Equal(t, "10abc", vars["trailing"])
+}
+
+func TestParseVariableOverridesErrors(t *testing.T) {
+	for _, pairs := range [][]string{
+		{"noequals"},
+		{"=value"},
+		{"count:int=abc"},
+		{"x:date=2020-01-01"},
+		{"x:json={"},
+	} {
+		_, err := parseVariableOverrides(pairs)
+		assert.Error(t, err, pairs)
+	}
+}
+
+func TestMergeVariables(t *testing.T) {
+	merged := mergeVariables(
+		map[string]interface{}{"a": 1, "b": 1},
+		map[string]interface{}{"b": 2, "c": 2},
+		map[string]interface{}{"c": 3},
+	)
+
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2, "c": 3}, merged)
+}
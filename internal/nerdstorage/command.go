@@ -2,6 +2,10 @@ package nerdstorage
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/entities/resolver"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
 )
 
 var (
@@ -19,3 +23,12 @@ var Command = &cobra.Command{
 	Use:   "nerdstorage",
 	Short: "Read, write, and delete NerdStorage documents and collections.",
 }
+
+// resolveEntityGUID returns the GUID of the entity given by --entityGuid,
+// looking it up by name when given as name=<name>.
+func resolveEntityGUID(nrClient *newrelic.NewRelic) string {
+	guid, err := resolver.New(&nrClient.NerdGraph).Resolve(utils.SignalCtx, entityGUID)
+	utils.LogIfFatal(err)
+
+	return guid
+}
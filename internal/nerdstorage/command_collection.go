@@ -53,7 +53,7 @@ GUID.  A valid Nerdpack package ID is required.
 			case "account":
 				resp, err = nrClient.NerdStorage.GetCollectionWithAccountScope(accountID, input)
 			case "entity":
-				resp, err = nrClient.NerdStorage.GetCollectionWithEntityScope(entityGUID, input)
+				resp, err = nrClient.NerdStorage.GetCollectionWithEntityScope(resolveEntityGUID(nrClient), input)
 			case "user":
 				resp, err = nrClient.NerdStorage.GetCollectionWithUserScope(input)
 			default:
@@ -101,7 +101,7 @@ GUID.  A valid Nerdpack package ID is required.
 			case "account":
 				_, err = nrClient.NerdStorage.DeleteCollectionWithAccountScope(accountID, input)
 			case "entity":
-				_, err = nrClient.NerdStorage.DeleteCollectionWithEntityScope(entityGUID, input)
+				_, err = nrClient.NerdStorage.DeleteCollectionWithEntityScope(resolveEntityGUID(nrClient), input)
 			case "user":
 				_, err = nrClient.NerdStorage.DeleteCollectionWithUserScope(input)
 			default:
@@ -121,7 +121,7 @@ func init() {
 
 	cmdCollection.AddCommand(cmdCollectionGet)
 	cmdCollectionGet.Flags().IntVarP(&accountID, "accountId", "a", 0, "the account ID")
-	cmdCollectionGet.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID")
+	cmdCollectionGet.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID, or the entity name as name=<name>")
 	cmdCollectionGet.Flags().StringVarP(&packageID, "packageId", "p", "", "the external package ID")
 	cmdCollectionGet.Flags().StringVarP(&collection, "collection", "c", "", "the collection name to get the document from")
 	cmdCollectionGet.Flags().StringVarP(&scope, "scope", "s", "USER", "the scope to get the document from")
@@ -137,7 +137,7 @@ func init() {
 
 	cmdCollection.AddCommand(cmdCollectionDelete)
 	cmdCollectionDelete.Flags().IntVarP(&accountID, "accountId", "a", 0, "the account ID")
-	cmdCollectionDelete.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID")
+	cmdCollectionDelete.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID, or the entity name as name=<name>")
 	cmdCollectionDelete.Flags().StringVarP(&packageID, "packageId", "", "p", "the external package ID")
 	cmdCollectionDelete.Flags().StringVarP(&collection, "collection", "c", "", "the collection name to delete the document from")
 	cmdCollectionDelete.Flags().StringVarP(&scope, "scope", "s", "USER", "the scope to delete the document from")
@@ -55,7 +55,7 @@ GUID.  A valid Nerdpack package ID is required.
 			case "account":
 				document, err = nrClient.NerdStorage.GetDocumentWithAccountScope(accountID, input)
 			case "entity":
-				document, err = nrClient.NerdStorage.GetDocumentWithEntityScope(entityGUID, input)
+				document, err = nrClient.NerdStorage.GetDocumentWithEntityScope(resolveEntityGUID(nrClient), input)
 			case "user":
 				document, err = nrClient.NerdStorage.GetDocumentWithUserScope(input)
 			default:
@@ -109,7 +109,7 @@ GUID.  A valid Nerdpack package ID is required.
 			case "account":
 				_, err = nrClient.NerdStorage.WriteDocumentWithAccountScope(accountID, input)
 			case "entity":
-				_, err = nrClient.NerdStorage.WriteDocumentWithEntityScope(entityGUID, input)
+				_, err = nrClient.NerdStorage.WriteDocumentWithEntityScope(resolveEntityGUID(nrClient), input)
 			case "user":
 				_, err = nrClient.NerdStorage.WriteDocumentWithUserScope(input)
 			default:
@@ -157,7 +157,7 @@ GUID.  A valid Nerdpack package ID is required.
 			case "account":
 				_, err = nrClient.NerdStorage.DeleteDocumentWithAccountScope(accountID, input)
 			case "entity":
-				_, err = nrClient.NerdStorage.DeleteDocumentWithEntityScope(entityGUID, input)
+				_, err = nrClient.NerdStorage.DeleteDocumentWithEntityScope(resolveEntityGUID(nrClient), input)
 			case "user":
 				_, err = nrClient.NerdStorage.DeleteDocumentWithUserScope(input)
 			default:
@@ -177,7 +177,7 @@ func init() {
 
 	cmdDocument.AddCommand(cmdDocumentGet)
 	cmdDocumentGet.Flags().IntVarP(&accountID, "accountId", "a", 0, "the account ID")
-	cmdDocumentGet.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID")
+	cmdDocumentGet.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID, or the entity name as name=<name>")
 	cmdDocumentGet.Flags().StringVarP(&packageID, "packageId", "p", "", "the external package ID")
 	cmdDocumentGet.Flags().StringVarP(&collection, "collection", "c", "", "the collection name to get the document from")
 	cmdDocumentGet.Flags().StringVarP(&documentID, "documentId", "d", "", "the document ID")
@@ -197,7 +197,7 @@ func init() {
 
 	cmdDocument.AddCommand(cmdDocumentWrite)
 	cmdDocumentWrite.Flags().IntVarP(&accountID, "accountId", "a", 0, "the account ID")
-	cmdDocumentWrite.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID")
+	cmdDocumentWrite.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID, or the entity name as name=<name>")
 	cmdDocumentWrite.Flags().StringVarP(&packageID, "packageId", "p", "", "the external package ID")
 	cmdDocumentWrite.Flags().StringVarP(&collection, "collection", "c", "", "the collection name to write the document to")
 	cmdDocumentWrite.Flags().StringVarP(&documentID, "documentId", "d", "", "the document ID")
@@ -221,7 +221,7 @@ func init() {
 
 	cmdDocument.AddCommand(cmdDocumentDelete)
 	cmdDocumentDelete.Flags().IntVarP(&accountID, "accountId", "a", 0, "the account ID")
-	cmdDocumentDelete.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID")
+	cmdDocumentDelete.Flags().StringVarP(&entityGUID, "entityGuid", "e", "", "the entity GUID, or the entity name as name=<name>")
 	cmdDocumentDelete.Flags().StringVarP(&packageID, "packageId", "p", "", "the external package ID")
 	cmdDocumentDelete.Flags().StringVarP(&collection, "collection", "c", "", "the collection name to delete the document from")
 	cmdDocumentDelete.Flags().StringVarP(&documentID, "documentId", "d", "", "the document ID")
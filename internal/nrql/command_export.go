@@ -0,0 +1,104 @@
+package nrql
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+)
+
+var (
+	exportSince       string
+	exportUntil       string
+	exportChunk       string
+	exportRowLimit    int
+	exportConcurrency int
+)
+
+var cmdExport = &cobra.Command{
+	Use:   "export",
+	Short: "Export the events matching a NRQL query over a time range",
+	Long: `Export the events matching a NRQL query over a time range
+
+The export command retrieves more events than a single NRQL query can return by
+splitting the time range into windows of the --chunk size and querying each one.
+Windows are queried concurrently, and any window that reaches the row limit is
+split in half and queried again until every event has been retrieved.  Rows are
+written as they arrive, in time window order.  An event with an id, guid or
+messageId returned by two windows at the boundary between them is written once,
+while identical rows are otherwise kept, as they are separate events.
+
+The query must not contain SINCE, UNTIL or LIMIT clauses, which are added for each
+window, and must return events rather than aggregates.  The --since and --until
+flags accept a duration before now, such as 7d, 12h or 30m, or an RFC3339
+timestamp.
+
+Rows are written to standard output as newline delimited JSON, or as CSV when
+--format CSV is given.  The CSV header is taken from the attributes of the first
+rows exported.
+`,
+	Example: `newrelic nrql export --accountId 12345678 --query "SELECT * FROM Transaction WHERE appName = 'WebPortal'" --since 7d --chunk 1h > transactions.ndjson
+newrelic nrql export --accountId 12345678 --query 'SELECT timestamp, message FROM Log' --since 2021-01-01T00:00:00Z --until 2021-01-02T00:00:00Z --format CSV > logs.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+
+		since, err := parseExportTime(exportSince, now)
+		utils.LogIfFatal(err)
+
+		until, err := parseExportTime(exportUntil, now)
+		utils.LogIfFatal(err)
+
+		if !since.Before(until) {
+			utils.LogIfFatal(errors.New("--since must be earlier than --until"))
+		}
+
+		chunk, err := parseExportDuration(exportChunk)
+		utils.LogIfFatal(err)
+
+		if chunk < minExportWindow {
+			utils.LogIfFatal(fmt.Errorf("--chunk must be at least %s", minExportWindow))
+		}
+
+		var w exportRowWriter
+
+		switch format := output.CurrentFormat(); format {
+		case output.FormatJSON:
+			w = newNDJSONRowWriter(os.Stdout)
+		case output.FormatCSV:
+			w = newCSVRowWriter(os.Stdout)
+		default:
+			utils.LogIfFatal(fmt.Errorf("export does not support the %s format, please use JSON or CSV", format))
+		}
+
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			e, err := newExporter(&nrClient.Nrdb, accountID, query, exportRowLimit, exportConcurrency)
+			utils.LogIfFatal(err)
+
+			utils.LogIfFatal(e.Run(utils.SignalCtx, since, until, chunk, w))
+		})
+	},
+}
+
+func init() {
+	Command.AddCommand(cmdExport)
+	cmdExport.Flags().IntVarP(&accountID, "accountId", "a", 0, "the New Relic account ID where you want to query")
+	utils.LogIfError(cmdExport.MarkFlagRequired("accountId"))
+
+	cmdExport.Flags().StringVarP(&query, "query", "q", "", "the NRQL query whose events you want to export")
+	utils.LogIfError(cmdExport.MarkFlagRequired("query"))
+
+	cmdExport.Flags().StringVar(&exportSince, "since", "", "the start of the time range, as a duration before now or an RFC3339 timestamp")
+	utils.LogIfError(cmdExport.MarkFlagRequired("since"))
+
+	cmdExport.Flags().StringVar(&exportUntil, "until", "now", "the end of the time range, as a duration before now or an RFC3339 timestamp")
+	cmdExport.Flags().StringVar(&exportChunk, "chunk", defaultExportChunk, "the size of the time windows queried, such as 1h or 1d")
+	cmdExport.Flags().IntVar(&exportRowLimit, "limit", defaultExportRowLimit, "the maximum number of rows requested per window")
+	cmdExport.Flags().IntVar(&exportConcurrency, "concurrency", defaultExportConcurrency, "the maximum number of windows to query at once")
+}
@@ -0,0 +1,18 @@
+// +build unit
+
+package nrql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestExport(t *testing.T) {
+	assert.Equal(t, "export", cmdExport.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdExport)
+	testcobra.CheckCobraRequiredFlags(t, cmdExport, []string{"accountId", "query", "since"})
+}
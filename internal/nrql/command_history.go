@@ -0,0 +1,155 @@
+package nrql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+var (
+	historyLimit     int
+	historyGrep      string
+	historySource    string
+	historyAccountID int
+)
+
+var cmdHistory = &cobra.Command{
+	Use:   "history",
+	Short: "Retrieve NRQL query history",
+	Long: `Retrieve NRQL query history
+
+The history command will fetch a list of the most recent NRQL queries you executed,
+newest first, along with the account each was run against and when.  Queries run
+through this CLI are also kept in a local history file in the configuration
+directory, which is merged with the history stored by New Relic.  Use the --source
+flag to show only one of the two.
+
+Each query is numbered by its position in the history.  The --grep flag filters
+the queries shown by a case-insensitive regular expression while keeping their
+numbers, which can be passed to 'nrql history rerun' to execute a query again.
+`,
+	Example: `newrelic nrql history
+newrelic nrql history --grep 'FROM Transaction' --limit 20 --format YAML
+newrelic nrql history --source local`,
+	PreRunE: validateHistorySource,
+	Run: func(cmd *cobra.Command, args []string) {
+		pattern, err := compileHistoryGrep(historyGrep)
+		utils.LogIfFatal(err)
+
+		withHistory(func(entries []historyEntry) {
+			if len(entries) == 0 {
+				log.Info("no history found. Try using the 'newrelic nrql query' command")
+				return
+			}
+
+			utils.LogIfFatal(output.Print(filterHistory(entries, pattern, historyLimit)))
+		})
+	},
+}
+
+var cmdHistoryRerun = &cobra.Command{
+	Use:   "rerun <index>",
+	Short: "Execute a query from the NRQL query history again",
+	Long: `Execute a query from the NRQL query history again
+
+The rerun command executes the query with the given index, as shown by the
+'nrql history' command, against the account it was originally run against.  Use
+the --accountId flag to run it against a different account.  The same --source
+flag given to 'nrql history' must be used for the index to refer to the same query.
+`,
+	Example: `newrelic nrql history rerun 3
+newrelic nrql history rerun 3 --accountId 12345678`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("index must be a number: %s", args[0])
+		}
+
+		return validateHistorySource(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		index, _ := strconv.Atoi(args[0])
+
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			entries, err := loadHistory(localHistory, &nrClient.Nrdb, historySource)
+			utils.LogIfFatal(err)
+
+			entry, err := findHistoryEntry(entries, index)
+			utils.LogIfFatal(err)
+
+			if historyAccountID != 0 {
+				entry.AccountID = historyAccountID
+			}
+
+			log.Infof("running %s against account %d", entry.NRQL, entry.AccountID)
+
+			result, err := nrClient.Nrdb.QueryWithContext(utils.SignalCtx, entry.AccountID, nrdb.NRQL(entry.NRQL))
+			utils.LogIfFatal(err)
+
+			recordQuery(localHistory, entry.AccountID, entry.NRQL)
+
+			utils.LogIfFatal(output.Print(formatResults(result, output.CurrentFormat(), false, false)))
+		})
+	},
+}
+
+// withHistory loads the query history from the selected source.  The local
+// history is available without credentials.
+func withHistory(f func(entries []historyEntry)) {
+	if historySource == historySourceLocal {
+		entries, err := loadHistory(localHistory, nil, historySource)
+		utils.LogIfFatal(err)
+
+		f(entries)
+		return
+	}
+
+	client.WithClient(func(nrClient *newrelic.NewRelic) {
+		entries, err := loadHistory(localHistory, &nrClient.Nrdb, historySource)
+		utils.LogIfFatal(err)
+
+		f(entries)
+	})
+}
+
+func validateHistorySource(cmd *cobra.Command, args []string) error {
+	for _, s := range historySources {
+		if s == historySource {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown history source %s, please use one of: all, local, remote", historySource)
+}
+
+func compileHistoryGrep(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --grep pattern: %s", err)
+	}
+
+	return re, nil
+}
+
+func init() {
+	Command.AddCommand(cmdHistory)
+	cmdHistory.PersistentFlags().StringVar(&historySource, "source", historySourceAll, "the history to use: all, local or remote")
+	cmdHistory.Flags().IntVarP(&historyLimit, "limit", "l", 10, "history items to return (default: 10, max: 100)")
+	cmdHistory.Flags().StringVar(&historyGrep, "grep", "", "only show queries matching a case-insensitive regular expression")
+
+	cmdHistory.AddCommand(cmdHistoryRerun)
+	cmdHistoryRerun.Flags().IntVarP(&historyAccountID, "accountId", "a", 0, "the account to run the query against, instead of the one it was run against originally")
+}
@@ -0,0 +1,25 @@
+// +build unit
+
+package nrql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestHistory(t *testing.T) {
+	assert.Equal(t, "history", cmdHistory.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdHistory)
+	testcobra.CheckCobraRequiredFlags(t, cmdHistory, []string{})
+}
+
+func TestHistoryRerun(t *testing.T) {
+	assert.Equal(t, "rerun", cmdHistoryRerun.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdHistoryRerun)
+	testcobra.CheckCobraRequiredFlags(t, cmdHistoryRerun, []string{})
+}
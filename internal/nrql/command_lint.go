@@ -0,0 +1,152 @@
+package nrql
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+)
+
+var (
+	lintHighCardinality []string
+	lintDisabledRules   []string
+	lintStrict          bool
+)
+
+var cmdLint = &cobra.Command{
+	Use:   "lint [file ...]",
+	Short: "Check NRQL queries for errors without running them",
+	Long: `Check NRQL queries for errors without running them
+
+The lint command checks NRQL syntax locally, without contacting New Relic, which
+makes it suitable for validating queries kept in source control as part of CI.
+Queries are read from the --query flag or from the given files.  Files ending in
+.yaml, .yml or .json are read as query files, in the same format accepted by
+'nrql query --file'.  Any other file is read as one or more NRQL queries separated
+by semicolons.
+
+In addition to syntax errors, the following are reported as warnings:
+
+  missing-since           the query has no SINCE clause
+  unbounded-limit         the query uses LIMIT MAX
+  high-cardinality-facet  the query facets on an attribute from --highCardinality
+  unknown-function        the query calls a function that is not known to the CLI
+
+The command exits with a non-zero status when errors are found, or when warnings
+are found and --strict is set.
+`,
+	Example: `newrelic nrql lint --query 'SELECT count(*) FROM Transaction FACET traceId'
+newrelic nrql lint alerts/*.nrql dashboards/queries.yaml --strict
+newrelic nrql lint --disable missing-since --highCardinality traceId,sessionId alerts/*.nrql`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if (query == "") == (len(args) == 0) {
+			return errors.New("either --query or at least one file is required")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		l, err := newLinter(lintHighCardinality, lintDisabledRules)
+		utils.LogIfFatal(err)
+
+		vars, err := parseVariables(queryVariables)
+		utils.LogIfFatal(err)
+
+		issues := []lintIssue{}
+
+		if query != "" {
+			issues = append(issues, l.Lint("", query)...)
+		}
+
+		for _, path := range args {
+			found, err := lintFile(l, path, vars)
+			utils.LogIfFatal(err)
+
+			issues = append(issues, found...)
+		}
+
+		if output.CurrentFormat() == output.FormatText {
+			for _, i := range issues {
+				fmt.Println(i)
+			}
+		} else {
+			utils.LogIfFatal(output.Print(issues))
+		}
+
+		errorCount, warningCount := countIssues(issues)
+		if errorCount > 0 || lintStrict && warningCount > 0 {
+			log.Fatalf("found %d errors and %d warnings", errorCount, warningCount)
+		}
+	},
+}
+
+// lintFile lints the queries in a query file or a file of NRQL statements.
+func lintFile(l *linter, path string, vars map[string]string) ([]lintIssue, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return l.Lint(path, string(content)), nil
+	}
+
+	f, err := parseQueryFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	queries, err := f.render(vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	var issues []lintIssue
+	for _, q := range queries {
+		issues = append(issues, l.Lint(fmt.Sprintf("%s[%s]", path, q.Name), q.Query)...)
+	}
+
+	return issues, nil
+}
+
+// preflightLint lints a query before it is executed.  Warnings are logged,
+// while errors prevent the query from being run.
+func preflightLint(l *linter, source string, query string) error {
+	issues := l.Lint(source, query)
+
+	for _, i := range issues {
+		if i.Severity == lintSeverityWarning {
+			log.Warn(i)
+		} else {
+			log.Error(i)
+		}
+	}
+
+	if errorCount, _ := countIssues(issues); errorCount > 0 {
+		return fmt.Errorf("query failed lint checks with %d errors", errorCount)
+	}
+
+	return nil
+}
+
+func addLintFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&lintHighCardinality, "highCardinality", defaultHighCardinalityAttributes, "attributes that should not be used in a FACET clause")
+	cmd.Flags().StringSliceVar(&lintDisabledRules, "disable", []string{}, "lint rules to skip, such as missing-since")
+}
+
+func init() {
+	Command.AddCommand(cmdLint)
+	cmdLint.Flags().StringVarP(&query, "query", "q", "", "the NRQL query you want to check")
+	cmdLint.Flags().StringArrayVar(&queryVariables, "var", []string{}, "a key=value pair used to fill placeholders in a query file, may be repeated")
+	cmdLint.Flags().BoolVar(&lintStrict, "strict", false, "exit with a non-zero status when warnings are found")
+	addLintFlags(cmdLint)
+}
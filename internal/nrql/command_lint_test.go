@@ -0,0 +1,18 @@
+// +build unit
+
+package nrql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestLint(t *testing.T) {
+	assert.Equal(t, "lint", cmdLint.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdLint)
+	testcobra.CheckCobraRequiredFlags(t, cmdLint, []string{})
+}
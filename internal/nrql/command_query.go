@@ -1,8 +1,14 @@
 package nrql
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"github.com/newrelic/newrelic-cli/internal/client"
 	"github.com/newrelic/newrelic-cli/internal/output"
@@ -12,9 +18,16 @@ import (
 )
 
 var (
-	accountID    int
-	historyLimit int
-	query        string
+	accountID        int
+	query            string
+	queryFilePath    string
+	queryVariables   []string
+	queryConcurrency int
+	watchInterval    time.Duration
+	flattenResult    bool
+	pivotResult      bool
+	lintBeforeQuery  bool
+	showStats        bool
 )
 
 var cmdQuery = &cobra.Command{
@@ -22,64 +35,175 @@ var cmdQuery = &cobra.Command{
 	Short: "Execute a NRQL query to New Relic",
 	Long: `Execute a NRQL query to New Relic
 
-The query command requires the --query flag which represents a NRQL query string.
+The query command requires either the --query flag, which represents a NRQL query
+string, or the --file flag, which points to a YAML or JSON file of named queries.
 This command requires the --accountId <int> flag, which specifies the account to
 issue the query against.
+
+Queries within a file may contain placeholders such as {{ .appName }}, which are
+filled from the file's variables section and the --var key=value flag.  The queries
+are executed concurrently, and the results are returned keyed by query name:
+
+  variables:
+    appName: WebPortal
+  queries:
+    - name: throughput
+      query: SELECT rate(count(*), 1 minute) FROM Transaction WHERE appName = '{{ .appName }}'
+    - name: errors
+      query: SELECT count(*) FROM TransactionError WHERE appName = '{{ .appName }}'
+      accountId: 87654321
+
+The --watch flag re-executes a query on the given interval until interrupted.  When
+attached to a terminal the results are redrawn in place as a table, with values that
+changed since the previous iteration highlighted.  Otherwise, each iteration is
+written as a line of JSON.
+
+Text and CSV output flatten TIMESERIES, FACET and COMPARE WITH results into rows,
+with explicit timestamp, endTimestamp, comparison and facet columns.  Nested values,
+such as those returned by percentile(), become one column per key.  The --flatten
+flag applies the same treatment to JSON and YAML output.  The --pivot flag turns
+each facet value into its own column, leaving one row per time bucket.
+
+The --stats flag adds the metadata returned with the results, such as the event
+types queried, the time window and any warnings from NRDB, along with the time the
+query took from the client's point of view.  In JSON and YAML output the results
+and stats are returned together as a single object, while text and CSV output
+show the stats after the results.  With --file, the stats are included with the
+result of each query.
+
+The --lint flag checks each query locally before it is sent, as the 'nrql lint'
+command does.  Warnings are logged and queries with errors are not executed.
 `,
-	Example: `newrelic nrql query --accountId 12345678 --query 'SELECT count(*) FROM Transaction TIMESERIES'`,
+	Example: `newrelic nrql query --accountId 12345678 --query 'SELECT count(*) FROM Transaction TIMESERIES'
+newrelic nrql query --accountId 12345678 --file queries.yaml --var appName=WebPortal
+newrelic nrql query --accountId 12345678 --query 'SELECT count(*) FROM TransactionError FACET appName' --watch 30s
+newrelic nrql query --accountId 12345678 --query 'SELECT count(*) FROM Transaction FACET appName TIMESERIES' --format csv --pivot
+newrelic nrql query --accountId 12345678 --query 'SELECT count(*) FROM Transaction SINCE 1 week ago' --stats`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if (query == "") == (queryFilePath == "") {
+			return errors.New("exactly one of --query or --file is required")
+		}
+
+		if watchInterval < 0 || watchInterval > 0 && watchInterval < time.Second {
+			return errors.New("--watch interval must be at least 1s")
+		}
+
+		if watchInterval > 0 && queryFilePath != "" {
+			return errors.New("--watch cannot be used with --file")
+		}
+
+		if watchInterval > 0 && showStats {
+			return errors.New("--stats cannot be used with --watch")
+		}
+
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		var l *linter
+		if lintBeforeQuery {
+			var err error
+			l, err = newLinter(lintHighCardinality, lintDisabledRules)
+			utils.LogIfFatal(err)
+		}
+
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			if queryFilePath != "" {
+				runQueryFile(&nrClient.Nrdb, l)
+				return
+			}
 
-			result, err := nrClient.Nrdb.Query(accountID, nrdb.NRQL(query))
-			if err != nil {
-				log.Fatal(err)
+			if l != nil {
+				utils.LogIfFatal(preflightLint(l, "", query))
 			}
 
-			utils.LogIfFatal(output.Print(result.Results))
-		})
-	},
-}
+			recordQuery(localHistory, accountID, query)
 
-var cmdHistory = &cobra.Command{
-	Use:   "history",
-	Short: "Retrieve NRQL query history",
-	Long: `Retrieve NRQL query history
+			if watchInterval > 0 {
+				tty := term.IsTerminal(int(os.Stdout.Fd()))
+				w := newWatcher(&nrClient.Nrdb, accountID, nrdb.NRQL(query), watchInterval, os.Stdout, tty)
+				utils.LogIfFatal(w.Run(utils.SignalCtx))
+				return
+			}
 
-The history command will fetch a list of the most recent NRQL queries you executed.
-`,
-	Example: `newrelic nrql query history`,
-	Run: func(cmd *cobra.Command, args []string) {
-		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			start := time.Now()
 
-			result, err := nrClient.Nrdb.QueryHistory()
+			result, err := nrClient.Nrdb.QueryWithContext(utils.SignalCtx, accountID, nrdb.NRQL(query))
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			if result == nil {
-				log.Info("no history found. Try using the 'newrelc nrql query' command")
+			elapsed := time.Since(start)
+			format := output.CurrentFormat()
+			results := formatResults(result, format, flattenResult, pivotResult)
+
+			if !showStats {
+				utils.LogIfFatal(output.Print(results))
 				return
 			}
 
-			count := len(*result)
+			stats := newQueryStats(accountID, query, result, elapsed)
 
-			if count < historyLimit {
-				historyLimit = count
+			if format == output.FormatText || format == output.FormatCSV {
+				utils.LogIfFatal(output.Print(results))
+				fmt.Println()
+				utils.LogIfFatal(output.Print(stats))
+				return
 			}
 
-			output.Text((*result)[0:historyLimit])
+			utils.LogIfFatal(output.Print(queryResultWithStats{Results: results, Stats: stats}))
 		})
 	},
 }
 
+func runQueryFile(client nrdbClient, l *linter) {
+	f, err := loadQueryFile(queryFilePath)
+	utils.LogIfFatal(err)
+
+	vars, err := parseVariables(queryVariables)
+	utils.LogIfFatal(err)
+
+	queries, err := f.render(vars)
+	utils.LogIfFatal(err)
+
+	if l != nil {
+		for _, q := range queries {
+			utils.LogIfFatal(preflightLint(l, q.Name, q.Query))
+		}
+	}
+
+	results := runNamedQueries(utils.SignalCtx, client, accountID, queries, queryConcurrency, showStats)
+
+	for _, q := range queries {
+		recordQuery(localHistory, results[q.Name].AccountID, q.Query)
+	}
+
+	utils.LogIfFatal(output.Print(results))
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		log.Fatalf("%d of %d queries failed", failed, len(results))
+	}
+}
+
 func init() {
 	Command.AddCommand(cmdQuery)
 	cmdQuery.Flags().IntVarP(&accountID, "accountId", "a", 0, "the New Relic account ID where you want to query")
 	utils.LogIfError(cmdQuery.MarkFlagRequired("accountId"))
 
 	cmdQuery.Flags().StringVarP(&query, "query", "q", "", "the NRQL query you want to execute")
-	utils.LogIfError(cmdQuery.MarkFlagRequired("query"))
-
-	Command.AddCommand(cmdHistory)
-	cmdHistory.Flags().IntVarP(&historyLimit, "limit", "l", 10, "history items to return (default: 10, max: 100)")
+	cmdQuery.Flags().StringVarP(&queryFilePath, "file", "f", "", "a YAML or JSON file of named NRQL queries to execute")
+	cmdQuery.Flags().StringArrayVar(&queryVariables, "var", []string{}, "a key=value pair used to fill placeholders in a query file, may be repeated")
+	cmdQuery.Flags().DurationVar(&watchInterval, "watch", 0, "re-execute the query on the given interval, such as 30s or 5m")
+	cmdQuery.Flags().BoolVar(&flattenResult, "flatten", false, "flatten timeseries, faceted and comparison results into rows for JSON and YAML output")
+	cmdQuery.Flags().BoolVar(&pivotResult, "pivot", false, "pivot facet values into columns, implies --flatten")
+	cmdQuery.Flags().BoolVar(&showStats, "stats", false, "include the result metadata and query timing in the output")
+	cmdQuery.Flags().BoolVar(&lintBeforeQuery, "lint", false, "check the query for errors locally before executing it")
+	addLintFlags(cmdQuery)
+	cmdQuery.Flags().IntVar(&queryConcurrency, "concurrency", defaultQueryFileConcurrency, "the maximum number of queries from a query file to execute at once")
 }
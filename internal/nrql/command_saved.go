@@ -0,0 +1,170 @@
+package nrql
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/credentials"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+var (
+	savedDescription string
+	savedParams      []string
+	savedAccountID   int
+	savedProfile     string
+	savedForce       bool
+)
+
+var cmdSaved = &cobra.Command{
+	Use:   "saved",
+	Short: "Manage and run saved NRQL queries",
+	Long: `Manage and run saved NRQL queries
+
+Saved queries are kept in a YAML file in the configuration directory, which can be
+shared between team members.  A saved query may contain parameters, such as
+{{ .service }}, whose values are given with the --param flag when the query is run.
+Parameters may have default values, and a saved query may be pinned to the account
+and profile it should be run against.
+`,
+	Example: `newrelic nrql saved add errors-by-service --query "SELECT count(*) FROM TransactionError WHERE appName = '{{ .service }}' SINCE 1 day ago" --param service
+newrelic nrql saved run errors-by-service --param service=checkout`,
+}
+
+var cmdSavedAdd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Save a NRQL query",
+	Long: `Save a NRQL query
+
+The add command saves a query under the given name.  Parameters used in the query
+must be declared with the --param flag, either as name, for a parameter that must
+be given a value whenever the query is run, or as name=default.
+`,
+	Example: `newrelic nrql saved add errors-by-service --query "SELECT count(*) FROM TransactionError WHERE appName = '{{ .service }}' FACET error.class SINCE {{ .since }}" --param service --param since='1 day ago'
+newrelic nrql saved add checkout-throughput --query "SELECT rate(count(*), 1 minute) FROM Transaction WHERE appName = 'checkout' TIMESERIES" --accountId 12345678 --profile production`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		params, err := parseParamDefinitions(savedParams)
+		utils.LogIfFatal(err)
+
+		q := savedQuery{
+			Description: savedDescription,
+			Query:       query,
+			Params:      params,
+			AccountID:   savedAccountID,
+			Profile:     savedProfile,
+		}
+
+		utils.LogIfFatal(savedQueries.Add(args[0], q, savedForce))
+		log.Infof("saved query %s", args[0])
+	},
+}
+
+var cmdSavedList = &cobra.Command{
+	Use:   "list",
+	Short: "List saved NRQL queries",
+	Long: `List saved NRQL queries
+
+The list command shows each saved query along with its parameters, their default
+values, and the account and profile it is pinned to.
+`,
+	Example: `newrelic nrql saved list --format text`,
+	Run: func(cmd *cobra.Command, args []string) {
+		queries, err := savedQueries.Load()
+		utils.LogIfFatal(err)
+
+		utils.LogIfFatal(output.Print(summarizeSavedQueries(queries)))
+	},
+}
+
+var cmdSavedRun = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a saved NRQL query",
+	Long: `Run a saved NRQL query
+
+The run command fills in the saved query's parameters from the --param flag and
+their defaults, then executes it.  The query is run against the account given by
+the --accountId flag, or else the account it was saved with, or else the account of
+the profile in use.  Queries saved with a profile use that profile's credentials.
+`,
+	Example: `newrelic nrql saved run errors-by-service --param service=checkout`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		q, err := savedQueries.Get(name)
+		utils.LogIfFatal(err)
+
+		values, err := parseVariables(savedParams)
+		utils.LogIfFatal(err)
+
+		rendered, err := q.render(name, values)
+		utils.LogIfFatal(err)
+
+		client.WithClientAndNamedProfile(q.Profile, func(nrClient *newrelic.NewRelic, profile *credentials.Profile) {
+			runAccountID := savedAccountID
+			if runAccountID == 0 {
+				runAccountID = q.AccountID
+			}
+
+			if runAccountID == 0 && profile != nil {
+				runAccountID = profile.AccountID
+			}
+
+			if runAccountID == 0 {
+				utils.LogIfFatal(errors.New("no account ID available, use the --accountId flag"))
+			}
+
+			log.Debugf("running %s against account %d", rendered, runAccountID)
+
+			result, err := nrClient.Nrdb.QueryWithContext(utils.SignalCtx, runAccountID, nrdb.NRQL(rendered))
+			utils.LogIfFatal(err)
+
+			recordQuery(localHistory, runAccountID, rendered)
+
+			utils.LogIfFatal(output.Print(formatResults(result, output.CurrentFormat(), false, false)))
+		})
+	},
+}
+
+var cmdSavedDelete = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a saved NRQL query",
+	Long: `Delete a saved NRQL query
+
+The delete command removes the saved query with the given name.
+`,
+	Example: `newrelic nrql saved delete errors-by-service`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.LogIfFatal(savedQueries.Delete(args[0]))
+		log.Infof("deleted saved query %s", args[0])
+	},
+}
+
+func init() {
+	Command.AddCommand(cmdSaved)
+
+	cmdSaved.AddCommand(cmdSavedAdd)
+	cmdSavedAdd.Flags().StringVarP(&query, "query", "q", "", "the NRQL query to save")
+	utils.LogIfError(cmdSavedAdd.MarkFlagRequired("query"))
+	cmdSavedAdd.Flags().StringVarP(&savedDescription, "description", "d", "", "a description of the query")
+	cmdSavedAdd.Flags().StringArrayVar(&savedParams, "param", []string{}, "a parameter used by the query, as name or name=default, may be repeated")
+	cmdSavedAdd.Flags().IntVarP(&savedAccountID, "accountId", "a", 0, "the account the query should be run against")
+	cmdSavedAdd.Flags().StringVar(&savedProfile, "profile", "", "the profile the query should be run with")
+	cmdSavedAdd.Flags().BoolVar(&savedForce, "force", false, "replace an existing saved query with the same name")
+
+	cmdSaved.AddCommand(cmdSavedList)
+
+	cmdSaved.AddCommand(cmdSavedRun)
+	cmdSavedRun.Flags().StringArrayVar(&savedParams, "param", []string{}, "a parameter value, as name=value, may be repeated")
+	cmdSavedRun.Flags().IntVarP(&savedAccountID, "accountId", "a", 0, "the account to run the query against, instead of the one it was saved with")
+
+	cmdSaved.AddCommand(cmdSavedDelete)
+}
@@ -0,0 +1,46 @@
+// +build unit
+
+package nrql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestSaved(t *testing.T) {
+	assert.Equal(t, "saved", cmdSaved.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdSaved)
+	testcobra.CheckCobraRequiredFlags(t, cmdSaved, []string{})
+}
+
+func TestSavedAdd(t *testing.T) {
+	assert.Equal(t, "add", cmdSavedAdd.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdSavedAdd)
+	testcobra.CheckCobraRequiredFlags(t, cmdSavedAdd, []string{"query"})
+}
+
+func TestSavedList(t *testing.T) {
+	assert.Equal(t, "list", cmdSavedList.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdSavedList)
+	testcobra.CheckCobraRequiredFlags(t, cmdSavedList, []string{})
+}
+
+func TestSavedRun(t *testing.T) {
+	assert.Equal(t, "run", cmdSavedRun.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdSavedRun)
+	testcobra.CheckCobraRequiredFlags(t, cmdSavedRun, []string{})
+}
+
+func TestSavedDelete(t *testing.T) {
+	assert.Equal(t, "delete", cmdSavedDelete.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdSavedDelete)
+	testcobra.CheckCobraRequiredFlags(t, cmdSavedDelete, []string{})
+}
@@ -0,0 +1,53 @@
+package nrql
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/config"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+)
+
+var cmdShell = &cobra.Command{
+	Use:   "shell",
+	Short: "Start an interactive NRQL shell",
+	Long: `Start an interactive NRQL shell
+
+The shell command starts a line-editing NRQL prompt.  Queries may span several
+lines and are executed once terminated with a semicolon.  Event types and
+attribute names can be completed with the TAB key, and query history is kept
+between sessions.  Results are displayed as a table unless the --format flag is
+provided.  Type \help within the shell for a list of meta-commands.
+
+This command requires the --accountId <int> flag, which specifies the account to
+issue queries against.
+`,
+	Example: `newrelic nrql shell --accountId 12345678`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			// Results read best as a table unless a format was explicitly requested
+			format := output.FormatText
+			if f := cmd.Flag("format"); f != nil && f.Changed {
+				var err error
+				format, err = parseFormatStrict(f.Value.String())
+				utils.LogIfFatal(err)
+			}
+
+			s := newShell(&nrClient.Nrdb, accountID, format)
+			s.history = localHistory
+
+			if err := s.Run(config.DefaultConfigDirectory); err != nil {
+				log.Fatal(err)
+			}
+		})
+	},
+}
+
+func init() {
+	Command.AddCommand(cmdShell)
+	cmdShell.Flags().IntVarP(&accountID, "accountId", "a", 0, "the New Relic account ID where you want to query")
+	utils.LogIfError(cmdShell.MarkFlagRequired("accountId"))
+}
@@ -0,0 +1,263 @@
+package nrql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+var nrqlKeywords = []string{
+	"AGO", "AND", "AS", "ASC", "AUTO", "BY", "COMPARE", "DESC", "EXTRAPOLATE",
+	"FACET", "FALSE", "FROM", "IN", "IS", "LIKE", "LIMIT", "MAX", "NOT", "NULL",
+	"OFFSET", "OR", "ORDER", "RAW", "RLIKE", "SELECT", "SHOW", "SINCE", "SLIDE",
+	"TIMESERIES", "TIMEZONE", "TRUE", "UNTIL", "WHERE", "WITH",
+}
+
+var nrqlFunctions = []string{
+	"abs", "accountId", "apdex", "aparse", "average", "blob", "boolean",
+	"bucketPercentile", "buckets", "bytecountestimate", "capture",
+	"cardinality", "cdfPercentage", "ceil", "cidrAddress", "clamp_max",
+	"clamp_min", "concat", "convert", "count", "dateOf", "dayOfWeek", "decode",
+	"derivative", "dimensions", "earliest", "encode", "eventType", "exp",
+	"filter", "floor", "funnel", "getCdfCount", "getField", "histogram",
+	"hourOf", "if", "indexOf", "jparse", "keyset", "latest", "latestrate",
+	"length", "ln", "log", "log10", "log2", "lookup", "lower", "mapKeys",
+	"mapValues", "max", "median", "min", "minuteOf", "mod", "monthOf",
+	"numeric", "percentage", "percentile", "position", "pow", "predictLinear",
+	"quarterOf", "rate", "round", "sqrt", "stddev", "stdvar", "string",
+	"substring", "sum", "toDatetime", "tuple", "uniqueCount", "uniques",
+	"upper", "weekOf", "weekdayOf", "yearOf",
+}
+
+var fromClauseRegex = regexp.MustCompile("(?i)\\bFROM\\s+([\\w`.:]+(?:\\s*,\\s*[\\w`.:]+)*)")
+
+// nrqlCompleter provides tab completion of NRQL keywords, functions, event
+// types and attribute names.  Event types and attributes are looked up
+// lazily and cached per account.
+type nrqlCompleter struct {
+	client    nrdbClient
+	accountID func() int
+	pending   func() string
+
+	mu         sync.Mutex
+	eventTypes map[int][]string
+	attributes map[string][]string
+}
+
+func newNRQLCompleter(client nrdbClient, accountID func() int, pending func() string) *nrqlCompleter {
+	return &nrqlCompleter{
+		client:     client,
+		accountID:  accountID,
+		pending:    pending,
+		eventTypes: map[int][]string{},
+		attributes: map[string][]string{},
+	}
+}
+
+// Do implements readline.AutoCompleter.
+func (c *nrqlCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	start := strings.LastIndexAny(text, " \t(),=") + 1
+	prefix := text[start:]
+
+	statement := text[:start]
+	if c.pending != nil {
+		statement = c.pending() + " " + statement
+	}
+
+	var matches [][]rune
+	for _, candidate := range c.candidates(statement) {
+		if suffix, ok := completionSuffix(candidate, prefix); ok {
+			matches = append(matches, []rune(suffix))
+		}
+	}
+
+	return matches, len([]rune(prefix))
+}
+
+// candidates returns the completions that make sense following the given
+// partial statement.
+func (c *nrqlCompleter) candidates(statement string) []string {
+	if lastKeyword(statement) == "FROM" {
+		return c.getEventTypes()
+	}
+
+	candidates := append([]string{}, nrqlKeywords...)
+	candidates = append(candidates, nrqlFunctions...)
+
+	for _, eventType := range referencedEventTypes(statement) {
+		candidates = append(candidates, c.getAttributes(eventType)...)
+	}
+
+	return candidates
+}
+
+func (c *nrqlCompleter) getEventTypes() []string {
+	accountID := c.accountID()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if eventTypes, ok := c.eventTypes[accountID]; ok {
+		return eventTypes
+	}
+
+	result, err := c.client.QueryWithContext(context.Background(), accountID, "SHOW EVENT TYPES")
+	if err != nil {
+		log.Debugf("unable to fetch event types for completion: %s", err)
+		return nil
+	}
+
+	eventTypes := collectStrings(result.Results, "eventType")
+	c.eventTypes[accountID] = eventTypes
+
+	return eventTypes
+}
+
+func (c *nrqlCompleter) getAttributes(eventType string) []string {
+	accountID := c.accountID()
+	cacheKey := fmt.Sprintf("%d:%s", accountID, eventType)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if attributes, ok := c.attributes[cacheKey]; ok {
+		return attributes
+	}
+
+	query := nrdb.NRQL(fmt.Sprintf("SELECT keyset() FROM %s", eventType))
+
+	result, err := c.client.QueryWithContext(context.Background(), accountID, query)
+	if err != nil {
+		log.Debugf("unable to fetch attributes of %s for completion: %s", eventType, err)
+		return nil
+	}
+
+	attributes := collectStrings(result.Results, "key", "allKeys")
+	c.attributes[cacheKey] = attributes
+
+	return attributes
+}
+
+// referencedEventTypes returns the event types named in the FROM clause
+// of a partial statement.
+func referencedEventTypes(statement string) []string {
+	var eventTypes []string
+
+	for _, m := range fromClauseRegex.FindAllStringSubmatch(statement, -1) {
+		for _, t := range strings.Split(m[1], ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				eventTypes = append(eventTypes, t)
+			}
+		}
+	}
+
+	return eventTypes
+}
+
+// lastKeyword returns the last NRQL keyword found in a partial statement,
+// provided nothing but a comma separated list follows it.
+func lastKeyword(statement string) string {
+	fields := strings.Fields(statement)
+
+	for i := len(fields) - 1; i >= 0; i-- {
+		word := strings.ToUpper(fields[i])
+
+		if isKeyword(word) {
+			return word
+		}
+
+		// Only lists continue the previous clause
+		if i == len(fields)-1 && !strings.HasSuffix(fields[i], ",") {
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// collectStrings gathers the distinct string values stored under any of the
+// given keys, whether they are single values or lists of values.
+func collectStrings(results []nrdb.NRDBResult, keys ...string) []string {
+	seen := map[string]bool{}
+	values := []string{}
+
+	add := func(v interface{}) {
+		if s, ok := v.(string); ok && !seen[s] {
+			seen[s] = true
+			values = append(values, s)
+		}
+	}
+
+	for _, r := range results {
+		for _, k := range keys {
+			switch v := r[k].(type) {
+			case []interface{}:
+				for _, x := range v {
+					add(x)
+				}
+			default:
+				add(v)
+			}
+		}
+	}
+
+	sort.Strings(values)
+
+	return values
+}
+
+// completionSuffix returns the remainder of candidate to be inserted after
+// prefix.  Keywords and functions are matched case-insensitively.
+func completionSuffix(candidate string, prefix string) (string, bool) {
+	if len(prefix) > len(candidate) {
+		return "", false
+	}
+
+	terminator := " "
+	if isFunction(candidate) && !isKeyword(candidate) {
+		terminator = "("
+	}
+
+	if strings.HasPrefix(candidate, prefix) {
+		return candidate[len(prefix):] + terminator, true
+	}
+
+	if !isKeyword(candidate) && !isFunction(candidate) || !strings.EqualFold(candidate[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	suffix := candidate[len(prefix):]
+	if prefix != "" && prefix == strings.ToLower(prefix) {
+		suffix = strings.ToLower(suffix)
+	}
+
+	return suffix + terminator, true
+}
+
+func isKeyword(s string) bool {
+	for _, k := range nrqlKeywords {
+		if k == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isFunction(s string) bool {
+	for _, f := range nrqlFunctions {
+		if f == s {
+			return true
+		}
+	}
+
+	return false
+}
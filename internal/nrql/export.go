@@ -0,0 +1,443 @@
+package nrql
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+const (
+	defaultExportChunk       = "1h"
+	defaultExportRowLimit    = 5000
+	defaultExportConcurrency = 5
+	minExportWindow          = time.Second
+)
+
+// exportWindow is a half-open time range, [since, until).
+type exportWindow struct {
+	since time.Time
+	until time.Time
+}
+
+func (w exportWindow) String() string {
+	return fmt.Sprintf("%s to %s", w.since.UTC().Format(time.RFC3339), w.until.UTC().Format(time.RFC3339))
+}
+
+// exportRowWriter writes batches of exported rows as they arrive.
+type exportRowWriter interface {
+	Write(rows []nrdb.NRDBResult) error
+	Flush() error
+}
+
+// exporter runs a NRQL query over consecutive time windows, so that more rows
+// can be retrieved than a single query allows.
+type exporter struct {
+	client      nrdbClient
+	accountID   int
+	query       string
+	limit       int
+	concurrency int
+}
+
+type exportBatch struct {
+	rows []nrdb.NRDBResult
+	err  error
+}
+
+func newExporter(client nrdbClient, accountID int, query string, limit int, concurrency int) (*exporter, error) {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), shellStatementEnding))
+
+	if err := validateExportQuery(query); err != nil {
+		return nil, err
+	}
+
+	if limit < 1 {
+		return nil, errors.New("the row limit must be at least 1")
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &exporter{
+		client:      client,
+		accountID:   accountID,
+		query:       query,
+		limit:       limit,
+		concurrency: concurrency,
+	}, nil
+}
+
+// validateExportQuery ensures a query can have a time range and limit added
+// to it, and that it returns individual events rather than aggregates.
+func validateExportQuery(query string) error {
+	statements, errs := parseNRQL(query)
+	if len(errs) > 0 {
+		line, column := lineAndColumn(query, errs[0].pos)
+		return fmt.Errorf("invalid query at %d:%d: %s", line, column, errs[0].msg)
+	}
+
+	if len(statements) != 1 || statements[0].show {
+		return errors.New("export requires a single SELECT query")
+	}
+
+	for _, clause := range []string{"SINCE", "UNTIL", "LIMIT"} {
+		if _, ok := statements[0].clauses[clause]; ok {
+			return fmt.Errorf("remove the %s clause from the query, export adds its own time range and limit", clause)
+		}
+	}
+
+	for _, clause := range []string{"FACET", "TIMESERIES", "COMPARE WITH"} {
+		if _, ok := statements[0].clauses[clause]; ok {
+			return fmt.Errorf("export does not support %s, it is intended for queries that return events", clause)
+		}
+	}
+
+	return nil
+}
+
+// Run exports the rows between since and until, querying windows of the
+// given size concurrently.  Rows are written in window order, and events
+// returned by both windows at a boundary are written once.
+func (e *exporter) Run(ctx context.Context, since time.Time, until time.Time, chunk time.Duration, w exportRowWriter) error {
+	windows := splitWindows(since, until, chunk)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// A slot is held from the start of a window's queries until its rows are
+	// written, which bounds both the queries in flight and the rows buffered.
+	slots := make(chan struct{}, e.concurrency)
+	batches := make([]chan exportBatch, len(windows))
+	for i := range batches {
+		batches[i] = make(chan exportBatch, 1)
+	}
+
+	go func() {
+		for i, win := range windows {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(i int, win exportWindow) {
+				rows, err := e.fetch(ctx, win)
+				batches[i] <- exportBatch{rows: rows, err: err}
+			}(i, win)
+		}
+	}()
+
+	d := newExportDeduplicator()
+
+	for i := range windows {
+		var b exportBatch
+
+		select {
+		case b = <-batches[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if b.err != nil {
+			return fmt.Errorf("unable to export %s: %s", windows[i], b.err)
+		}
+
+		if err := w.Write(d.filter(windows[i], b.rows)); err != nil {
+			return err
+		}
+
+		<-slots
+	}
+
+	return w.Flush()
+}
+
+// fetch queries a single window, splitting it in half whenever the row limit
+// is reached so that no rows are lost.
+func (e *exporter) fetch(ctx context.Context, win exportWindow) ([]nrdb.NRDBResult, error) {
+	result, err := e.client.QueryWithContext(ctx, e.accountID, nrdb.NRQL(e.windowQuery(win)))
+	if err != nil {
+		return nil, err
+	}
+
+	size := win.until.Sub(win.since)
+
+	if len(result.Results) < e.limit {
+		log.Debugf("exported %d rows from %s", len(result.Results), win)
+		return result.Results, nil
+	}
+
+	if size <= minExportWindow {
+		log.Warnf("%s returned %d rows, the row limit, so some rows may be missing", win, len(result.Results))
+		return result.Results, nil
+	}
+
+	mid := win.since.Add(size / 2)
+	log.Debugf("%s reached the row limit, splitting at %s", win, mid.UTC().Format(time.RFC3339))
+
+	earlier, err := e.fetch(ctx, exportWindow{since: win.since, until: mid})
+	if err != nil {
+		return nil, err
+	}
+
+	later, err := e.fetch(ctx, exportWindow{since: mid, until: win.until})
+	if err != nil {
+		return nil, err
+	}
+
+	boundary := toMilliseconds(mid)
+	later = dropBoundaryDuplicates(later, boundary, exportBoundaryKeys(earlier, boundary))
+
+	return append(earlier, later...), nil
+}
+
+func (e *exporter) windowQuery(win exportWindow) string {
+	return fmt.Sprintf("%s SINCE %d UNTIL %d LIMIT %d", e.query, toMilliseconds(win.since), toMilliseconds(win.until), e.limit)
+}
+
+func toMilliseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// splitWindows divides [since, until) into consecutive windows of at most
+// chunk in length.
+func splitWindows(since time.Time, until time.Time, chunk time.Duration) []exportWindow {
+	var windows []exportWindow
+
+	for start := since; start.Before(until); start = start.Add(chunk) {
+		end := start.Add(chunk)
+		if end.After(until) {
+			end = until
+		}
+
+		windows = append(windows, exportWindow{since: start, until: end})
+	}
+
+	return windows
+}
+
+// exportEventIDAttributes are the attributes that identify an event, used
+// with its timestamp to recognise the same event returned by two windows.
+var exportEventIDAttributes = []string{"id", "guid", "messageId"}
+
+// exportBoundaryKey returns the key of a row that sits exactly on the
+// boundary, at ms, between two windows.  Rows elsewhere, and rows without an
+// event ID, have no key, as identical rows are not necessarily the same event.
+func exportBoundaryKey(r nrdb.NRDBResult, ms int64) (string, bool) {
+	ts, ok := r["timestamp"].(float64)
+	if !ok || int64(ts) != ms {
+		return "", false
+	}
+
+	for _, attr := range exportEventIDAttributes {
+		if id, ok := r[attr]; ok && id != nil {
+			return fmt.Sprintf("%d|%s=%v", ms, attr, id), true
+		}
+	}
+
+	return "", false
+}
+
+// exportBoundaryKeys returns the keys of the rows on the boundary at ms.
+func exportBoundaryKeys(rows []nrdb.NRDBResult, ms int64) map[string]bool {
+	keys := map[string]bool{}
+
+	for _, r := range rows {
+		if key, ok := exportBoundaryKey(r, ms); ok {
+			keys[key] = true
+		}
+	}
+
+	return keys
+}
+
+// dropBoundaryDuplicates drops the rows on the boundary at ms that were
+// already returned, with the keys given, by the window ending there.
+func dropBoundaryDuplicates(rows []nrdb.NRDBResult, ms int64, seen map[string]bool) []nrdb.NRDBResult {
+	if len(seen) == 0 {
+		return rows
+	}
+
+	unique := make([]nrdb.NRDBResult, 0, len(rows))
+
+	for _, r := range rows {
+		if key, ok := exportBoundaryKey(r, ms); ok && seen[key] {
+			continue
+		}
+
+		unique = append(unique, r)
+	}
+
+	return unique
+}
+
+// exportDeduplicator drops the events returned both at the end of a window
+// and at the start of the next, should a boundary be included in both.
+type exportDeduplicator struct {
+	boundary int64
+	previous map[string]bool
+}
+
+func newExportDeduplicator() *exportDeduplicator {
+	return &exportDeduplicator{}
+}
+
+func (d *exportDeduplicator) filter(win exportWindow, rows []nrdb.NRDBResult) []nrdb.NRDBResult {
+	if toMilliseconds(win.since) == d.boundary {
+		rows = dropBoundaryDuplicates(rows, d.boundary, d.previous)
+	}
+
+	d.boundary = toMilliseconds(win.until)
+	d.previous = exportBoundaryKeys(rows, d.boundary)
+
+	return rows
+}
+
+// ndjsonRowWriter writes each row as a line of JSON.
+type ndjsonRowWriter struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONRowWriter(w io.Writer) *ndjsonRowWriter {
+	return &ndjsonRowWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *ndjsonRowWriter) Write(rows []nrdb.NRDBResult) error {
+	for _, r := range rows {
+		if err := w.encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *ndjsonRowWriter) Flush() error {
+	return nil
+}
+
+// csvRowWriter writes rows as CSV.  The header is taken from the first
+// non-empty batch, as rows are streamed before the full set of attributes is
+// known.  Attributes that first appear in later batches are dropped.
+type csvRowWriter struct {
+	writer  *csv.Writer
+	columns []string
+	known   map[string]bool
+	dropped map[string]bool
+}
+
+func newCSVRowWriter(w io.Writer) *csvRowWriter {
+	return &csvRowWriter{
+		writer:  csv.NewWriter(w),
+		dropped: map[string]bool{},
+	}
+}
+
+func (w *csvRowWriter) Write(rows []nrdb.NRDBResult) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if w.columns == nil {
+		w.known = map[string]bool{}
+		for _, r := range rows {
+			for k := range r {
+				if !w.known[k] {
+					w.known[k] = true
+					w.columns = append(w.columns, k)
+				}
+			}
+		}
+
+		sort.Strings(w.columns)
+
+		if err := w.writer.Write(w.columns); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range rows {
+		for k := range r {
+			if !w.known[k] && !w.dropped[k] {
+				w.dropped[k] = true
+				log.Warnf("attribute %s was not present in the first rows exported and will be omitted from the CSV", k)
+			}
+		}
+
+		record := make([]string, len(w.columns))
+		for i, col := range w.columns {
+			record[i] = output.CSVValue(r[col])
+		}
+
+		if err := w.writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.writer.Flush()
+
+	return w.writer.Error()
+}
+
+func (w *csvRowWriter) Flush() error {
+	w.writer.Flush()
+
+	return w.writer.Error()
+}
+
+// parseExportDuration parses a duration, additionally accepting whole days
+// and weeks such as 7d or 2w.
+func parseExportDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("a duration is required")
+	}
+
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+
+	if unit, ok := units[strings.ToLower(s[len(s)-1:])]; ok && len(s) > 1 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+
+		return time.Duration(n) * unit, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+
+	return d, nil
+}
+
+// parseExportTime parses either an RFC3339 timestamp or a duration before now.
+func parseExportTime(s string, now time.Time) (time.Time, error) {
+	if s == "" || strings.EqualFold(s, "now") {
+		return now, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	d, err := parseExportDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s is neither a duration such as 7d nor an RFC3339 timestamp", s)
+	}
+
+	return now.Add(-d), nil
+}
@@ -0,0 +1,221 @@
+// +build unit
+
+package nrql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+var windowRegex = regexp.MustCompile(`SINCE (\d+) UNTIL (\d+) LIMIT (\d+)`)
+
+// eventsPerMinute returns a mock result function with one event per minute,
+// honoring the time range and limit of each query.
+func eventsPerMinute(t *testing.T) func(int, nrdb.NRQL) []nrdb.NRDBResult {
+	return func(accountID int, nrql nrdb.NRQL) []nrdb.NRDBResult {
+		m := windowRegex.FindStringSubmatch(string(nrql))
+		require.NotNil(t, m, nrql)
+
+		since, _ := strconv.ParseInt(m[1], 10, 64)
+		until, _ := strconv.ParseInt(m[2], 10, 64)
+		limit, _ := strconv.Atoi(m[3])
+
+		results := []nrdb.NRDBResult{}
+		minute := int64(time.Minute / time.Millisecond)
+
+		for ts := (since + minute - 1) / minute * minute; ts < until && len(results) < limit; ts += minute {
+			results = append(results, nrdb.NRDBResult{"timestamp": float64(ts)})
+		}
+
+		return results
+	}
+}
+
+type captureRowWriter struct {
+	rows    []nrdb.NRDBResult
+	flushed bool
+}
+
+func (w *captureRowWriter) Write(rows []nrdb.NRDBResult) error {
+	w.rows = append(w.rows, rows...)
+	return nil
+}
+
+func (w *captureRowWriter) Flush() error {
+	w.flushed = true
+	return nil
+}
+
+func TestSplitWindows(t *testing.T) {
+	since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	windows := splitWindows(since, since.Add(150*time.Minute), time.Hour)
+	require.Len(t, windows, 3)
+
+	assert.Equal(t, since, windows[0].since)
+	assert.Equal(t, since.Add(time.Hour), windows[0].until)
+	assert.Equal(t, since.Add(2*time.Hour), windows[2].since)
+	assert.Equal(t, since.Add(150*time.Minute), windows[2].until)
+}
+
+func TestExporterRun(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ReturnResults(eventsPerMinute(t))
+
+	e, err := newExporter(c, 1, "SELECT * FROM Transaction;", 1000, 3)
+	require.NoError(t, err)
+
+	since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := &captureRowWriter{}
+
+	require.NoError(t, e.Run(context.Background(), since, since.Add(5*time.Hour), time.Hour, w))
+
+	require.Len(t, w.rows, 300)
+	assert.True(t, w.flushed)
+	assert.Len(t, c.Queries(), 5)
+
+	// Rows are written in window order
+	for i := 1; i < len(w.rows); i++ {
+		assert.Less(t, w.rows[i-1]["timestamp"].(float64), w.rows[i]["timestamp"].(float64))
+	}
+
+	assert.True(t, strings.HasPrefix(string(c.Queries()[0]), "SELECT * FROM Transaction SINCE "))
+}
+
+func TestExporterSplitsFullWindows(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ReturnResults(eventsPerMinute(t))
+
+	e, err := newExporter(c, 1, "SELECT * FROM Log", 25, 2)
+	require.NoError(t, err)
+
+	since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := &captureRowWriter{}
+
+	require.NoError(t, e.Run(context.Background(), since, since.Add(2*time.Hour), time.Hour, w))
+
+	assert.Len(t, w.rows, 120)
+	assert.Greater(t, len(c.Queries()), 2)
+}
+
+func TestExporterErrors(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ThrowError("query failed")
+
+	e, err := newExporter(c, 1, "SELECT * FROM Log", 100, 2)
+	require.NoError(t, err)
+
+	since := time.Now().Add(-3 * time.Hour)
+	err = e.Run(context.Background(), since, since.Add(3*time.Hour), time.Hour, &captureRowWriter{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "query failed")
+}
+
+func TestValidateExportQuery(t *testing.T) {
+	assert.NoError(t, validateExportQuery("SELECT * FROM Log WHERE level = 'error'"))
+	assert.Error(t, validateExportQuery("SELECT * FROM Log SINCE 1 day ago"))
+	assert.Error(t, validateExportQuery("SELECT * FROM Log LIMIT 10"))
+	assert.Error(t, validateExportQuery("SELECT count(*) FROM Log FACET level"))
+	assert.Error(t, validateExportQuery("SELECT * FROM"))
+	assert.Error(t, validateExportQuery("SHOW EVENT TYPES"))
+}
+
+func TestExportDeduplicator(t *testing.T) {
+	since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := exportWindow{since: since, until: since.Add(time.Hour)}
+	second := exportWindow{since: first.until, until: first.until.Add(time.Hour)}
+	boundary := float64(toMilliseconds(first.until))
+
+	d := newExportDeduplicator()
+
+	// Identical rows are separate events and are kept
+	rows := d.filter(first, []nrdb.NRDBResult{
+		{"appName": "a", "duration": 1.0},
+		{"appName": "a", "duration": 1.0},
+		{"timestamp": boundary, "id": "x"},
+		{"timestamp": boundary, "appName": "a"},
+	})
+	assert.Len(t, rows, 4)
+
+	// Only events on the boundary with the same ID are dropped
+	rows = d.filter(second, []nrdb.NRDBResult{
+		{"timestamp": boundary, "id": "x"},
+		{"timestamp": boundary, "id": "y"},
+		{"timestamp": boundary, "appName": "a"},
+		{"timestamp": boundary + 1, "id": "x"},
+	})
+	assert.Equal(t, []nrdb.NRDBResult{
+		{"timestamp": boundary, "id": "y"},
+		{"timestamp": boundary, "appName": "a"},
+		{"timestamp": boundary + 1, "id": "x"},
+	}, rows)
+}
+
+func TestNDJSONRowWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newNDJSONRowWriter(&buf)
+
+	require.NoError(t, w.Write([]nrdb.NRDBResult{{"a": 1}, {"b": "x"}}))
+	require.NoError(t, w.Flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, "x", row["b"])
+}
+
+func TestCSVRowWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newCSVRowWriter(&buf)
+
+	require.NoError(t, w.Write([]nrdb.NRDBResult{}))
+	require.NoError(t, w.Write([]nrdb.NRDBResult{{"timestamp": float64(1609459200000), "message": "hi"}}))
+	require.NoError(t, w.Write([]nrdb.NRDBResult{{"message": "there", "extra": true}}))
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, "message,timestamp\nhi,1609459200000\nthere,\n", buf.String())
+}
+
+func TestParseExportTime(t *testing.T) {
+	now := time.Date(2021, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	since, err := parseExportTime("7d", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), since)
+
+	since, err = parseExportTime("90m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-90*time.Minute), since)
+
+	since, err = parseExportTime("2021-01-02T03:04:05Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), since)
+
+	until, err := parseExportTime("now", now)
+	require.NoError(t, err)
+	assert.Equal(t, now, until)
+
+	_, err = parseExportTime("yesterday", now)
+	assert.Error(t, err)
+
+	d, err := parseExportDuration("2w")
+	require.NoError(t, err)
+	assert.Equal(t, 14*24*time.Hour, d)
+
+	_, err = parseExportDuration("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,296 @@
+package nrql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+const (
+	beginTimeKey = "beginTimeSeconds"
+	endTimeKey   = "endTimeSeconds"
+	facetKey     = "facet"
+
+	timestampColumn    = "timestamp"
+	endTimestampColumn = "endTimestamp"
+	comparisonColumn   = "comparison"
+)
+
+// resultTable is a flattened NRQL result.  The first Keys columns identify a
+// row (timestamps, comparison window and facet values), while the remaining
+// columns hold the aggregate values.  The last Facets of the key columns are
+// facet values.
+type resultTable struct {
+	output.Table
+	Keys   int
+	Facets int
+}
+
+// flattenResults normalizes faceted, timeseries and COMPARE WITH results into
+// flat rows with explicit columns.  Nested aggregate values, such as those
+// returned by percentile(), become one column per nested key.
+func flattenResults(result *nrdb.NRDBResultContainer) resultTable {
+	type section struct {
+		comparison string
+		results    []nrdb.NRDBResult
+	}
+
+	sections := []section{{results: result.Results}}
+
+	if len(result.CurrentResults) > 0 || len(result.PreviousResults) > 0 {
+		sections = []section{
+			{comparison: "current", results: result.CurrentResults},
+			{comparison: "previous", results: result.PreviousResults},
+		}
+	}
+
+	facets := facetColumns(result)
+	excluded := map[string]bool{beginTimeKey: true, endTimeKey: true, facetKey: true}
+	for _, f := range facets {
+		excluded[f] = true
+	}
+
+	hasTimeseries := false
+	aggregateSet := map[string]bool{}
+	flattened := make([][]map[string]interface{}, len(sections))
+
+	for s, sec := range sections {
+		flattened[s] = make([]map[string]interface{}, len(sec.results))
+
+		for i, r := range sec.results {
+			if _, ok := r[beginTimeKey]; ok {
+				hasTimeseries = true
+			}
+
+			values := map[string]interface{}{}
+			for k, v := range r {
+				if !excluded[k] {
+					flattenValue(k, v, values)
+				}
+			}
+
+			for k := range values {
+				aggregateSet[k] = true
+			}
+
+			flattened[s][i] = values
+		}
+	}
+
+	aggregates := make([]string, 0, len(aggregateSet))
+	for k := range aggregateSet {
+		aggregates = append(aggregates, k)
+	}
+	sort.Strings(aggregates)
+
+	var columns []string
+	if hasTimeseries {
+		columns = append(columns, timestampColumn, endTimestampColumn)
+	}
+	if len(sections) > 1 {
+		columns = append(columns, comparisonColumn)
+	}
+	columns = append(columns, facets...)
+
+	keys := len(columns)
+	columns = append(columns, aggregates...)
+
+	var rows [][]interface{}
+
+	for s, sec := range sections {
+		for i, r := range sec.results {
+			row := make([]interface{}, 0, len(columns))
+
+			if hasTimeseries {
+				row = append(row, formatTimestamp(r[beginTimeKey]), formatTimestamp(r[endTimeKey]))
+			}
+
+			if len(sections) > 1 {
+				row = append(row, sec.comparison)
+			}
+
+			for f, name := range facets {
+				row = append(row, facetValue(r, name, f))
+			}
+
+			for _, a := range aggregates {
+				row = append(row, flattened[s][i][a])
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	return resultTable{
+		Table:  output.Table{Columns: columns, Rows: rows},
+		Keys:   keys,
+		Facets: len(facets),
+	}
+}
+
+// formatResults prepares query results for printing in the given format.
+// Text and CSV output are always flattened into rows, as are JSON and YAML
+// output when flatten is set.  Pivoting implies flattening.
+func formatResults(result *nrdb.NRDBResultContainer, format output.Format, flatten bool, pivot bool) interface{} {
+	if !flatten && !pivot && format != output.FormatText && format != output.FormatCSV {
+		return result.Results
+	}
+
+	t := flattenResults(result)
+	if pivot {
+		t = pivotFacets(t)
+	}
+
+	return t.Table
+}
+
+// facetColumns returns the names of the facet columns, preferring the facet
+// attribute names reported in the result metadata.
+func facetColumns(result *nrdb.NRDBResultContainer) []string {
+	if len(result.Metadata.Facets) > 0 {
+		return result.Metadata.Facets
+	}
+
+	all := append(append(append([]nrdb.NRDBResult{}, result.Results...), result.CurrentResults...), result.PreviousResults...)
+
+	for _, r := range all {
+		switch f := r[facetKey].(type) {
+		case nil:
+			continue
+		case []interface{}:
+			columns := make([]string, len(f))
+			for i := range f {
+				columns[i] = fmt.Sprintf("%s.%d", facetKey, i)
+			}
+			return columns
+		default:
+			return []string{facetKey}
+		}
+	}
+
+	return nil
+}
+
+// facetValue returns the value of the named facet for a result, falling back
+// to its position within the facet list.
+func facetValue(r nrdb.NRDBResult, name string, index int) interface{} {
+	if v, ok := r[name]; ok && name != facetKey {
+		return v
+	}
+
+	switch f := r[facetKey].(type) {
+	case []interface{}:
+		if index < len(f) {
+			return f[index]
+		}
+		return nil
+	default:
+		return f
+	}
+}
+
+// flattenValue stores v under key, expanding nested maps into dotted keys.
+func flattenValue(key string, v interface{}, into map[string]interface{}) {
+	if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+		for k, nested := range m {
+			flattenValue(key+"."+k, nested, into)
+		}
+		return
+	}
+
+	into[key] = v
+}
+
+func formatTimestamp(v interface{}) interface{} {
+	var seconds int64
+
+	switch t := v.(type) {
+	case float64:
+		seconds = int64(t)
+	case int:
+		seconds = int64(t)
+	case int64:
+		seconds = t
+	default:
+		return v
+	}
+
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+// pivotFacets turns each distinct combination of facet values into its own set
+// of columns, leaving one row per timestamp and comparison window.
+func pivotFacets(t resultTable) resultTable {
+	if t.Facets == 0 {
+		return t
+	}
+
+	indexCount := t.Keys - t.Facets
+	aggregates := t.Columns[t.Keys:]
+
+	var (
+		columns     = append([]string{}, t.Columns[:indexCount]...)
+		columnIndex = map[string]int{}
+		rowIndex    = map[string]int{}
+		rows        [][]interface{}
+	)
+
+	for _, r := range t.Rows {
+		key := fmt.Sprintf("%v", r[:indexCount])
+
+		i, ok := rowIndex[key]
+		if !ok {
+			i = len(rows)
+			rowIndex[key] = i
+			rows = append(rows, append([]interface{}{}, r[:indexCount]...))
+		}
+
+		for a, name := range aggregates {
+			col := pivotColumn(r[indexCount:t.Keys], name, len(aggregates) > 1)
+
+			c, ok := columnIndex[col]
+			if !ok {
+				c = len(columns)
+				columnIndex[col] = c
+				columns = append(columns, col)
+			}
+
+			for len(rows[i]) <= c {
+				rows[i] = append(rows[i], nil)
+			}
+
+			rows[i][c] = r[t.Keys+a]
+		}
+	}
+
+	for i := range rows {
+		for len(rows[i]) < len(columns) {
+			rows[i] = append(rows[i], nil)
+		}
+	}
+
+	return resultTable{
+		Table: output.Table{Columns: columns, Rows: rows},
+		Keys:  indexCount,
+	}
+}
+
+// pivotColumn names the column holding an aggregate for a combination of
+// facet values.  The aggregate name is only included when there are several.
+func pivotColumn(facetValues []interface{}, aggregate string, multipleAggregates bool) string {
+	labels := make([]string, len(facetValues))
+	for i, v := range facetValues {
+		labels[i] = fmt.Sprint(v)
+	}
+
+	col := strings.Join(labels, ", ")
+	if multipleAggregates {
+		col += ": " + aggregate
+	}
+
+	return col
+}
@@ -0,0 +1,137 @@
+// +build unit
+
+package nrql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+func TestFlattenResultsSimple(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		Results: []nrdb.NRDBResult{{"count": float64(42)}},
+	}
+
+	table := flattenResults(result)
+
+	assert.Equal(t, []string{"count"}, table.Columns)
+	assert.Equal(t, [][]interface{}{{float64(42)}}, table.Rows)
+	assert.Equal(t, 0, table.Keys)
+}
+
+func TestFlattenResultsFacet(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		Results: []nrdb.NRDBResult{
+			{"facet": "WebPortal", "appName": "WebPortal", "count": float64(10)},
+			{"facet": "Billing", "appName": "Billing", "count": float64(5)},
+		},
+		Metadata: nrdb.NRDBMetadata{Facets: []string{"appName"}},
+	}
+
+	table := flattenResults(result)
+
+	assert.Equal(t, []string{"appName", "count"}, table.Columns)
+	assert.Equal(t, [][]interface{}{{"WebPortal", float64(10)}, {"Billing", float64(5)}}, table.Rows)
+	assert.Equal(t, 1, table.Keys)
+	assert.Equal(t, 1, table.Facets)
+}
+
+func TestFlattenResultsMultipleFacets(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		Results: []nrdb.NRDBResult{
+			{"facet": []interface{}{"WebPortal", "host-1"}, "count": float64(10)},
+		},
+	}
+
+	table := flattenResults(result)
+
+	assert.Equal(t, []string{"facet.0", "facet.1", "count"}, table.Columns)
+	assert.Equal(t, [][]interface{}{{"WebPortal", "host-1", float64(10)}}, table.Rows)
+}
+
+func TestFlattenResultsTimeseries(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		Results: []nrdb.NRDBResult{
+			{"beginTimeSeconds": float64(1600000000), "endTimeSeconds": float64(1600000060), "count": float64(3)},
+		},
+	}
+
+	table := flattenResults(result)
+
+	assert.Equal(t, []string{"timestamp", "endTimestamp", "count"}, table.Columns)
+	assert.Equal(t, [][]interface{}{{"2020-09-13T12:26:40Z", "2020-09-13T12:27:40Z", float64(3)}}, table.Rows)
+	assert.Equal(t, 2, table.Keys)
+}
+
+func TestFlattenResultsCompareWith(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		CurrentResults:  []nrdb.NRDBResult{{"count": float64(8)}},
+		PreviousResults: []nrdb.NRDBResult{{"count": float64(6)}},
+	}
+
+	table := flattenResults(result)
+
+	assert.Equal(t, []string{"comparison", "count"}, table.Columns)
+	assert.Equal(t, [][]interface{}{{"current", float64(8)}, {"previous", float64(6)}}, table.Rows)
+}
+
+func TestFlattenResultsNestedValues(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		Results: []nrdb.NRDBResult{
+			{"percentile.duration": map[string]interface{}{"50": 0.1, "99": 1.5}},
+		},
+	}
+
+	table := flattenResults(result)
+
+	assert.Equal(t, []string{"percentile.duration.50", "percentile.duration.99"}, table.Columns)
+	assert.Equal(t, [][]interface{}{{0.1, 1.5}}, table.Rows)
+}
+
+func TestPivotFacets(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		Results: []nrdb.NRDBResult{
+			{"beginTimeSeconds": float64(0), "endTimeSeconds": float64(60), "facet": "a", "count": float64(1)},
+			{"beginTimeSeconds": float64(0), "endTimeSeconds": float64(60), "facet": "b", "count": float64(2)},
+			{"beginTimeSeconds": float64(60), "endTimeSeconds": float64(120), "facet": "a", "count": float64(3)},
+		},
+	}
+
+	table := pivotFacets(flattenResults(result))
+
+	assert.Equal(t, []string{"timestamp", "endTimestamp", "a", "b"}, table.Columns)
+	assert.Equal(t, [][]interface{}{
+		{"1970-01-01T00:00:00Z", "1970-01-01T00:01:00Z", float64(1), float64(2)},
+		{"1970-01-01T00:01:00Z", "1970-01-01T00:02:00Z", float64(3), nil},
+	}, table.Rows)
+	assert.Equal(t, 2, table.Keys)
+}
+
+func TestPivotFacetsMultipleAggregates(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		Results: []nrdb.NRDBResult{
+			{"facet": "a", "count": float64(1), "max": float64(5)},
+		},
+	}
+
+	table := pivotFacets(flattenResults(result))
+
+	assert.Equal(t, []string{"a: count", "a: max"}, table.Columns)
+	assert.Equal(t, [][]interface{}{{float64(1), float64(5)}}, table.Rows)
+}
+
+func TestFormatResults(t *testing.T) {
+	result := &nrdb.NRDBResultContainer{
+		Results: []nrdb.NRDBResult{{"facet": "a", "count": float64(1)}},
+	}
+
+	assert.Equal(t, result.Results, formatResults(result, output.FormatJSON, false, false))
+	assert.IsType(t, output.Table{}, formatResults(result, output.FormatJSON, true, false))
+	assert.IsType(t, output.Table{}, formatResults(result, output.FormatText, false, false))
+	assert.IsType(t, output.Table{}, formatResults(result, output.FormatCSV, false, false))
+	assert.Equal(t, []string{"a"}, formatResults(result, output.FormatYAML, false, true).(output.Table).Columns)
+}
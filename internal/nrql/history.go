@@ -0,0 +1,228 @@
+package nrql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-cli/internal/config"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+	"github.com/newrelic/newrelic-client-go/pkg/nrtime"
+)
+
+const (
+	queryHistoryFile = "nrql_history.json"
+	maxQueryHistory  = 1000
+
+	historySourceAll    = "all"
+	historySourceLocal  = "local"
+	historySourceRemote = "remote"
+
+	// Queries run through the CLI also appear in the remote history, with a
+	// timestamp that may differ slightly from the one recorded locally.
+	historyDuplicateWindow = time.Minute
+)
+
+var historySources = []string{historySourceAll, historySourceLocal, historySourceRemote}
+
+// localHistory records the queries run through the CLI.
+var localHistory = newHistoryStore(config.DefaultConfigDirectory)
+
+// historyEntry is a previously executed query.  The index is the position of
+// the query in the complete history, newest first, and is used to re-run it.
+type historyEntry struct {
+	Index     int       `json:"index" yaml:"index"`
+	AccountID int       `json:"accountId" yaml:"accountId"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	Source    string    `json:"source" yaml:"source"`
+	NRQL      string    `json:"nrql" yaml:"nrql"`
+}
+
+// historyClient retrieves the NRQL query history stored by New Relic.
+type historyClient interface {
+	QueryHistory() (*[]nrdb.NRQLHistoricalQuery, error)
+}
+
+// historyStore is a file of recently executed queries, newest last.
+type historyStore struct {
+	path string
+}
+
+func newHistoryStore(dir string) *historyStore {
+	return &historyStore{
+		path: filepath.Join(dir, queryHistoryFile),
+	}
+}
+
+// Load returns the stored queries, or none if nothing has been recorded yet.
+func (h *historyStore) Load() ([]nrdb.NRQLHistoricalQuery, error) {
+	content, err := ioutil.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return []nrdb.NRQLHistoricalQuery{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var queries []nrdb.NRQLHistoricalQuery
+	if err := json.Unmarshal(content, &queries); err != nil {
+		return nil, fmt.Errorf("unable to read query history %s: %s", h.path, err)
+	}
+
+	return queries, nil
+}
+
+// Record appends a query to the store, discarding the oldest queries once
+// the store is full.
+func (h *historyStore) Record(accountID int, query string, at time.Time) error {
+	queries, err := h.Load()
+	if err != nil {
+		return err
+	}
+
+	queries = append(queries, nrdb.NRQLHistoricalQuery{
+		AccountID: accountID,
+		NRQL:      nrdb.NRQL(strings.TrimSpace(query)),
+		Timestamp: nrtime.EpochSeconds(at),
+	})
+
+	if len(queries) > maxQueryHistory {
+		queries = queries[len(queries)-maxQueryHistory:]
+	}
+
+	content, err := json.MarshalIndent(queries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(h.path, content, 0600)
+}
+
+// recordQuery adds a query to the local history.  Failing to record history
+// never prevents a query from running, so errors are only logged.
+func recordQuery(store *historyStore, accountID int, query string) {
+	if store == nil {
+		return
+	}
+
+	if err := store.Record(accountID, query, time.Now()); err != nil {
+		log.Debugf("unable to record query history: %s", err)
+	}
+}
+
+// loadHistory combines the local and remote history according to source,
+// newest first, with each entry numbered by its position.
+func loadHistory(store *historyStore, client historyClient, source string) ([]historyEntry, error) {
+	var local, remote []nrdb.NRQLHistoricalQuery
+
+	if source != historySourceRemote {
+		var err error
+		if local, err = store.Load(); err != nil {
+			return nil, err
+		}
+	}
+
+	if source != historySourceLocal {
+		result, err := client.QueryHistory()
+		if err != nil {
+			return nil, err
+		}
+
+		if result != nil {
+			remote = *result
+		}
+	}
+
+	return mergeHistory(local, remote), nil
+}
+
+// mergeHistory sorts local and remote queries newest first.  Remote queries
+// that duplicate a local query are dropped.
+func mergeHistory(local []nrdb.NRQLHistoricalQuery, remote []nrdb.NRQLHistoricalQuery) []historyEntry {
+	entries := make([]historyEntry, 0, len(local)+len(remote))
+
+	for _, q := range local {
+		entries = append(entries, newHistoryEntry(q, historySourceLocal))
+	}
+
+	for _, q := range remote {
+		e := newHistoryEntry(q, historySourceRemote)
+		if !isDuplicateHistoryEntry(entries[:len(local)], e) {
+			entries = append(entries, e)
+		}
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].Timestamp.After(entries[b].Timestamp)
+	})
+
+	for i := range entries {
+		entries[i].Index = i + 1
+	}
+
+	return entries
+}
+
+func newHistoryEntry(q nrdb.NRQLHistoricalQuery, source string) historyEntry {
+	return historyEntry{
+		AccountID: q.AccountID,
+		Timestamp: time.Time(q.Timestamp),
+		Source:    source,
+		NRQL:      strings.TrimSpace(string(q.NRQL)),
+	}
+}
+
+func isDuplicateHistoryEntry(entries []historyEntry, e historyEntry) bool {
+	for _, existing := range entries {
+		if existing.AccountID != e.AccountID || existing.NRQL != e.NRQL {
+			continue
+		}
+
+		delta := existing.Timestamp.Sub(e.Timestamp)
+		if delta < historyDuplicateWindow && delta > -historyDuplicateWindow {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterHistory returns the entries whose query matches pattern, if given,
+// limited to at most limit entries.
+func filterHistory(entries []historyEntry, pattern *regexp.Regexp, limit int) []historyEntry {
+	filtered := []historyEntry{}
+
+	for _, e := range entries {
+		if limit > 0 && len(filtered) >= limit {
+			break
+		}
+
+		if pattern == nil || pattern.MatchString(e.NRQL) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// findHistoryEntry returns the entry with the given index.
+func findHistoryEntry(entries []historyEntry, index int) (historyEntry, error) {
+	if index < 1 || index > len(entries) {
+		return historyEntry{}, fmt.Errorf("no query found at index %d, the history contains %d queries", index, len(entries))
+	}
+
+	return entries[index-1], nil
+}
@@ -0,0 +1,163 @@
+// +build unit
+
+package nrql
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+	"github.com/newrelic/newrelic-client-go/pkg/nrtime"
+)
+
+type mockHistoryClient struct {
+	queries []nrdb.NRQLHistoricalQuery
+	err     error
+}
+
+func (c *mockHistoryClient) QueryHistory() (*[]nrdb.NRQLHistoricalQuery, error) {
+	return &c.queries, c.err
+}
+
+func newTestHistoryStore(t *testing.T) (*historyStore, func()) {
+	dir, err := ioutil.TempDir("", "nrql-history")
+	require.NoError(t, err)
+
+	return newHistoryStore(dir), func() { os.RemoveAll(dir) }
+}
+
+func historicalQuery(accountID int, query string, at time.Time) nrdb.NRQLHistoricalQuery {
+	return nrdb.NRQLHistoricalQuery{
+		AccountID: accountID,
+		NRQL:      nrdb.NRQL(query),
+		Timestamp: nrtime.EpochSeconds(at),
+	}
+}
+
+func TestHistoryStore(t *testing.T) {
+	store, cleanup := newTestHistoryStore(t)
+	defer cleanup()
+
+	queries, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, queries)
+
+	at := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Record(1, " SELECT count(*) FROM Transaction ", at))
+	require.NoError(t, store.Record(2, "SELECT count(*) FROM Log", at.Add(time.Minute)))
+
+	queries, err = store.Load()
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+
+	assert.Equal(t, 1, queries[0].AccountID)
+	assert.Equal(t, nrdb.NRQL("SELECT count(*) FROM Transaction"), queries[0].NRQL)
+	assert.True(t, at.Equal(time.Time(queries[0].Timestamp)))
+	assert.Equal(t, 2, queries[1].AccountID)
+}
+
+func TestHistoryStoreLimit(t *testing.T) {
+	store, cleanup := newTestHistoryStore(t)
+	defer cleanup()
+
+	for i := 0; i < maxQueryHistory+5; i++ {
+		require.NoError(t, store.Record(i, "SELECT 1 FROM Transaction", time.Now()))
+	}
+
+	queries, err := store.Load()
+	require.NoError(t, err)
+	require.Len(t, queries, maxQueryHistory)
+	assert.Equal(t, 5, queries[0].AccountID)
+}
+
+func TestMergeHistory(t *testing.T) {
+	at := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	local := []nrdb.NRQLHistoricalQuery{
+		historicalQuery(1, "SELECT count(*) FROM Transaction", at),
+		historicalQuery(1, "SELECT count(*) FROM Log", at.Add(2*time.Hour)),
+	}
+
+	remote := []nrdb.NRQLHistoricalQuery{
+		// The same query recorded by New Relic a few seconds later
+		historicalQuery(1, "SELECT count(*) FROM Transaction", at.Add(5*time.Second)),
+		historicalQuery(2, "SELECT count(*) FROM PageView", at.Add(time.Hour)),
+	}
+
+	entries := mergeHistory(local, remote)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, historyEntry{Index: 1, AccountID: 1, Timestamp: at.Add(2 * time.Hour), Source: historySourceLocal, NRQL: "SELECT count(*) FROM Log"}, entries[0])
+	assert.Equal(t, historyEntry{Index: 2, AccountID: 2, Timestamp: at.Add(time.Hour), Source: historySourceRemote, NRQL: "SELECT count(*) FROM PageView"}, entries[1])
+	assert.Equal(t, historySourceLocal, entries[2].Source)
+	assert.Equal(t, 3, entries[2].Index)
+}
+
+func TestLoadHistory(t *testing.T) {
+	store, cleanup := newTestHistoryStore(t)
+	defer cleanup()
+
+	at := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Record(1, "SELECT count(*) FROM Log", at))
+
+	c := &mockHistoryClient{queries: []nrdb.NRQLHistoricalQuery{historicalQuery(2, "SELECT count(*) FROM PageView", at)}}
+
+	entries, err := loadHistory(store, c, historySourceAll)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, err = loadHistory(store, nil, historySourceLocal)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, historySourceLocal, entries[0].Source)
+
+	entries, err = loadHistory(store, c, historySourceRemote)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, historySourceRemote, entries[0].Source)
+
+	_, err = loadHistory(store, &mockHistoryClient{err: errors.New("unavailable")}, historySourceAll)
+	assert.Error(t, err)
+}
+
+func TestFilterHistory(t *testing.T) {
+	entries := []historyEntry{
+		{Index: 1, NRQL: "SELECT count(*) FROM Transaction"},
+		{Index: 2, NRQL: "SELECT count(*) FROM Log"},
+		{Index: 3, NRQL: "SELECT average(duration) FROM Transaction"},
+	}
+
+	pattern, err := compileHistoryGrep("from transaction")
+	require.NoError(t, err)
+
+	filtered := filterHistory(entries, pattern, 10)
+	require.Len(t, filtered, 2)
+	assert.Equal(t, 3, filtered[1].Index)
+
+	assert.Len(t, filterHistory(entries, nil, 2), 2)
+	assert.Len(t, filterHistory(entries, regexp.MustCompile("PageView"), 10), 0)
+
+	_, err = compileHistoryGrep("(")
+	assert.Error(t, err)
+}
+
+func TestFindHistoryEntry(t *testing.T) {
+	entries := []historyEntry{{Index: 1, NRQL: "a"}, {Index: 2, NRQL: "b"}}
+
+	entry, err := findHistoryEntry(entries, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "b", entry.NRQL)
+
+	_, err = findHistoryEntry(entries, 0)
+	assert.Error(t, err)
+
+	_, err = findHistoryEntry(entries, 3)
+	assert.Error(t, err)
+}
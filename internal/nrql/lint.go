@@ -0,0 +1,196 @@
+package nrql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	lintSeverityError   = "error"
+	lintSeverityWarning = "warning"
+
+	lintRuleSyntax               = "syntax"
+	lintRuleMissingSince         = "missing-since"
+	lintRuleUnboundedLimit       = "unbounded-limit"
+	lintRuleHighCardinalityFacet = "high-cardinality-facet"
+	lintRuleUnknownFunction      = "unknown-function"
+)
+
+var lintRules = []string{
+	lintRuleSyntax,
+	lintRuleMissingSince,
+	lintRuleUnboundedLimit,
+	lintRuleHighCardinalityFacet,
+	lintRuleUnknownFunction,
+}
+
+// defaultHighCardinalityAttributes are attributes that typically hold a
+// unique value per event, which makes faceting on them expensive and rarely
+// useful.
+var defaultHighCardinalityAttributes = []string{
+	"guid", "id", "messageId", "parentId", "requestId", "sessionId", "spanId",
+	"timestamp", "traceId", "userId", "uuid",
+}
+
+// lintIssue is a problem found in a NRQL query.  Lines and columns start at 1.
+type lintIssue struct {
+	Source   string `json:"source,omitempty" yaml:"source,omitempty"`
+	Line     int    `json:"line" yaml:"line"`
+	Column   int    `json:"column" yaml:"column"`
+	Severity string `json:"severity" yaml:"severity"`
+	Rule     string `json:"rule" yaml:"rule"`
+	Message  string `json:"message" yaml:"message"`
+
+	pos int
+}
+
+func (i lintIssue) String() string {
+	location := fmt.Sprintf("%d:%d", i.Line, i.Column)
+	if i.Source != "" {
+		location = i.Source + ":" + location
+	}
+
+	return fmt.Sprintf("%s: %s: %s [%s]", location, i.Severity, i.Message, i.Rule)
+}
+
+// linter checks NRQL queries for syntax errors and common mistakes without
+// contacting New Relic.
+type linter struct {
+	highCardinality map[string]bool
+	disabled        map[string]bool
+}
+
+func newLinter(highCardinalityAttributes []string, disabledRules []string) (*linter, error) {
+	l := &linter{
+		highCardinality: map[string]bool{},
+		disabled:        map[string]bool{},
+	}
+
+	for _, a := range highCardinalityAttributes {
+		l.highCardinality[strings.ToLower(a)] = true
+	}
+
+	for _, r := range disabledRules {
+		if !containsFold(lintRules, r) {
+			return nil, fmt.Errorf("unknown lint rule %s, please use one of: %s", r, strings.Join(lintRules, ", "))
+		}
+
+		l.disabled[strings.ToLower(r)] = true
+	}
+
+	return l, nil
+}
+
+// Lint checks one or more semicolon separated queries, returning the issues
+// found ordered by their position.
+func (l *linter) Lint(source string, query string) []lintIssue {
+	statements, errs := parseNRQL(query)
+
+	var issues []lintIssue
+
+	for _, err := range errs {
+		issues = append(issues, lintIssue{
+			Severity: lintSeverityError,
+			Rule:     lintRuleSyntax,
+			Message:  err.msg,
+			pos:      err.pos,
+		})
+	}
+
+	for _, s := range statements {
+		issues = append(issues, l.check(s)...)
+	}
+
+	sort.SliceStable(issues, func(a, b int) bool {
+		return issues[a].pos < issues[b].pos
+	})
+
+	enabled := []lintIssue{}
+
+	for _, i := range issues {
+		if l.disabled[i.Rule] {
+			continue
+		}
+
+		i.Source = source
+		i.Line, i.Column = lineAndColumn(query, i.pos)
+		enabled = append(enabled, i)
+	}
+
+	return enabled
+}
+
+func (l *linter) check(s *nrqlStatement) []lintIssue {
+	var issues []lintIssue
+
+	if _, ok := s.clauses["SINCE"]; !ok && !s.show {
+		issues = append(issues, lintIssue{
+			Severity: lintSeverityWarning,
+			Rule:     lintRuleMissingSince,
+			Message:  "query has no SINCE clause and will only cover the last hour",
+			pos:      s.start.pos,
+		})
+	}
+
+	for _, t := range s.limits {
+		issues = append(issues, lintIssue{
+			Severity: lintSeverityWarning,
+			Rule:     lintRuleUnboundedLimit,
+			Message:  "LIMIT MAX returns an unbounded number of results, consider an explicit limit",
+			pos:      t.pos,
+		})
+	}
+
+	for _, t := range s.facets {
+		if l.highCardinality[strings.ToLower(t.text)] {
+			issues = append(issues, lintIssue{
+				Severity: lintSeverityWarning,
+				Rule:     lintRuleHighCardinalityFacet,
+				Message:  fmt.Sprintf("FACET on high-cardinality attribute %s", t.text),
+				pos:      t.pos,
+			})
+		}
+	}
+
+	for _, t := range s.functions {
+		// Functions are added to NRQL over time, so one that is not known
+		// here may still be valid.
+		if !containsFold(nrqlFunctions, t.text) {
+			issues = append(issues, lintIssue{
+				Severity: lintSeverityWarning,
+				Rule:     lintRuleUnknownFunction,
+				Message:  fmt.Sprintf("unknown function %s", t.text),
+				pos:      t.pos,
+			})
+		}
+	}
+
+	return issues
+}
+
+// lineAndColumn converts a byte offset into a 1-based line and column.
+func lineAndColumn(text string, pos int) (int, int) {
+	if pos > len(text) {
+		pos = len(text)
+	}
+
+	before := text[:pos]
+	line := strings.Count(before, "\n") + 1
+	column := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+
+	return line, column
+}
+
+// countIssues returns the number of errors and warnings in a list of issues.
+func countIssues(issues []lintIssue) (errors int, warnings int) {
+	for _, i := range issues {
+		if i.Severity == lintSeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+
+	return errors, warnings
+}
@@ -0,0 +1,170 @@
+// +build unit
+
+package nrql
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLinter(t *testing.T, disabled ...string) *linter {
+	l, err := newLinter(defaultHighCardinalityAttributes, disabled)
+	require.NoError(t, err)
+
+	return l
+}
+
+func TestLintValidQueries(t *testing.T) {
+	queries := []string{
+		"SELECT count(*) FROM Transaction SINCE 1 day ago",
+		"SELECT average(duration), percentile(duration, 95, 99) FROM Transaction WHERE appName = 'WebPortal' FACET host LIMIT 20 SINCE 3 days ago UNTIL 1 day ago",
+		"FROM Transaction SELECT rate(count(*), 1 minute) TIMESERIES 5 minutes SINCE yesterday COMPARE WITH 1 week ago",
+		"SELECT filter(count(*), WHERE error IS true) / count(*) * 100 AS 'Error %' FROM Transaction SINCE this week",
+		"SELECT count(*) FROM Transaction WHERE name NOT LIKE '%health%' AND (code >= 500 OR code IS NULL) SINCE '2020-01-01 00:00:00'",
+		"SELECT uniqueCount(session) FROM PageView WHERE userId IN (FROM Transaction SELECT uniques(userId) WHERE error) SINCE 1600000000000",
+		"SELECT count(*) FROM Transaction FACET CASES (WHERE duration < 1 AS 'fast', WHERE duration >= 1 AS 'slow') SINCE 1 hour ago",
+		"SELECT `my-attribute` FROM Log SINCE 30 minutes ago TIMESERIES AUTO SLIDE BY 1 minute WITH TIMEZONE 'America/Los_Angeles'",
+		"SELECT * FROM Log SINCE today LIMIT 100 OFFSET 10 EXTRAPOLATE",
+		"SHOW EVENT TYPES SINCE 1 week ago",
+		"SELECT average(x) FROM (SELECT count(*) AS x FROM Transaction FACET appName) SINCE 1 day ago",
+		"FROM (FROM Transaction SELECT max(duration) AS slowest FACET host TIMESERIES) SELECT average(slowest) SINCE 1 day ago",
+		"SELECT count(*) FROM Log WHERE message RLIKE r'.*\\d+ ms.*' AND host RLIKE R\"web-\\w+\" SINCE 1 day ago",
+		"-- throughput\nSELECT count(*) FROM Transaction /* all apps */ SINCE 1 day ago;",
+	}
+
+	l := newTestLinter(t)
+
+	for _, q := range queries {
+		assert.Empty(t, l.Lint("", q), q)
+	}
+}
+
+func TestLintSyntaxErrors(t *testing.T) {
+	cases := []struct {
+		query   string
+		line    int
+		column  int
+		message string
+	}{
+		{"SELECT count(*) Transaction", 1, 17, "expected FROM but found Transaction"},
+		{"SELECT count(* FROM Transaction", 1, 16, "expected ',' or ')' but found FROM"},
+		{"SELECT count(*) FROM Transaction\nWHERE appName = 'x", 2, 17, "unterminated string"},
+		{"SELECT count(*) FROM Transaction SINCE 1 day ago SINCE 2 days ago", 1, 50, "duplicate SINCE clause"},
+		{"SELECT count(*) FROM Transaction SINCE banana", 1, 40, "expected a time such as 1 day ago but found banana"},
+		{"SELECT count(*) FROM Transaction LIMIT lots", 1, 40, "expected a number or MAX after LIMIT but found lots"},
+		{"FROM Transaction WHERE x = 1", 1, 29, "query is missing a SELECT clause"},
+		{"UPDATE Transaction", 1, 1, "expected SELECT or FROM but found UPDATE"},
+		{"SELECT average(x) FROM (SELECT count(*) AS x FROM Transaction", 1, 62, "expected ')' but found end of query"},
+		{"SELECT count(*) FROM Log WHERE message RLIKE r'\\d+", 1, 46, "unterminated string"},
+	}
+
+	l := newTestLinter(t, lintRuleMissingSince)
+
+	for _, c := range cases {
+		issues := l.Lint("", c.query)
+		require.Len(t, issues, 1, c.query)
+
+		assert.Equal(t, lintSeverityError, issues[0].Severity, c.query)
+		assert.Equal(t, lintRuleSyntax, issues[0].Rule, c.query)
+		assert.Equal(t, c.message, issues[0].Message, c.query)
+		assert.Equal(t, c.line, issues[0].Line, c.query)
+		assert.Equal(t, c.column, issues[0].Column, c.query)
+	}
+}
+
+func TestLintRecoversAfterSyntaxError(t *testing.T) {
+	l := newTestLinter(t)
+
+	issues := l.Lint("alerts.nrql", "SELECT FROM Transaction;\nSELECT count(*) FROM Transaction")
+	require.Len(t, issues, 2)
+
+	assert.Equal(t, lintRuleSyntax, issues[0].Rule)
+	assert.Equal(t, lintRuleMissingSince, issues[1].Rule)
+	assert.Equal(t, 2, issues[1].Line)
+	assert.Equal(t, "alerts.nrql:2:1: warning: query has no SINCE clause and will only cover the last hour [missing-since]", issues[1].String())
+}
+
+func TestLintWarnings(t *testing.T) {
+	l := newTestLinter(t)
+
+	issues := l.Lint("", "SELECT count(*) FROM Transaction FACET appName, traceId LIMIT MAX")
+	require.Len(t, issues, 3)
+
+	assert.Equal(t, lintRuleMissingSince, issues[0].Rule)
+	assert.Equal(t, lintRuleHighCardinalityFacet, issues[1].Rule)
+	assert.Equal(t, 49, issues[1].Column)
+	assert.Equal(t, lintRuleUnboundedLimit, issues[2].Rule)
+
+	for _, i := range issues {
+		assert.Equal(t, lintSeverityWarning, i.Severity)
+	}
+}
+
+func TestLintUnknownFunction(t *testing.T) {
+	l := newTestLinter(t)
+
+	issues := l.Lint("", "SELECT avg(duration) FROM Transaction SINCE 1 day ago")
+	require.Len(t, issues, 1)
+
+	assert.Equal(t, lintRuleUnknownFunction, issues[0].Rule)
+	assert.Equal(t, lintSeverityWarning, issues[0].Severity)
+	assert.Equal(t, "unknown function avg", issues[0].Message)
+	assert.Equal(t, 8, issues[0].Column)
+
+	for _, q := range []string{
+		"SELECT bytecountestimate() FROM Log SINCE 1 day ago",
+		"SELECT cdfPercentage(duration, 0.1, 0.5) FROM Transaction SINCE 1 day ago",
+		"SELECT count(*) FROM Transaction FACET weekdayOf(timestamp), quarterOf(timestamp) SINCE 1 day ago",
+	} {
+		assert.Empty(t, l.Lint("", q), q)
+	}
+}
+
+func TestLintConfiguration(t *testing.T) {
+	l, err := newLinter([]string{"appName"}, []string{lintRuleMissingSince})
+	require.NoError(t, err)
+
+	issues := l.Lint("", "SELECT count(*) FROM Transaction FACET appName, traceId")
+	require.Len(t, issues, 1)
+	assert.Equal(t, "FACET on high-cardinality attribute appName", issues[0].Message)
+
+	_, err = newLinter(nil, []string{"no-such-rule"})
+	assert.Error(t, err)
+}
+
+func TestLintFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nrql-lint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	nrqlPath := filepath.Join(dir, "alerts.nrql")
+	require.NoError(t, ioutil.WriteFile(nrqlPath, []byte("SELECT count(*) FROM Transaction SINCE 1 day ago;\nSELECT max(duration) FROM Transaction"), 0600))
+
+	yamlPath := filepath.Join(dir, "queries.yaml")
+	require.NoError(t, ioutil.WriteFile(yamlPath, []byte(`
+variables:
+  appName: WebPortal
+queries:
+  - name: errors
+    query: SELECT count(*) FROM TransactionError WHERE appName = '{{ .appName }}' SINCE 1 day ago FACET traceId
+`), 0600))
+
+	l := newTestLinter(t)
+
+	issues, err := lintFile(l, nrqlPath, nil)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, nrqlPath, issues[0].Source)
+	assert.Equal(t, lintRuleMissingSince, issues[0].Rule)
+
+	issues, err = lintFile(l, yamlPath, nil)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, yamlPath+"[errors]", issues[0].Source)
+	assert.Equal(t, lintRuleHighCardinalityFacet, issues[0].Rule)
+}
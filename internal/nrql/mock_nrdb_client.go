@@ -0,0 +1,64 @@
+package nrql
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+type MockNRDBClient struct {
+	mu       sync.Mutex
+	results  func(int, nrdb.NRQL) []nrdb.NRDBResult
+	error    string
+	queries  []nrdb.NRQL
+	accounts []int
+}
+
+func NewMockNRDBClient() *MockNRDBClient {
+	return &MockNRDBClient{
+		results: func(int, nrdb.NRQL) []nrdb.NRDBResult {
+			return []nrdb.NRDBResult{}
+		},
+	}
+}
+
+func (c *MockNRDBClient) QueryWithContext(ctx context.Context, accountID int, nrql nrdb.NRQL) (*nrdb.NRDBResultContainer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queries = append(c.queries, nrql)
+	c.accounts = append(c.accounts, accountID)
+
+	if c.error != "" {
+		return nil, errors.New(c.error)
+	}
+
+	return &nrdb.NRDBResultContainer{
+		NRQL:    nrql,
+		Results: c.results(accountID, nrql),
+	}, nil
+}
+
+func (c *MockNRDBClient) ThrowError(message string) {
+	c.error = message
+}
+
+func (c *MockNRDBClient) ReturnResults(f func(accountID int, nrql nrdb.NRQL) []nrdb.NRDBResult) {
+	c.results = f
+}
+
+func (c *MockNRDBClient) Queries() []nrdb.NRQL {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]nrdb.NRQL{}, c.queries...)
+}
+
+func (c *MockNRDBClient) Accounts() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]int{}, c.accounts...)
+}
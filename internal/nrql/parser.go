@@ -0,0 +1,853 @@
+package nrql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+// token is a lexical element of a NRQL query.  The position is the byte
+// offset of the token within the query.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return "'" + t.text + "'"
+	case tokenQuotedIdent:
+		return "`" + t.text + "`"
+	}
+
+	return t.text
+}
+
+// is reports whether the token is the given keyword, ignoring case, or the
+// given symbol.
+func (t token) is(s string) bool {
+	switch t.kind {
+	case tokenIdent:
+		return strings.EqualFold(t.text, s)
+	case tokenSymbol:
+		return t.text == s
+	}
+
+	return false
+}
+
+// nrqlSymbols are the operators and punctuation of NRQL, longest first.
+var nrqlSymbols = []string{
+	"!=", "<>", "<=", ">=", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", ";",
+}
+
+// nrqlReservedWords may not be used as unquoted attribute names.
+var nrqlReservedWords = []string{
+	"AND", "AS", "COMPARE", "EXTRAPOLATE", "FACET", "FROM", "IN", "IS", "LIKE",
+	"LIMIT", "NOT", "OFFSET", "OR", "ORDER", "RLIKE", "SELECT", "SHOW", "SINCE",
+	"SLIDE", "TIMESERIES", "UNTIL", "WHERE", "WITH",
+}
+
+var nrqlTimeUnits = []string{
+	"SECOND", "SECONDS", "MINUTE", "MINUTES", "HOUR", "HOURS", "DAY", "DAYS",
+	"WEEK", "WEEKS", "MONTH", "MONTHS", "QUARTER", "QUARTERS", "YEAR", "YEARS",
+}
+
+// syntaxError is a NRQL syntax error at a byte offset within the query.
+type syntaxError struct {
+	pos int
+	msg string
+}
+
+func (e *syntaxError) Error() string {
+	return e.msg
+}
+
+// tokenize splits a NRQL query into tokens, skipping whitespace and comments.
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(query[i:], "--") || strings.HasPrefix(query[i:], "//"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return nil, &syntaxError{pos: i, msg: "unterminated comment"}
+			}
+			i += end + 4
+		case (c == 'r' || c == 'R') && i+1 < len(query) && (query[i+1] == '\'' || query[i+1] == '"'):
+			// Raw strings, such as r'\d+', used for RLIKE patterns
+			end := strings.IndexByte(query[i+2:], query[i+1])
+			if end < 0 {
+				return nil, &syntaxError{pos: i, msg: "unterminated string"}
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: query[i+2 : i+2+end], pos: i})
+			i += end + 3
+		case isIdentStart(c):
+			start := i
+			for i < len(query) && isIdentPart(query[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: query[start:i], pos: start})
+		case isDigit(c) || c == '.' && i+1 < len(query) && isDigit(query[i+1]):
+			start := i
+			i = scanNumber(query, i)
+			if _, err := strconv.ParseFloat(query[start:i], 64); err != nil {
+				return nil, &syntaxError{pos: start, msg: fmt.Sprintf("invalid number %s", query[start:i])}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: query[start:i], pos: start})
+		case c == '\'' || c == '"' || c == '`':
+			text, end, ok := scanQuoted(query, i)
+			if !ok {
+				if c == '`' {
+					return nil, &syntaxError{pos: i, msg: "unterminated quoted identifier"}
+				}
+				return nil, &syntaxError{pos: i, msg: "unterminated string"}
+			}
+
+			kind := tokenString
+			if c == '`' {
+				kind = tokenQuotedIdent
+			}
+
+			tokens = append(tokens, token{kind: kind, text: text, pos: i})
+			i = end
+		default:
+			sym := scanSymbol(query[i:])
+			if sym == "" {
+				r, _ := utf8.DecodeRuneInString(query[i:])
+				return nil, &syntaxError{pos: i, msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: sym, pos: i})
+			i += len(sym)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(query)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func scanNumber(query string, i int) int {
+	for i < len(query) && (isDigit(query[i]) || query[i] == '.') {
+		i++
+	}
+
+	if i < len(query) && (query[i] == 'e' || query[i] == 'E') {
+		j := i + 1
+		if j < len(query) && (query[j] == '+' || query[j] == '-') {
+			j++
+		}
+
+		if j < len(query) && isDigit(query[j]) {
+			for i = j; i < len(query) && isDigit(query[i]); i++ {
+			}
+		}
+	}
+
+	return i
+}
+
+// scanQuoted reads a quoted string starting at i, returning its unescaped
+// contents and the offset following the closing quote.  Quotes are escaped
+// by doubling them or with a backslash.
+func scanQuoted(query string, i int) (string, int, bool) {
+	quote := query[i]
+
+	var b strings.Builder
+
+	for j := i + 1; j < len(query); j++ {
+		switch c := query[j]; {
+		case c == '\\' && j+1 < len(query):
+			j++
+			b.WriteByte(query[j])
+		case c == quote && j+1 < len(query) && query[j+1] == quote:
+			j++
+			b.WriteByte(quote)
+		case c == quote:
+			return b.String(), j + 1, true
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", len(query), false
+}
+
+func scanSymbol(s string) string {
+	for _, sym := range nrqlSymbols {
+		if strings.HasPrefix(s, sym) {
+			return sym
+		}
+	}
+
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isReserved(t token) bool {
+	return t.kind == tokenIdent && containsFold(nrqlReservedWords, t.text)
+}
+
+func isTimeUnit(t token) bool {
+	return t.kind == tokenIdent && containsFold(nrqlTimeUnits, t.text)
+}
+
+// nrqlStatement records the parts of a parsed statement that are of interest
+// to the linter.
+type nrqlStatement struct {
+	start     token
+	show      bool
+	clauses   map[string]token
+	limits    []token
+	facets    []token
+	functions []token
+}
+
+// parser is a recursive descent parser for NRQL.  It validates the structure
+// of a query rather than building a syntax tree.
+type parser struct {
+	tokens []token
+	pos    int
+	stmt   *nrqlStatement
+}
+
+// parseNRQL parses one or more semicolon separated NRQL statements.  Parsing
+// resumes at the next statement following a syntax error.
+func parseNRQL(query string) ([]*nrqlStatement, []*syntaxError) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, []*syntaxError{err.(*syntaxError)}
+	}
+
+	p := &parser{tokens: tokens}
+
+	var (
+		statements []*nrqlStatement
+		errs       []*syntaxError
+	)
+
+	for p.peek().kind != tokenEOF {
+		if p.accept(";") {
+			continue
+		}
+
+		stmt, err := p.parseStatement()
+		if err != nil {
+			errs = append(errs, err.(*syntaxError))
+			p.skipStatement()
+			continue
+		}
+
+		statements = append(statements, stmt)
+	}
+
+	return statements, errs
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n < len(p.tokens) {
+		return p.tokens[p.pos+n]
+	}
+
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) accept(s string) bool {
+	if p.peek().is(s) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(s string, what string) error {
+	if t := p.peek(); !t.is(s) {
+		return p.errorf(t, "expected %s but found %s", what, t)
+	}
+
+	p.pos++
+
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, a ...interface{}) error {
+	return &syntaxError{pos: t.pos, msg: fmt.Sprintf(format, a...)}
+}
+
+func (p *parser) skipStatement() {
+	for t := p.peek(); t.kind != tokenEOF && !t.is(";"); t = p.peek() {
+		p.next()
+	}
+}
+
+func (p *parser) parseStatement() (*nrqlStatement, error) {
+	p.stmt = &nrqlStatement{start: p.peek(), clauses: map[string]token{}}
+
+	if p.accept("SHOW") {
+		if err := p.expect("EVENT", "EVENT TYPES"); err != nil {
+			return nil, err
+		}
+
+		if err := p.expect("TYPES", "EVENT TYPES"); err != nil {
+			return nil, err
+		}
+
+		p.stmt.show = true
+
+		if err := p.parseClauses(p.stmt.clauses, false); err != nil {
+			return nil, err
+		}
+	} else if err := p.parseQuery(p.stmt.clauses, false); err != nil {
+		return nil, err
+	}
+
+	return p.stmt, nil
+}
+
+// parseQuery parses a SELECT query, which may begin with either its SELECT
+// or its FROM clause.
+func (p *parser) parseQuery(clauses map[string]token, nested bool) error {
+	t := p.peek()
+
+	switch {
+	case t.is("SELECT"):
+		clauses["SELECT"] = p.next()
+		if err := p.parseSelectList(); err != nil {
+			return err
+		}
+
+		if err := p.expect("FROM", "FROM"); err != nil {
+			return err
+		}
+	case t.is("FROM"):
+		p.next()
+	default:
+		return p.errorf(t, "expected SELECT or FROM but found %s", t)
+	}
+
+	clauses["FROM"] = t
+
+	if err := p.parseEventTypes(); err != nil {
+		return err
+	}
+
+	if err := p.parseClauses(clauses, nested); err != nil {
+		return err
+	}
+
+	if _, ok := clauses["SELECT"]; !ok {
+		return p.errorf(p.peek(), "query is missing a SELECT clause")
+	}
+
+	return nil
+}
+
+// parseClauses parses the clauses following FROM until the end of the
+// statement, or the closing parenthesis of a nested query.
+func (p *parser) parseClauses(clauses map[string]token, nested bool) error {
+	for {
+		t := p.peek()
+		if t.kind == tokenEOF || t.is(";") || nested && t.is(")") {
+			return nil
+		}
+
+		name := strings.ToUpper(t.text)
+		switch name {
+		case "COMPARE":
+			name = "COMPARE WITH"
+		case "ORDER":
+			name = "ORDER BY"
+		case "WITH":
+			name = "WITH TIMEZONE"
+		}
+
+		if t.kind != tokenIdent || !containsFold(clauseKeywords, strings.Fields(name)[0]) {
+			return p.errorf(t, "unexpected %s", t)
+		}
+
+		if _, ok := clauses[name]; ok {
+			return p.errorf(t, "duplicate %s clause", name)
+		}
+
+		clauses[name] = p.next()
+
+		if err := p.parseClause(name); err != nil {
+			return err
+		}
+	}
+}
+
+var clauseKeywords = []string{
+	"COMPARE", "EXTRAPOLATE", "FACET", "LIMIT", "OFFSET", "ORDER", "RAW",
+	"SELECT", "SINCE", "TIMESERIES", "UNTIL", "WHERE", "WITH",
+}
+
+func (p *parser) parseClause(name string) error {
+	switch name {
+	case "SELECT":
+		return p.parseSelectList()
+	case "WHERE":
+		return p.parseExpr()
+	case "FACET":
+		return p.parseFacet()
+	case "SINCE", "UNTIL":
+		return p.parseTime()
+	case "COMPARE WITH":
+		if err := p.expect("WITH", "WITH"); err != nil {
+			return err
+		}
+		return p.parseTime()
+	case "TIMESERIES":
+		return p.parseTimeseries()
+	case "LIMIT":
+		return p.parseLimit()
+	case "OFFSET":
+		if t := p.next(); t.kind != tokenNumber {
+			return p.errorf(t, "expected a number after OFFSET but found %s", t)
+		}
+	case "ORDER BY":
+		if err := p.expect("BY", "BY"); err != nil {
+			return err
+		}
+		return p.parseOrderBy()
+	case "WITH TIMEZONE":
+		if err := p.expect("TIMEZONE", "TIMEZONE"); err != nil {
+			return err
+		}
+		if t := p.next(); t.kind != tokenString {
+			return p.errorf(t, "expected a time zone name such as 'America/Los_Angeles' but found %s", t)
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) parseSelectList() error {
+	for {
+		if !p.accept("*") {
+			if err := p.parseExpr(); err != nil {
+				return err
+			}
+
+			if err := p.parseAlias(); err != nil {
+				return err
+			}
+		}
+
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+// parseEventTypes parses the event types following FROM, or a nested query
+// in parentheses whose results are queried in turn.
+func (p *parser) parseEventTypes() error {
+	if p.accept("(") {
+		if err := p.parseQuery(map[string]token{}, true); err != nil {
+			return err
+		}
+
+		return p.expect(")", "')'")
+	}
+
+	for {
+		t := p.next()
+		if t.kind != tokenIdent && t.kind != tokenQuotedIdent || isReserved(t) {
+			return p.errorf(t, "expected an event type but found %s", t)
+		}
+
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseAlias() error {
+	if !p.accept("AS") {
+		return nil
+	}
+
+	t := p.next()
+	if t.kind == tokenString || t.kind == tokenQuotedIdent || t.kind == tokenIdent && !isReserved(t) {
+		return nil
+	}
+
+	return p.errorf(t, "expected an alias after AS but found %s", t)
+}
+
+func (p *parser) parseFacet() error {
+	for {
+		if p.peek().is("CASES") && p.peekAt(1).is("(") {
+			if err := p.parseCases(); err != nil {
+				return err
+			}
+		} else {
+			t := p.peek()
+			start := p.pos
+
+			if err := p.parseExpr(); err != nil {
+				return err
+			}
+
+			if p.pos == start+1 && (t.kind == tokenIdent || t.kind == tokenQuotedIdent) {
+				p.stmt.facets = append(p.stmt.facets, t)
+			}
+
+			if err := p.parseAlias(); err != nil {
+				return err
+			}
+		}
+
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseCases() error {
+	p.next()
+	p.next()
+
+	for {
+		if err := p.expect("WHERE", "WHERE"); err != nil {
+			return err
+		}
+
+		if err := p.parseExpr(); err != nil {
+			return err
+		}
+
+		if err := p.parseAlias(); err != nil {
+			return err
+		}
+
+		if !p.accept(",") {
+			return p.expect(")", "',' or ')'")
+		}
+	}
+}
+
+// parseTime parses a point in time, such as 1 day ago, yesterday, last week,
+// '2020-01-01 00:00:00' or an epoch timestamp in milliseconds.
+func (p *parser) parseTime() error {
+	t := p.next()
+
+	switch {
+	case t.kind == tokenNumber:
+		if isTimeUnit(p.peek()) {
+			p.next()
+			p.accept("AGO")
+		}
+		return nil
+	case t.kind == tokenString, t.is("NOW"), t.is("TODAY"), t.is("YESTERDAY"):
+		return nil
+	case t.is("THIS"), t.is("LAST"):
+		if u := p.next(); !isTimeUnit(u) {
+			return p.errorf(u, "expected a time unit after %s but found %s", strings.ToUpper(t.text), u)
+		}
+		return nil
+	}
+
+	return p.errorf(t, "expected a time such as 1 day ago but found %s", t)
+}
+
+func (p *parser) parseTimeseries() error {
+	if err := p.parseBucketSize(true); err != nil {
+		return err
+	}
+
+	if p.accept("SLIDE") {
+		if err := p.expect("BY", "BY"); err != nil {
+			return err
+		}
+
+		return p.parseBucketSize(false)
+	}
+
+	return nil
+}
+
+func (p *parser) parseBucketSize(optional bool) error {
+	t := p.peek()
+
+	switch {
+	case t.is("AUTO"), t.is("MAX"):
+		p.next()
+	case t.kind == tokenNumber:
+		p.next()
+		if u := p.next(); !isTimeUnit(u) {
+			return p.errorf(u, "expected a time unit but found %s", u)
+		}
+	case !optional:
+		return p.errorf(t, "expected a duration, AUTO or MAX but found %s", t)
+	}
+
+	return nil
+}
+
+func (p *parser) parseLimit() error {
+	t := p.next()
+
+	switch {
+	case t.is("MAX"):
+		p.stmt.limits = append(p.stmt.limits, t)
+	case t.kind != tokenNumber:
+		return p.errorf(t, "expected a number or MAX after LIMIT but found %s", t)
+	}
+
+	return nil
+}
+
+func (p *parser) parseOrderBy() error {
+	for {
+		if err := p.parseExpr(); err != nil {
+			return err
+		}
+
+		if !p.accept("ASC") {
+			p.accept("DESC")
+		}
+
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseExpr() error {
+	return p.parseBinary(p.parseAnd, "OR")
+}
+
+func (p *parser) parseAnd() error {
+	return p.parseBinary(p.parseNot, "AND")
+}
+
+func (p *parser) parseNot() error {
+	if p.accept("NOT") {
+		return p.parseNot()
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() error {
+	if err := p.parseAdditive(); err != nil {
+		return err
+	}
+
+	t := p.peek()
+	if t.is("NOT") && (p.peekAt(1).is("IN") || p.peekAt(1).is("LIKE") || p.peekAt(1).is("RLIKE")) {
+		p.next()
+		t = p.peek()
+	}
+
+	switch {
+	case t.is("="), t.is("!="), t.is("<>"), t.is("<"), t.is("<="), t.is(">"), t.is(">="),
+		t.is("LIKE"), t.is("RLIKE"):
+		p.next()
+		return p.parseAdditive()
+	case t.is("IS"):
+		p.next()
+		p.accept("NOT")
+		if v := p.next(); !v.is("NULL") && !v.is("TRUE") && !v.is("FALSE") {
+			return p.errorf(v, "expected NULL, TRUE or FALSE but found %s", v)
+		}
+		return nil
+	case t.is("IN"):
+		p.next()
+		return p.parseInList()
+	}
+
+	return nil
+}
+
+func (p *parser) parseInList() error {
+	if err := p.expect("(", "'('"); err != nil {
+		return err
+	}
+
+	if t := p.peek(); t.is("SELECT") || t.is("FROM") {
+		if err := p.parseQuery(map[string]token{}, true); err != nil {
+			return err
+		}
+
+		return p.expect(")", "')'")
+	}
+
+	for {
+		if err := p.parseExpr(); err != nil {
+			return err
+		}
+
+		if !p.accept(",") {
+			return p.expect(")", "',' or ')'")
+		}
+	}
+}
+
+func (p *parser) parseAdditive() error {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() error {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+// parseBinary parses a left associative chain of operands separated by any
+// of the given operators.
+func (p *parser) parseBinary(operand func() error, operators ...string) error {
+	for {
+		if err := operand(); err != nil {
+			return err
+		}
+
+		matched := false
+		for _, op := range operators {
+			if p.accept(op) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseUnary() error {
+	if p.accept("-") || p.accept("+") {
+		return p.parseUnary()
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() error {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber:
+		// Durations, such as the 1 minute in rate(count(*), 1 minute)
+		if isTimeUnit(p.peek()) {
+			p.next()
+		}
+		return nil
+	case tokenString, tokenQuotedIdent:
+		return nil
+	case tokenIdent:
+		if isReserved(t) {
+			return p.errorf(t, "unexpected %s", strings.ToUpper(t.text))
+		}
+
+		if p.peek().is("(") {
+			return p.parseCall(t)
+		}
+
+		return nil
+	case tokenSymbol:
+		if t.is("(") {
+			if err := p.parseExpr(); err != nil {
+				return err
+			}
+
+			return p.expect(")", "')'")
+		}
+	}
+
+	return p.errorf(t, "expected an attribute, value or function but found %s", t)
+}
+
+func (p *parser) parseCall(name token) error {
+	p.stmt.functions = append(p.stmt.functions, name)
+	p.next()
+
+	if p.accept(")") {
+		return nil
+	}
+
+	for {
+		switch {
+		case p.accept("*"):
+		case p.accept("WHERE"):
+			if err := p.parseExpr(); err != nil {
+				return err
+			}
+
+			if err := p.parseAlias(); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseExpr(); err != nil {
+				return err
+			}
+		}
+
+		if !p.accept(",") {
+			return p.expect(")", "',' or ')'")
+		}
+	}
+}
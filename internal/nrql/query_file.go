@@ -0,0 +1,201 @@
+package nrql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+const defaultQueryFileConcurrency = 5
+
+// queryFile is a collection of named NRQL queries.  Queries may reference
+// variables using Go template syntax, such as {{ .appName }}.
+type queryFile struct {
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Queries   []namedQuery      `yaml:"queries"`
+}
+
+// namedQuery is a single query within a queryFile.
+type namedQuery struct {
+	Name      string `yaml:"name"`
+	Query     string `yaml:"query"`
+	AccountID int    `yaml:"accountId,omitempty"`
+}
+
+// namedQueryResult holds the outcome of running a single namedQuery.
+type namedQueryResult struct {
+	Query     string            `json:"query" yaml:"query"`
+	AccountID int               `json:"accountId" yaml:"accountId"`
+	Results   []nrdb.NRDBResult `json:"results,omitempty" yaml:"results,omitempty"`
+	Error     string            `json:"error,omitempty" yaml:"error,omitempty"`
+	Stats     *queryStats       `json:"stats,omitempty" yaml:"stats,omitempty"`
+}
+
+// loadQueryFile reads and validates a query file in YAML or JSON format.
+func loadQueryFile(path string) (*queryFile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseQueryFile(content)
+}
+
+func parseQueryFile(content []byte) (*queryFile, error) {
+	var f queryFile
+
+	if err := yaml.UnmarshalStrict(content, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse query file: %s", err)
+	}
+
+	if len(f.Queries) == 0 {
+		return nil, errors.New("query file does not contain any queries")
+	}
+
+	names := map[string]bool{}
+	for i, q := range f.Queries {
+		if q.Name == "" {
+			return nil, fmt.Errorf("query %d is missing a name", i+1)
+		}
+
+		if names[q.Name] {
+			return nil, fmt.Errorf("duplicate query name: %s", q.Name)
+		}
+
+		if strings.TrimSpace(q.Query) == "" {
+			return nil, fmt.Errorf("query %s is empty", q.Name)
+		}
+
+		names[q.Name] = true
+	}
+
+	return &f, nil
+}
+
+// render substitutes variables into each query, with the given overrides
+// taking precedence over the defaults declared in the file.
+func (f *queryFile) render(overrides map[string]string) ([]namedQuery, error) {
+	vars := map[string]string{}
+	for k, v := range f.Variables {
+		vars[k] = v
+	}
+	for k, v := range overrides {
+		vars[k] = v
+	}
+
+	rendered := make([]namedQuery, len(f.Queries))
+
+	for i, q := range f.Queries {
+		query, err := renderQuery(q.Name, q.Query, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		rendered[i] = namedQuery{
+			Name:      q.Name,
+			Query:     query,
+			AccountID: q.AccountID,
+		}
+	}
+
+	return rendered, nil
+}
+
+func renderQuery(name string, query string, vars map[string]string) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(query)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse query %s: %s", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("unable to render query %s: %s", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+// parseVariables converts a list of key=value pairs into a map.
+func parseVariables(pairs []string) (map[string]string, error) {
+	vars := map[string]string{}
+
+	for _, p := range pairs {
+		v := strings.SplitN(p, "=", 2)
+		if len(v) != 2 || v[0] == "" {
+			return nil, fmt.Errorf("variables must be specified as key=value pairs: %s", p)
+		}
+
+		vars[v[0]] = v[1]
+	}
+
+	return vars, nil
+}
+
+// runNamedQueries executes the given queries with at most concurrency
+// queries in flight at once.  Individual query failures are recorded in
+// the result rather than aborting the remaining queries.  When withStats is
+// set, each successful result includes its query stats.
+func runNamedQueries(ctx context.Context, client nrdbClient, defaultAccountID int, queries []namedQuery, concurrency int, withStats bool) map[string]namedQueryResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		results = make(map[string]namedQueryResult, len(queries))
+	)
+
+	for _, q := range queries {
+		wg.Add(1)
+
+		go func(q namedQuery) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			r := namedQueryResult{
+				Query:     q.Query,
+				AccountID: q.AccountID,
+			}
+
+			if r.AccountID == 0 {
+				r.AccountID = defaultAccountID
+			}
+
+			start := time.Now()
+
+			result, err := client.QueryWithContext(ctx, r.AccountID, nrdb.NRQL(q.Query))
+			if err != nil {
+				r.Error = err.Error()
+			} else {
+				r.Results = result.Results
+			}
+
+			if err == nil && withStats {
+				stats := newQueryStats(r.AccountID, q.Query, result, time.Since(start))
+				r.Stats = &stats
+			}
+
+			mu.Lock()
+			results[q.Name] = r
+			mu.Unlock()
+		}(q)
+	}
+
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,130 @@
+// +build unit
+
+package nrql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+var testQueryFile = `
+variables:
+  appName: WebPortal
+  since: 1 day ago
+queries:
+  - name: throughput
+    query: SELECT count(*) FROM Transaction WHERE appName = '{{ .appName }}' SINCE {{ .since }}
+  - name: errors
+    query: SELECT count(*) FROM TransactionError WHERE appName = '{{ .appName }}'
+    accountId: 2
+`
+
+func TestParseQueryFile(t *testing.T) {
+	f, err := parseQueryFile([]byte(testQueryFile))
+	require.NoError(t, err)
+
+	assert.Len(t, f.Queries, 2)
+	assert.Equal(t, "WebPortal", f.Variables["appName"])
+	assert.Equal(t, 2, f.Queries[1].AccountID)
+}
+
+func TestParseQueryFileInvalid(t *testing.T) {
+	var scenarios = []struct {
+		content string
+		err     string
+	}{
+		{`queries: []`, "does not contain any queries"},
+		{"queries:\n  - query: SELECT 1", "missing a name"},
+		{"queries:\n  - name: a\n    query: SELECT 1\n  - name: a\n    query: SELECT 2", "duplicate query name"},
+		{"queries:\n  - name: a", "query a is empty"},
+		{"queries:\n  - name: a\n    nrql: SELECT 1", "unable to parse"},
+	}
+
+	for _, s := range scenarios {
+		_, err := parseQueryFile([]byte(s.content))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), s.err)
+	}
+}
+
+func TestQueryFileRender(t *testing.T) {
+	f, err := parseQueryFile([]byte(testQueryFile))
+	require.NoError(t, err)
+
+	queries, err := f.render(map[string]string{"appName": "Checkout"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT count(*) FROM Transaction WHERE appName = 'Checkout' SINCE 1 day ago", queries[0].Query)
+	assert.Equal(t, "SELECT count(*) FROM TransactionError WHERE appName = 'Checkout'", queries[1].Query)
+}
+
+func TestQueryFileRenderMissingVariable(t *testing.T) {
+	f, err := parseQueryFile([]byte("queries:\n  - name: a\n    query: SELECT {{ .missing }}"))
+	require.NoError(t, err)
+
+	_, err = f.render(nil)
+	assert.Error(t, err)
+}
+
+func TestParseVariables(t *testing.T) {
+	vars, err := parseVariables([]string{"a=1", "b=x=y", "c="})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "x=y", "c": ""}, vars)
+
+	_, err = parseVariables([]string{"novalue"})
+	assert.Error(t, err)
+
+	_, err = parseVariables([]string{"=value"})
+	assert.Error(t, err)
+}
+
+func TestRunNamedQueries(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ReturnResults(func(accountID int, nrql nrdb.NRQL) []nrdb.NRDBResult {
+		return []nrdb.NRDBResult{{"count": accountID}}
+	})
+
+	queries := []namedQuery{
+		{Name: "a", Query: "SELECT count(*) FROM A"},
+		{Name: "b", Query: "SELECT count(*) FROM B", AccountID: 2},
+		{Name: "c", Query: "SELECT count(*) FROM C"},
+	}
+
+	results := runNamedQueries(context.Background(), c, 1, queries, 2, false)
+
+	require.Len(t, results, 3)
+	assert.Equal(t, 1, results["a"].AccountID)
+	assert.Equal(t, []nrdb.NRDBResult{{"count": 2}}, results["b"].Results)
+	assert.True(t, strings.HasSuffix(results["c"].Query, "FROM C"))
+	assert.Len(t, c.Queries(), 3)
+	assert.Nil(t, results["a"].Stats)
+}
+
+func TestRunNamedQueriesWithStats(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ReturnResults(func(accountID int, nrql nrdb.NRQL) []nrdb.NRDBResult {
+		return []nrdb.NRDBResult{{"count": 1}, {"count": 2}}
+	})
+
+	results := runNamedQueries(context.Background(), c, 1, []namedQuery{{Name: "a", Query: "SELECT count(*) FROM A"}}, 1, true)
+
+	require.NotNil(t, results["a"].Stats)
+	assert.Equal(t, 2, results["a"].Stats.ResultCount)
+	assert.Equal(t, "SELECT count(*) FROM A", results["a"].Stats.Query)
+}
+
+func TestRunNamedQueriesError(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ThrowError("bad query")
+
+	results := runNamedQueries(context.Background(), c, 1, []namedQuery{{Name: "a", Query: "SELECT"}}, 0, false)
+
+	assert.Equal(t, "bad query", results["a"].Error)
+	assert.Empty(t, results["a"].Results)
+}
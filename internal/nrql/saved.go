@@ -0,0 +1,256 @@
+package nrql
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/newrelic/newrelic-cli/internal/config"
+)
+
+const savedQueriesFile = "nrql_saved_queries.yaml"
+
+var savedQueryNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// savedQueries is the store of saved queries in the configuration directory.
+var savedQueries = newSavedQueryStore(config.DefaultConfigDirectory)
+
+// savedQuery is a named NRQL query that may contain parameters, such as
+// {{ .service }}, and may be pinned to an account and profile.
+type savedQuery struct {
+	Description string            `yaml:"description,omitempty"`
+	Query       string            `yaml:"query"`
+	Params      []savedQueryParam `yaml:"params,omitempty"`
+	AccountID   int               `yaml:"accountId,omitempty"`
+	Profile     string            `yaml:"profile,omitempty"`
+}
+
+// savedQueryParam is a parameter of a saved query.  A parameter without a
+// default must be given a value whenever the query is run.
+type savedQueryParam struct {
+	Name    string  `yaml:"name"`
+	Default *string `yaml:"default,omitempty"`
+}
+
+// savedQuerySummary is how a saved query is listed.
+type savedQuerySummary struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Query       string `json:"query" yaml:"query"`
+	Params      string `json:"params,omitempty" yaml:"params,omitempty"`
+	AccountID   int    `json:"accountId,omitempty" yaml:"accountId,omitempty"`
+	Profile     string `json:"profile,omitempty" yaml:"profile,omitempty"`
+}
+
+type savedQueryStore struct {
+	path string
+}
+
+func newSavedQueryStore(dir string) *savedQueryStore {
+	return &savedQueryStore{
+		path: filepath.Join(dir, savedQueriesFile),
+	}
+}
+
+// Load returns the saved queries keyed by name.
+func (s *savedQueryStore) Load() (map[string]savedQuery, error) {
+	content, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return map[string]savedQuery{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	queries := map[string]savedQuery{}
+	if err := yaml.UnmarshalStrict(content, &queries); err != nil {
+		return nil, fmt.Errorf("unable to read saved queries %s: %s", s.path, err)
+	}
+
+	return queries, nil
+}
+
+// Get returns the saved query with the given name.
+func (s *savedQueryStore) Get(name string) (savedQuery, error) {
+	queries, err := s.Load()
+	if err != nil {
+		return savedQuery{}, err
+	}
+
+	q, ok := queries[name]
+	if !ok {
+		return savedQuery{}, fmt.Errorf("no saved query named %s, see newrelic nrql saved list", name)
+	}
+
+	return q, nil
+}
+
+// Add saves a query under the given name, replacing an existing query of
+// the same name only when replace is set.
+func (s *savedQueryStore) Add(name string, q savedQuery, replace bool) error {
+	if err := q.validate(name); err != nil {
+		return err
+	}
+
+	queries, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := queries[name]; ok && !replace {
+		return fmt.Errorf("a saved query named %s already exists, use --force to replace it", name)
+	}
+
+	queries[name] = q
+
+	return s.save(queries)
+}
+
+// Delete removes the saved query with the given name.
+func (s *savedQueryStore) Delete(name string) error {
+	queries, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := queries[name]; !ok {
+		return fmt.Errorf("no saved query named %s", name)
+	}
+
+	delete(queries, name)
+
+	return s.save(queries)
+}
+
+func (s *savedQueryStore) save(queries map[string]savedQuery) error {
+	content, err := yaml.Marshal(queries)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, content, 0600)
+}
+
+// validate checks the name, parameters and template syntax of a query.
+func (q savedQuery) validate(name string) error {
+	if !savedQueryNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid name %s, names may contain letters, numbers, '_', '.' and '-'", name)
+	}
+
+	if strings.TrimSpace(q.Query) == "" {
+		return errors.New("query is empty")
+	}
+
+	// Rendering with a value for every parameter finds both template syntax
+	// errors and references to undeclared parameters
+	values := map[string]string{}
+	for _, p := range q.Params {
+		if p.Name == "" {
+			return errors.New("parameter names cannot be empty")
+		}
+
+		if _, ok := values[p.Name]; ok {
+			return fmt.Errorf("duplicate parameter %s", p.Name)
+		}
+
+		values[p.Name] = p.Name
+	}
+
+	_, err := renderQuery(name, q.Query, values)
+
+	return err
+}
+
+// render fills the query's parameters from their defaults and the given
+// values, which must all be declared parameters.
+func (q savedQuery) render(name string, values map[string]string) (string, error) {
+	vars := map[string]string{}
+	declared := map[string]bool{}
+
+	for _, p := range q.Params {
+		declared[p.Name] = true
+		if p.Default != nil {
+			vars[p.Name] = *p.Default
+		}
+	}
+
+	for k, v := range values {
+		if !declared[k] {
+			return "", fmt.Errorf("unknown parameter %s for saved query %s", k, name)
+		}
+
+		vars[k] = v
+	}
+
+	for _, p := range q.Params {
+		if _, ok := vars[p.Name]; !ok {
+			return "", fmt.Errorf("missing value for parameter %s, use --param %s=<value>", p.Name, p.Name)
+		}
+	}
+
+	return renderQuery(name, q.Query, vars)
+}
+
+// parseParamDefinitions converts a list of name or name=default values into
+// query parameters.
+func parseParamDefinitions(definitions []string) ([]savedQueryParam, error) {
+	params := make([]savedQueryParam, 0, len(definitions))
+
+	for _, d := range definitions {
+		v := strings.SplitN(d, "=", 2)
+		if v[0] == "" {
+			return nil, fmt.Errorf("parameters must be specified as name or name=default: %s", d)
+		}
+
+		p := savedQueryParam{Name: v[0]}
+		if len(v) == 2 {
+			p.Default = &v[1]
+		}
+
+		params = append(params, p)
+	}
+
+	return params, nil
+}
+
+// summarizeSavedQueries lists saved queries ordered by name.
+func summarizeSavedQueries(queries map[string]savedQuery) []savedQuerySummary {
+	summaries := make([]savedQuerySummary, 0, len(queries))
+
+	for name, q := range queries {
+		params := make([]string, len(q.Params))
+		for i, p := range q.Params {
+			params[i] = p.Name
+			if p.Default != nil {
+				params[i] += "=" + *p.Default
+			}
+		}
+
+		summaries = append(summaries, savedQuerySummary{
+			Name:        name,
+			Description: q.Description,
+			Query:       q.Query,
+			Params:      strings.Join(params, ", "),
+			AccountID:   q.AccountID,
+			Profile:     q.Profile,
+		})
+	}
+
+	sort.Slice(summaries, func(a, b int) bool {
+		return summaries[a].Name < summaries[b].Name
+	})
+
+	return summaries
+}
@@ -0,0 +1,113 @@
+// +build unit
+
+package nrql
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSavedQueryStore(t *testing.T) (*savedQueryStore, func()) {
+	dir, err := ioutil.TempDir("", "nrql-saved")
+	require.NoError(t, err)
+
+	return newSavedQueryStore(dir), func() { os.RemoveAll(dir) }
+}
+
+func TestSavedQueryStore(t *testing.T) {
+	store, cleanup := newTestSavedQueryStore(t)
+	defer cleanup()
+
+	queries, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, queries)
+
+	params, err := parseParamDefinitions([]string{"service", "since=1 day ago"})
+	require.NoError(t, err)
+
+	q := savedQuery{
+		Description: "Errors by class",
+		Query:       "SELECT count(*) FROM TransactionError WHERE appName = '{{ .service }}' FACET error.class SINCE {{ .since }}",
+		Params:      params,
+		AccountID:   12345,
+		Profile:     "production",
+	}
+
+	require.NoError(t, store.Add("errors-by-service", q, false))
+	assert.Error(t, store.Add("errors-by-service", q, false))
+	require.NoError(t, store.Add("errors-by-service", q, true))
+
+	loaded, err := store.Get("errors-by-service")
+	require.NoError(t, err)
+	assert.Equal(t, q, loaded)
+
+	summaries := summarizeSavedQueries(map[string]savedQuery{"errors-by-service": loaded})
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "service, since=1 day ago", summaries[0].Params)
+
+	require.NoError(t, store.Delete("errors-by-service"))
+	assert.Error(t, store.Delete("errors-by-service"))
+
+	_, err = store.Get("errors-by-service")
+	assert.Error(t, err)
+}
+
+func TestSavedQueryValidate(t *testing.T) {
+	valid := savedQuery{
+		Query:  "SELECT count(*) FROM Transaction WHERE appName = '{{ .app }}'",
+		Params: []savedQueryParam{{Name: "app"}},
+	}
+
+	assert.NoError(t, valid.validate("throughput"))
+	assert.Error(t, valid.validate("has spaces"))
+	assert.Error(t, savedQuery{Query: " "}.validate("empty"))
+
+	// The query references a parameter that was not declared
+	assert.Error(t, savedQuery{Query: valid.Query}.validate("undeclared"))
+
+	// Template syntax errors
+	assert.Error(t, savedQuery{Query: "SELECT {{ .app FROM X", Params: valid.Params}.validate("broken"))
+
+	assert.Error(t, savedQuery{Query: valid.Query, Params: []savedQueryParam{{Name: "app"}, {Name: "app"}}}.validate("duplicate"))
+}
+
+func TestSavedQueryRender(t *testing.T) {
+	params, err := parseParamDefinitions([]string{"service", "since=1 day ago"})
+	require.NoError(t, err)
+
+	q := savedQuery{
+		Query:  "SELECT count(*) FROM Transaction WHERE appName = '{{ .service }}' SINCE {{ .since }}",
+		Params: params,
+	}
+
+	rendered, err := q.render("q", map[string]string{"service": "checkout"})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT count(*) FROM Transaction WHERE appName = 'checkout' SINCE 1 day ago", rendered)
+
+	rendered, err = q.render("q", map[string]string{"service": "checkout", "since": "1 week ago"})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT count(*) FROM Transaction WHERE appName = 'checkout' SINCE 1 week ago", rendered)
+
+	_, err = q.render("q", map[string]string{})
+	assert.EqualError(t, err, "missing value for parameter service, use --param service=<value>")
+
+	_, err = q.render("q", map[string]string{"service": "checkout", "region": "eu"})
+	assert.Error(t, err)
+}
+
+func TestParseParamDefinitions(t *testing.T) {
+	params, err := parseParamDefinitions([]string{"a", "b=", "c=x=y"})
+	require.NoError(t, err)
+	require.Len(t, params, 3)
+
+	assert.Nil(t, params[0].Default)
+	assert.Equal(t, "", *params[1].Default)
+	assert.Equal(t, "x=y", *params[2].Default)
+
+	_, err = parseParamDefinitions([]string{"=x"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,263 @@
+package nrql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chzyer/readline"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+const (
+	shellHistoryFile     = "nrql_history"
+	shellPrompt          = "nrql> "
+	shellContinuePrompt  = "   -> "
+	shellStatementEnding = ";"
+)
+
+var errShellExit = errors.New("exit")
+
+var shellHelp = `Enter a NRQL query terminated with a semicolon to execute it.  Queries may
+span multiple lines.  Press TAB to complete keywords, event types and attributes.
+
+Meta-commands:
+  \account <id>     switch the account queries are issued against
+  \format <format>  set the output format [` + output.FormatOptions() + `]
+  \timing           toggle display of query execution time
+  \help             show this help
+  \quit             exit the shell (or press Ctrl-D)`
+
+// shell is an interactive NRQL read-eval-print loop.
+type shell struct {
+	client    nrdbClient
+	accountID int
+	format    output.Format
+	timing    bool
+	pending   []string
+	history   *historyStore
+}
+
+func newShell(client nrdbClient, accountID int, format output.Format) *shell {
+	return &shell{
+		client:    client,
+		accountID: accountID,
+		format:    format,
+	}
+}
+
+// Run reads and executes statements until the user exits the shell.
+func (s *shell) Run(historyDir string) error {
+	if err := os.MkdirAll(historyDir, os.ModePerm); err != nil {
+		log.Debugf("unable to create history directory: %s", err)
+	}
+
+	completer := newNRQLCompleter(s.client, s.currentAccountID, s.pendingStatement)
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:                 shellPrompt,
+		HistoryFile:            filepath.Join(historyDir, shellHistoryFile),
+		HistorySearchFold:      true,
+		DisableAutoSaveHistory: true,
+		AutoComplete:           completer,
+		InterruptPrompt:        "^C",
+		EOFPrompt:              `\quit`,
+	})
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+
+	fmt.Fprintf(rl.Stdout(), "Connected to account %d.  Type \\help for help.\n", s.accountID)
+
+	for {
+		line, err := rl.Readline()
+
+		if err == readline.ErrInterrupt {
+			s.pending = nil
+			rl.SetPrompt(shellPrompt)
+			continue
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		statement, complete := s.feed(line)
+		if !complete {
+			if len(s.pending) > 0 {
+				rl.SetPrompt(shellContinuePrompt)
+			}
+			continue
+		}
+
+		rl.SetPrompt(shellPrompt)
+		saveHistory(rl, statement)
+
+		if err := s.execute(statement); err != nil {
+			if err == errShellExit {
+				return nil
+			}
+
+			log.Error(err)
+		}
+	}
+}
+
+// feed buffers a line of input, returning the full statement once it is
+// complete.  Meta-commands are complete on their own line, while queries
+// must be terminated with a semicolon.
+func (s *shell) feed(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+
+	if len(s.pending) == 0 {
+		if trimmed == "" {
+			return "", false
+		}
+
+		if strings.HasPrefix(trimmed, `\`) {
+			return trimmed, true
+		}
+	}
+
+	if trimmed != "" {
+		s.pending = append(s.pending, trimmed)
+	}
+
+	if !strings.HasSuffix(trimmed, shellStatementEnding) {
+		return "", false
+	}
+
+	statement := strings.Join(s.pending, " ")
+	s.pending = nil
+
+	return statement, true
+}
+
+// execute runs a complete meta-command or query.
+func (s *shell) execute(statement string) error {
+	if strings.HasPrefix(statement, `\`) {
+		return s.executeMetaCommand(statement)
+	}
+
+	query := strings.TrimSpace(strings.TrimSuffix(statement, shellStatementEnding))
+	if query == "" {
+		return nil
+	}
+
+	start := time.Now()
+
+	result, err := s.client.QueryWithContext(context.Background(), s.accountID, nrdb.NRQL(query))
+	if err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
+
+	recordQuery(s.history, s.accountID, query)
+
+	if err := output.SetFormat(s.format); err != nil {
+		return err
+	}
+
+	if err := output.Print(formatResults(result, s.format, false, false)); err != nil {
+		return err
+	}
+
+	if s.timing {
+		output.Printf("Time: %s", elapsed.Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+func (s *shell) executeMetaCommand(statement string) error {
+	fields := strings.Fields(strings.TrimSuffix(statement, shellStatementEnding))
+	command := strings.TrimPrefix(fields[0], `\`)
+	args := fields[1:]
+
+	switch command {
+	case "q", "quit", "exit":
+		return errShellExit
+	case "?", "h", "help":
+		output.Text(shellHelp)
+	case "account":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: \\account <id>, currently using account %d", s.accountID)
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid account ID: %s", args[0])
+		}
+
+		s.accountID = id
+		output.Printf("Using account %d", s.accountID)
+	case "format":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: \\format <format>, currently using %s", s.format)
+		}
+
+		format, err := parseFormatStrict(args[0])
+		if err != nil {
+			return err
+		}
+
+		s.format = format
+		output.Printf("Output format is %s", s.format)
+	case "timing":
+		s.timing = !s.timing
+		output.Printf("Timing is %s", onOff(s.timing))
+	default:
+		return fmt.Errorf("unknown command \\%s, try \\help", command)
+	}
+
+	return nil
+}
+
+func (s *shell) currentAccountID() int {
+	return s.accountID
+}
+
+func (s *shell) pendingStatement() string {
+	return strings.Join(s.pending, " ")
+}
+
+// parseFormatStrict parses an output format name, returning an error rather
+// than falling back to the default format when the name is not recognized.
+func parseFormatStrict(name string) (output.Format, error) {
+	format := output.ParseFormat(name)
+
+	if !strings.EqualFold(format.String(), name) {
+		return format, fmt.Errorf("unknown format %s, please use one of: %s", name, output.FormatOptions())
+	}
+
+	return format, nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+
+	return "off"
+}
+
+func saveHistory(rl *readline.Instance, statement string) {
+	if err := rl.SaveHistory(statement); err != nil {
+		log.Debugf("unable to save history: %s", err)
+	}
+}
@@ -0,0 +1,128 @@
+// +build unit
+
+package nrql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+func TestShellFeedMultiline(t *testing.T) {
+	s := newShell(NewMockNRDBClient(), 1, output.FormatText)
+
+	statement, complete := s.feed("SELECT count(*)")
+	assert.False(t, complete)
+	assert.Equal(t, "", statement)
+
+	_, complete = s.feed("")
+	assert.False(t, complete)
+
+	statement, complete = s.feed("  FROM Transaction;")
+	assert.True(t, complete)
+	assert.Equal(t, "SELECT count(*) FROM Transaction;", statement)
+	assert.Empty(t, s.pending)
+}
+
+func TestShellFeedMetaCommand(t *testing.T) {
+	s := newShell(NewMockNRDBClient(), 1, output.FormatText)
+
+	statement, complete := s.feed(`\timing`)
+	assert.True(t, complete)
+	assert.Equal(t, `\timing`, statement)
+
+	// Backslashes inside a pending query are not meta-commands
+	s.feed("SELECT count(*) FROM Log")
+	_, complete = s.feed(`WHERE message LIKE '%\timing%'`)
+	assert.False(t, complete)
+}
+
+func TestShellMetaCommands(t *testing.T) {
+	s := newShell(NewMockNRDBClient(), 1, output.FormatText)
+
+	require.NoError(t, s.execute(`\account 12345`))
+	assert.Equal(t, 12345, s.accountID)
+	assert.Error(t, s.execute(`\account abc`))
+
+	require.NoError(t, s.execute(`\format csv`))
+	assert.Equal(t, output.FormatCSV, s.format)
+	assert.Error(t, s.execute(`\format xml`))
+	assert.Equal(t, output.FormatCSV, s.format)
+
+	require.NoError(t, s.execute(`\timing`))
+	assert.True(t, s.timing)
+
+	assert.Equal(t, errShellExit, s.execute(`\q`))
+	assert.Error(t, s.execute(`\unknown`))
+}
+
+func TestShellExecuteQuery(t *testing.T) {
+	c := NewMockNRDBClient()
+	s := newShell(c, 1, output.FormatJSON)
+
+	require.NoError(t, s.execute(`\account 42`))
+	require.NoError(t, s.execute("SELECT count(*) FROM Transaction;"))
+
+	assert.Equal(t, []nrdb.NRQL{"SELECT count(*) FROM Transaction"}, c.Queries())
+	assert.Equal(t, []int{42}, c.Accounts())
+}
+
+func TestCompleterEventTypes(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ReturnResults(func(accountID int, nrql nrdb.NRQL) []nrdb.NRDBResult {
+		return []nrdb.NRDBResult{
+			{"eventType": "Transaction"},
+			{"eventType": "TransactionError"},
+			{"eventType": "Log"},
+		}
+	})
+
+	completer := newNRQLCompleter(c, func() int { return 1 }, nil)
+
+	line := []rune("SELECT count(*) FROM Trans")
+	matches, length := completer.Do(line, len(line))
+
+	assert.Equal(t, 5, length)
+	assert.ElementsMatch(t, [][]rune{[]rune("action "), []rune("actionError ")}, matches)
+
+	// Event types are cached between completions
+	completer.Do(line, len(line))
+	assert.Len(t, c.Queries(), 1)
+}
+
+func TestCompleterAttributes(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ReturnResults(func(accountID int, nrql nrdb.NRQL) []nrdb.NRDBResult {
+		return []nrdb.NRDBResult{
+			{"key": "appName", "type": "string"},
+			{"key": "appId", "type": "numeric"},
+		}
+	})
+
+	completer := newNRQLCompleter(c, func() int { return 1 }, func() string { return "SELECT count(*) FROM Transaction" })
+
+	line := []rune("FACET ap")
+	matches, length := completer.Do(line, len(line))
+
+	assert.Equal(t, 2, length)
+	assert.Contains(t, matches, []rune("pName "))
+	assert.Contains(t, matches, []rune("pId "))
+	assert.Contains(t, matches, []rune("dex("))
+	assert.Equal(t, []nrdb.NRQL{"SELECT keyset() FROM Transaction"}, c.Queries())
+}
+
+func TestCompleterKeywordCase(t *testing.T) {
+	completer := newNRQLCompleter(NewMockNRDBClient(), func() int { return 1 }, nil)
+
+	line := []rune("sel")
+	matches, _ := completer.Do(line, len(line))
+	assert.Equal(t, [][]rune{[]rune("ect ")}, matches)
+
+	line = []rune("SEL")
+	matches, _ = completer.Do(line, len(line))
+	assert.Equal(t, [][]rune{[]rune("ECT ")}, matches)
+}
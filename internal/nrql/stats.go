@@ -0,0 +1,66 @@
+package nrql
+
+import (
+	"time"
+
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+// queryStats describes how a query was executed, from the result metadata
+// returned by NRDB and the time taken from the client's point of view.
+type queryStats struct {
+	Query       string     `json:"query" yaml:"query"`
+	AccountID   int        `json:"accountId" yaml:"accountId"`
+	EventTypes  []string   `json:"eventTypes" yaml:"eventTypes"`
+	Facets      []string   `json:"facets" yaml:"facets"`
+	Messages    []string   `json:"messages" yaml:"messages"`
+	Begin       *time.Time `json:"begin,omitempty" yaml:"begin,omitempty"`
+	End         *time.Time `json:"end,omitempty" yaml:"end,omitempty"`
+	Since       string     `json:"since,omitempty" yaml:"since,omitempty"`
+	Until       string     `json:"until,omitempty" yaml:"until,omitempty"`
+	CompareWith string     `json:"compareWith,omitempty" yaml:"compareWith,omitempty"`
+	ResultCount int        `json:"resultCount" yaml:"resultCount"`
+	ElapsedMs   int64      `json:"elapsedMs" yaml:"elapsedMs"`
+}
+
+// queryResultWithStats pairs query results with their stats.
+type queryResultWithStats struct {
+	Results interface{} `json:"results" yaml:"results"`
+	Stats   queryStats  `json:"stats" yaml:"stats"`
+}
+
+func newQueryStats(accountID int, query string, result *nrdb.NRDBResultContainer, elapsed time.Duration) queryStats {
+	metadata := result.Metadata
+	window := metadata.TimeWindow
+
+	stats := queryStats{
+		Query:       query,
+		AccountID:   accountID,
+		EventTypes:  nonNilStrings(metadata.EventTypes),
+		Facets:      nonNilStrings(metadata.Facets),
+		Messages:    nonNilStrings(metadata.Messages),
+		Since:       window.Since,
+		Until:       window.Until,
+		CompareWith: window.CompareWith,
+		ResultCount: len(result.Results) + len(result.CurrentResults) + len(result.PreviousResults),
+		ElapsedMs:   elapsed.Milliseconds(),
+	}
+
+	if begin := time.Time(window.Begin); !begin.IsZero() {
+		stats.Begin = &begin
+	}
+
+	if end := time.Time(window.End); !end.IsZero() {
+		stats.End = &end
+	}
+
+	return stats
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
@@ -0,0 +1,61 @@
+// +build unit
+
+package nrql
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+	"github.com/newrelic/newrelic-client-go/pkg/nrtime"
+)
+
+func TestNewQueryStats(t *testing.T) {
+	begin := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := begin.Add(time.Hour)
+
+	result := &nrdb.NRDBResultContainer{
+		CurrentResults:  []nrdb.NRDBResult{{"count": 2}},
+		PreviousResults: []nrdb.NRDBResult{{"count": 1}},
+		Metadata: nrdb.NRDBMetadata{
+			EventTypes: []string{"Transaction"},
+			Messages:   []string{"Your query's time range was limited"},
+			TimeWindow: nrdb.NRDBMetadataTimeWindow{
+				Begin:       nrtime.EpochMilliseconds(begin),
+				End:         nrtime.EpochMilliseconds(end),
+				Since:       "60 MINUTES AGO",
+				Until:       "NOW",
+				CompareWith: "1 DAY AGO",
+			},
+		},
+	}
+
+	stats := newQueryStats(1, "SELECT count(*) FROM Transaction COMPARE WITH 1 day ago", result, 1500*time.Millisecond)
+
+	assert.Equal(t, 1, stats.AccountID)
+	assert.Equal(t, []string{"Transaction"}, stats.EventTypes)
+	assert.Equal(t, []string{}, stats.Facets)
+	assert.Equal(t, []string{"Your query's time range was limited"}, stats.Messages)
+	assert.Equal(t, begin, *stats.Begin)
+	assert.Equal(t, end, *stats.End)
+	assert.Equal(t, "60 MINUTES AGO", stats.Since)
+	assert.Equal(t, "1 DAY AGO", stats.CompareWith)
+	assert.Equal(t, 2, stats.ResultCount)
+	assert.Equal(t, int64(1500), stats.ElapsedMs)
+}
+
+func TestQueryStatsJSON(t *testing.T) {
+	stats := newQueryStats(1, "SELECT 1", &nrdb.NRDBResultContainer{}, time.Millisecond)
+
+	b, err := json.Marshal(queryResultWithStats{Results: []nrdb.NRDBResult{}, Stats: stats})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"results": [],
+		"stats": {"query": "SELECT 1", "accountId": 1, "eventTypes": [], "facets": [], "messages": [], "resultCount": 0, "elapsedMs": 1}
+	}`, string(b))
+}
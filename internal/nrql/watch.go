@@ -0,0 +1,186 @@
+package nrql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+const clearScreen = "\033[H\033[2J"
+
+var (
+	changedValueColors = text.Colors{text.FgYellow, text.Bold}
+	addedRowColors     = text.Colors{text.FgGreen}
+)
+
+// watchSnapshot is a single iteration of a watched query, as emitted in
+// NDJSON mode.
+type watchSnapshot struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Results   []nrdb.NRDBResult `json:"results,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// watcher re-executes a query on an interval until its context is cancelled.
+type watcher struct {
+	client    nrdbClient
+	accountID int
+	query     nrdb.NRQL
+	interval  time.Duration
+	out       io.Writer
+	tty       bool
+
+	previous *resultTable
+}
+
+func newWatcher(client nrdbClient, accountID int, query nrdb.NRQL, interval time.Duration, out io.Writer, tty bool) *watcher {
+	return &watcher{
+		client:    client,
+		accountID: accountID,
+		query:     query,
+		interval:  interval,
+		out:       out,
+		tty:       tty,
+	}
+}
+
+// Run executes the query immediately and then once per interval.
+func (w *watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.iterate(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+			continue
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (w *watcher) iterate(ctx context.Context) error {
+	snapshot := watchSnapshot{Timestamp: time.Now()}
+
+	result, err := w.client.QueryWithContext(ctx, w.accountID, w.query)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if err != nil {
+		snapshot.Error = err.Error()
+	} else {
+		snapshot.Results = result.Results
+	}
+
+	if w.tty {
+		return w.redraw(snapshot, result)
+	}
+
+	return w.emit(snapshot)
+}
+
+// emit writes the snapshot as a single line of JSON.
+func (w *watcher) emit(snapshot watchSnapshot) error {
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w.out, string(b))
+
+	return err
+}
+
+// redraw clears the terminal and renders the snapshot as a table, with
+// values that changed since the previous iteration highlighted.
+func (w *watcher) redraw(snapshot watchSnapshot, result *nrdb.NRDBResultContainer) error {
+	fmt.Fprint(w.out, clearScreen)
+	fmt.Fprintf(w.out, "Every %s: %s\t%s\n\n", w.interval, w.query, snapshot.Timestamp.Format(time.RFC1123))
+
+	if snapshot.Error != "" {
+		fmt.Fprintln(w.out, text.FgRed.Sprint(snapshot.Error))
+		return nil
+	}
+
+	current := flattenResults(result)
+	highlighted := highlightChanges(w.previous, current)
+	w.previous = &current
+
+	return output.FprintTable(w.out, highlighted)
+}
+
+// highlightChanges returns a copy of current with the cells that differ from
+// the previous table colored.  Rows are matched on their key columns, such as
+// the timestamp and facet values, and on their position when there are none.
+func highlightChanges(previous *resultTable, current resultTable) output.Table {
+	highlighted := output.Table{
+		Columns: current.Columns,
+		Rows:    make([][]interface{}, len(current.Rows)),
+	}
+
+	var previousRows map[string][]interface{}
+	var previousColumns map[string]int
+
+	if previous != nil {
+		previousRows = map[string][]interface{}{}
+		for i, row := range previous.Rows {
+			previousRows[rowKey(row, previous.Keys, i)] = row
+		}
+
+		previousColumns = map[string]int{}
+		for i, col := range previous.Columns {
+			previousColumns[col] = i
+		}
+	}
+
+	for i, row := range current.Rows {
+		out := make([]interface{}, len(row))
+		copy(out, row)
+
+		if previous != nil {
+			prev, found := previousRows[rowKey(row, current.Keys, i)]
+
+			for c, value := range row {
+				switch {
+				case !found:
+					out[c] = addedRowColors.Sprint(value)
+				case !reflect.DeepEqual(value, previousValue(prev, previousColumns, current.Columns[c])):
+					out[c] = changedValueColors.Sprint(value)
+				}
+			}
+		}
+
+		highlighted.Rows[i] = out
+	}
+
+	return highlighted
+}
+
+func rowKey(row []interface{}, keys int, index int) string {
+	if keys == 0 || keys > len(row) {
+		return fmt.Sprint(index)
+	}
+
+	return fmt.Sprintf("%v", row[:keys])
+}
+
+func previousValue(row []interface{}, columns map[string]int, column string) interface{} {
+	if i, ok := columns[column]; ok && i < len(row) {
+		return row[i]
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+// +build unit
+
+package nrql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-client-go/pkg/nrdb"
+)
+
+func TestWatcherEmitsNDJSON(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ReturnResults(func(accountID int, nrql nrdb.NRQL) []nrdb.NRDBResult {
+		return []nrdb.NRDBResult{{"count": float64(len(c.queries))}}
+	})
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := newWatcher(c, 1, "SELECT count(*) FROM Transaction", 10*time.Millisecond, &buf, false)
+
+	go func() {
+		for len(c.Queries()) < 3 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	require.NoError(t, w.Run(ctx))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.GreaterOrEqual(t, len(lines), 3)
+
+	var snapshot watchSnapshot
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &snapshot))
+	assert.Equal(t, []nrdb.NRDBResult{{"count": float64(1)}}, snapshot.Results)
+	assert.False(t, snapshot.Timestamp.IsZero())
+}
+
+func TestWatcherEmitsErrors(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ThrowError("query failed")
+
+	var buf bytes.Buffer
+	w := newWatcher(c, 1, "SELECT", time.Second, &buf, false)
+
+	require.NoError(t, w.iterate(context.Background()))
+	assert.Contains(t, buf.String(), `"error":"query failed"`)
+}
+
+func TestHighlightChanges(t *testing.T) {
+	previous := resultTable{
+		Table: output.Table{
+			Columns: []string{"facet", "count"},
+			Rows:    [][]interface{}{{"a", 1}, {"b", 2}},
+		},
+		Keys:   1,
+		Facets: 1,
+	}
+
+	current := resultTable{
+		Table: output.Table{
+			Columns: []string{"facet", "count"},
+			Rows:    [][]interface{}{{"b", 3}, {"a", 1}, {"c", 4}},
+		},
+		Keys:   1,
+		Facets: 1,
+	}
+
+	// Nothing is highlighted on the first iteration
+	assert.Equal(t, current.Table, highlightChanges(nil, current))
+
+	highlighted := highlightChanges(&previous, current)
+
+	assert.Equal(t, "b", highlighted.Rows[0][0])
+	assert.Equal(t, changedValueColors.Sprint(3), highlighted.Rows[0][1])
+	assert.Equal(t, []interface{}{"a", 1}, highlighted.Rows[1])
+	assert.Equal(t, text.FgGreen.Sprint("c"), highlighted.Rows[2][0])
+}
+
+func TestWatcherRedraws(t *testing.T) {
+	c := NewMockNRDBClient()
+	c.ReturnResults(func(accountID int, nrql nrdb.NRQL) []nrdb.NRDBResult {
+		return []nrdb.NRDBResult{{"count": float64(42)}}
+	})
+
+	var buf bytes.Buffer
+	w := newWatcher(c, 1, "SELECT count(*) FROM Transaction", time.Second, &buf, true)
+
+	require.NoError(t, w.iterate(context.Background()))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, clearScreen))
+	assert.Contains(t, out, "Every 1s: SELECT count(*) FROM Transaction")
+	assert.Contains(t, out, "count")
+	assert.Contains(t, out, "42")
+}
@@ -0,0 +1,218 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// csv prints out data as comma separated values, with a header row
+// built from the struct field names or map keys.
+func (o *Output) csv(data interface{}) error {
+	// Early quit on no data
+	if data == nil {
+		return nil
+	}
+
+	if o == nil {
+		return errors.New("invalid output formatter")
+	}
+
+	if s, ok := data.(string); ok {
+		fmt.Println(s)
+		return nil
+	}
+
+	header, rows, err := tabulate(data)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(os.Stdout)
+
+	if err = w.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = CSVValue(v)
+		}
+
+		if err = w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// CSVValue renders a single CSV cell, encoding nested values as JSON
+func CSVValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+
+		v = rv.Elem().Interface()
+	}
+
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	// Avoid exponent notation for large whole numbers, such as timestamps
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		b, err := json.Marshal(v)
+		if err == nil {
+			return string(b)
+		}
+	}
+
+	return fmt.Sprint(v)
+}
+
+// tabulate converts a Table, a struct, a map or a slice of either into a
+// header and a list of rows.  Columns for maps are the sorted union of all keys.
+func tabulate(data interface{}) ([]string, [][]interface{}, error) {
+	switch t := data.(type) {
+	case Table:
+		return t.Columns, t.Rows, nil
+	case *Table:
+		return t.Columns, t.Rows, nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(data))
+
+	var items []reflect.Value
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, indirect(v.Index(i)))
+		}
+	case reflect.Struct, reflect.Map:
+		items = append(items, v)
+	default:
+		return nil, nil, fmt.Errorf("unable to format data as a table - type: %T", data)
+	}
+
+	if len(items) == 0 {
+		return []string{}, [][]interface{}{}, nil
+	}
+
+	switch items[0].Kind() {
+	case reflect.Struct:
+		return tabulateStructs(items)
+	case reflect.Map:
+		return tabulateMaps(items)
+	default:
+		return nil, nil, fmt.Errorf("unable to format data as a table - type: %T", data)
+	}
+}
+
+func tabulateStructs(items []reflect.Value) ([]string, [][]interface{}, error) {
+	typ := items[0].Type()
+
+	header := []string{}
+	fields := []int{}
+
+	for i := 0; i < typ.NumField(); i++ {
+		// Skip unexported fields
+		if typ.Field(i).PkgPath != "" {
+			continue
+		}
+
+		header = append(header, typ.Field(i).Name)
+		fields = append(fields, i)
+	}
+
+	rows := make([][]interface{}, len(items))
+
+	for r, item := range items {
+		if item.Type() != typ {
+			return nil, nil, fmt.Errorf("unable to format mixed types as a table: %s and %s", typ, item.Type())
+		}
+
+		row := make([]interface{}, len(fields))
+		for c, f := range fields {
+			row[c] = item.Field(f).Interface()
+		}
+
+		rows[r] = row
+	}
+
+	return header, rows, nil
+}
+
+func tabulateMaps(items []reflect.Value) ([]string, [][]interface{}, error) {
+	seen := map[string]bool{}
+	header := []string{}
+
+	for _, item := range items {
+		if item.Kind() != reflect.Map {
+			return nil, nil, fmt.Errorf("unable to format mixed types as a table: %s and %s", items[0].Type(), item.Type())
+		}
+
+		for _, k := range item.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			if !seen[key] {
+				seen[key] = true
+				header = append(header, key)
+			}
+		}
+	}
+
+	sort.Strings(header)
+
+	rows := make([][]interface{}, len(items))
+
+	for r, item := range items {
+		row := make([]interface{}, len(header))
+		values := map[string]interface{}{}
+
+		iter := item.MapRange()
+		for iter.Next() {
+			values[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+		}
+
+		for c, key := range header {
+			row[c] = values[key]
+		}
+
+		rows[r] = row
+	}
+
+	return header, rows, nil
+}
+
+// indirect follows pointers and interfaces down to the underlying value
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
@@ -0,0 +1,68 @@
+// +build unit
+
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTabulateMaps(t *testing.T) {
+	data := []map[string]interface{}{
+		{"name": "a", "count": 1},
+		{"name": "b", "other": true},
+	}
+
+	header, rows, err := tabulate(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"count", "name", "other"}, header)
+	assert.Equal(t, [][]interface{}{{1, "a", nil}, {nil, "b", true}}, rows)
+}
+
+func TestTabulateStructs(t *testing.T) {
+	type item struct {
+		Name  string
+		Count int
+	}
+
+	header, rows, err := tabulate([]*item{{Name: "a", Count: 1}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Name", "Count"}, header)
+	assert.Equal(t, [][]interface{}{{"a", 1}}, rows)
+}
+
+func TestTabulateUnsupported(t *testing.T) {
+	_, _, err := tabulate(42)
+	assert.Error(t, err)
+}
+
+func TestCSVValue(t *testing.T) {
+	assert.Equal(t, "", CSVValue(nil))
+	assert.Equal(t, "1.5", CSVValue(1.5))
+	assert.Equal(t, "1600000000000", CSVValue(float64(1600000000000)))
+	assert.Equal(t, `["a","b"]`, CSVValue([]string{"a", "b"}))
+	assert.Equal(t, `{"k":"v"}`, CSVValue(map[string]string{"k": "v"}))
+
+	ts := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2021-01-01T00:00:00Z", CSVValue(ts))
+	assert.Equal(t, "2021-01-01T00:00:00Z", CSVValue(&ts))
+	assert.Equal(t, "", CSVValue((*time.Time)(nil)))
+}
+
+func TestTabulateTable(t *testing.T) {
+	table := Table{
+		Columns: []string{"b", "a"},
+		Rows:    [][]interface{}{{1, 2}},
+	}
+
+	header, rows, err := tabulate(&table)
+	require.NoError(t, err)
+
+	assert.Equal(t, table.Columns, header)
+	assert.Equal(t, table.Rows, rows)
+}
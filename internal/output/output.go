@@ -22,13 +22,14 @@ const (
 	FormatJSON Format = iota
 	FormatText
 	FormatYAML
-	//FormatCSV
+	FormatCSV
 )
 
 var formatStrings = map[Format]string{
 	FormatJSON: "JSON",
 	FormatText: "Text",
 	FormatYAML: "YAML",
+	FormatCSV:  "CSV",
 }
 
 // Output is the main ref for the output package
@@ -78,6 +79,15 @@ func SetFormat(format Format) (err error) {
 	return nil
 }
 
+// CurrentFormat returns the format that Print will use
+func CurrentFormat() Format {
+	if globalOutput == nil {
+		return DefaultFormat
+	}
+
+	return globalOutput.format
+}
+
 func SetPrettyPrint(pretty bool) (err error) {
 	if err = ensureGlobalOutput(); err != nil {
 		return err
@@ -19,6 +19,8 @@ func Print(data interface{}) (err error) {
 		err = globalOutput.text(data)
 	case FormatYAML:
 		err = globalOutput.yaml(data)
+	case FormatCSV:
+		err = globalOutput.csv(data)
 	default:
 		err = globalOutput.json(data)
 	}
@@ -49,6 +51,13 @@ func Text(data interface{}) {
 	utils.LogIfFatal(globalOutput.text(data))
 }
 
+// CSV allows you to override the default output method and
+// explicitly print CSV to the screen
+func CSV(data interface{}) {
+	utils.LogIfFatal(ensureGlobalOutput())
+	utils.LogIfFatal(globalOutput.csv(data))
+}
+
 // YAML allows you to override the default output method and
 // explicitly print YAML to the screen
 func YAML(data interface{}) {
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Table is tabular data with an explicit column order.  It is rendered as a
+// table in text output, as a header and records in CSV output, and as a list
+// of objects keyed by column name in JSON and YAML output.
+type Table struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Records returns the rows of the table as maps keyed by column name.
+func (t Table) Records() []map[string]interface{} {
+	records := make([]map[string]interface{}, len(t.Rows))
+
+	for i, row := range t.Rows {
+		record := make(map[string]interface{}, len(t.Columns))
+		for c, col := range t.Columns {
+			if c < len(row) {
+				record[col] = row[c]
+			}
+		}
+
+		records[i] = record
+	}
+
+	return records
+}
+
+// MarshalJSON encodes the table as a list of objects.
+func (t Table) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Records())
+}
+
+// MarshalYAML encodes the table as a list of maps, preserving column order.
+func (t Table) MarshalYAML() (interface{}, error) {
+	records := make([]yaml.MapSlice, len(t.Rows))
+
+	for i, row := range t.Rows {
+		record := make(yaml.MapSlice, 0, len(t.Columns))
+		for c, col := range t.Columns {
+			if c < len(row) {
+				record = append(record, yaml.MapItem{Key: col, Value: row[c]})
+			}
+		}
+
+		records[i] = record
+	}
+
+	return records, nil
+}
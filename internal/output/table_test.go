@@ -0,0 +1,43 @@
+// +build unit
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestTableMarshal(t *testing.T) {
+	table := Table{
+		Columns: []string{"name", "count"},
+		Rows: [][]interface{}{
+			{"a", 1},
+			{"b"},
+		},
+	}
+
+	j, err := json.Marshal(table)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name":"a","count":1},{"name":"b"}]`, string(j))
+
+	y, err := yaml.Marshal(table)
+	require.NoError(t, err)
+	assert.Equal(t, "- name: a\n  count: 1\n- name: b\n", string(y))
+}
+
+func TestFprintTable(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, FprintTable(&buf, Table{
+		Columns: []string{"name", "count"},
+		Rows:    [][]interface{}{{"a", 1}},
+	}))
+
+	assert.Contains(t, buf.String(), "name")
+	assert.Contains(t, buf.String(), "a")
+}
@@ -3,6 +3,7 @@ package output
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 
@@ -20,11 +21,16 @@ func (o *Output) text(data interface{}) error {
 		return errors.New("invalid output formatter")
 	}
 
+	switch data.(type) {
+	case Table, *Table:
+		return o.renderTabulated(o.newTableWriter(), data)
+	}
+
 	// Let's see what they sent us
 	switch v := reflect.ValueOf(data); v.Kind() {
 	case reflect.String:
 		fmt.Println(data)
-	case reflect.Slice, reflect.Struct:
+	case reflect.Slice, reflect.Struct, reflect.Map:
 		return o.renderAsTable(data)
 	default:
 		return fmt.Errorf("unable to format data type: %T", data)
@@ -51,6 +57,11 @@ func (o *Output) renderAsTable(data interface{}) error {
 		// Create the header from the field names
 		typ := reflect.TypeOf(data).Elem()
 
+		// Slices of maps or interfaces have no fixed set of fields
+		if typ.Kind() != reflect.Struct {
+			return o.renderTabulated(tw, data)
+		}
+
 		cols := typ.NumField()
 		header := make([]interface{}, cols)
 		colConfig := make([]table.ColumnConfig, cols)
@@ -88,6 +99,10 @@ func (o *Output) renderAsTable(data interface{}) error {
 			tw.AppendRow(table.Row(row))
 		}
 
+	// Single Map becomes a one row table keyed by the map keys
+	case reflect.Map:
+		return o.renderTabulated(tw, data)
+
 	default:
 		return fmt.Errorf("unable to format data as table - type: %T", data)
 	}
@@ -97,6 +112,49 @@ func (o *Output) renderAsTable(data interface{}) error {
 	return nil
 }
 
+// renderTabulated renders data without a fixed set of fields, such as
+// a slice of maps, using the columns discovered by tabulate.
+func (o *Output) renderTabulated(tw table.Writer, data interface{}) error {
+	header, rows, err := tabulate(data)
+	if err != nil {
+		return err
+	}
+
+	headerRow := make(table.Row, len(header))
+	colConfig := make([]table.ColumnConfig, len(header))
+
+	for i, h := range header {
+		headerRow[i] = h
+		colConfig[i].Name = h
+		colConfig[i].WidthMin = len(h)
+		colConfig[i].WidthMax = o.terminalWidth * 3 / 4
+		colConfig[i].WidthMaxEnforcer = text.WrapSoft
+	}
+
+	tw.SetColumnConfigs(colConfig)
+	tw.AppendHeader(headerRow)
+
+	for _, r := range rows {
+		tw.AppendRow(table.Row(r))
+	}
+
+	tw.Render()
+
+	return nil
+}
+
+// FprintTable renders a table as text to w instead of stdout.
+func FprintTable(w io.Writer, t Table) error {
+	if err := ensureGlobalOutput(); err != nil {
+		return err
+	}
+
+	tw := globalOutput.newTableWriter()
+	tw.SetOutputMirror(w)
+
+	return globalOutput.renderTabulated(tw, t)
+}
+
 func (o *Output) newTableWriter() table.Writer {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
@@ -1,20 +1,24 @@
 // Package pipe provides a simple API to read and retrieve values
 // from stdin to use in Cobra commands. Public API consists of
 // GetInput, which reads stdin, Exists, which checks for value
-// existence, and Get for retrieving existing values.
+// existence, Get for retrieving existing values, and ReadAll for
+// retrieving the raw content of stdin.
 package pipe
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 
-	"github.com/tidwall/gjson"
+	log "github.com/sirupsen/logrus"
 
-	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/tidwall/gjson"
 )
 
 // Created Interface and struct to surround io.Reader for easy mocking
@@ -42,6 +46,44 @@ func (spr stdinPipeReader) ReadPipe() (string, error) {
 	return strings.TrimSpace(text), err
 }
 
+// bufferedStdin reads stdin in full on first use and keeps its content, since
+// stdin can only be read once but is used both by GetInput and ReadAll.
+type bufferedStdin struct {
+	input   io.Reader
+	once    sync.Once
+	content []byte
+	err     error
+}
+
+func (b *bufferedStdin) Bytes() ([]byte, error) {
+	b.once.Do(func() {
+		b.content, b.err = ioutil.ReadAll(b.input)
+	})
+
+	return b.content, b.err
+}
+
+// bufferedStdinReader is an io.Reader over the content of a bufferedStdin.
+type bufferedStdinReader struct {
+	stdin  *bufferedStdin
+	reader *bytes.Reader
+}
+
+func (r *bufferedStdinReader) Read(p []byte) (int, error) {
+	if r.reader == nil {
+		content, err := r.stdin.Bytes()
+		if err != nil {
+			return 0, err
+		}
+
+		r.reader = bytes.NewReader(content)
+	}
+
+	return r.reader.Read(p)
+}
+
+var stdin = &bufferedStdin{input: os.Stdin}
+
 func jsonToFilteredMap(r string, selectors []string) ([]map[string]string, error) {
 	text := r
 
@@ -104,7 +146,9 @@ func getPipeInputInnerFunc(pipe pipeReader, pipeInputExists bool, acceptedPipeIn
 		pipeInputMap := map[string][]string{}
 		inputArray, err := readStdin(pipe, acceptedPipeInput)
 		if err != nil {
-			utils.LogIfError(err)
+			// Commands may also read stdin for other purposes, such as a
+			// query, so input that isn't JSON is not an error here
+			log.Debugf("ignoring stdin: %s", err)
 			return map[string][]string{}
 		}
 		for _, key := range acceptedPipeInput {
@@ -137,7 +181,7 @@ var pipeInput map[string][]string
 // stores those desired json values from stdin. The existence of and values
 // of those stdin json keys can then be retrieved using the public Exists and
 // Get methods, respectively.
-var GetInput = getPipeInputFactory(stdinPipeReader{input: os.Stdin}, pipeInputExists)
+var GetInput = getPipeInputFactory(stdinPipeReader{input: &bufferedStdinReader{stdin: stdin}}, pipeInputExists)
 
 // Get is the only API provided to retrieve values from stdin json. Get
 // is designed to be used in the cobra command itself, when any required
@@ -160,3 +204,14 @@ func Exists(inputKey string) bool {
 	_, ok := Get(inputKey)
 	return ok
 }
+
+// ReadAll returns the raw content of stdin, for commands that accept input
+// such as a query or file content from stdin.  It returns an error when
+// nothing has been piped to stdin.
+func ReadAll() ([]byte, error) {
+	if !pipeInputExists() {
+		return nil, errors.New("nothing was piped to stdin")
+	}
+
+	return stdin.Bytes()
+}
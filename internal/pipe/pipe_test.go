@@ -461,3 +461,15 @@ func TestGet(t *testing.T) {
 		assert.Equal(t, c.ExpectedValue, value)
 	}
 }
+
+func TestBufferedStdin(t *testing.T) {
+	buffered := &bufferedStdin{input: strings.NewReader(`{ "guid": "abc" }`)}
+
+	result, err := stdinPipeReader{input: &bufferedStdinReader{stdin: buffered}}.ReadPipe()
+	assert.NoError(t, err)
+	assert.Equal(t, `{ "guid": "abc" }`, result)
+
+	content, err := buffered.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, `{ "guid": "abc" }`, string(content))
+}
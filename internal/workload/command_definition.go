@@ -0,0 +1,168 @@
+package workload
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/entities/resolver"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+)
+
+var (
+	definitionPath string
+	failOnDrift    bool
+)
+
+var cmdExport = &cobra.Command{
+	Use:   "export",
+	Short: "Export a New Relic One workload as a YAML definition.",
+	Long: `Export a New Relic One workload as a YAML definition
+
+The export command retrieves a workload by its GUID and prints it as a YAML
+definition of its name, account, entity GUIDs, entity search queries and scope
+accounts, which can be kept in version control and applied with 'workload apply'.
+The account ID is taken from the workload GUID unless given.
+`,
+	Example: `newrelic workload export --guid 'MjUyMDUyOHxBOE28QVBQTElDQVRDT058MjE1MDM3Nzk1' > payments.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			id := accountID
+			if id == 0 {
+				var err error
+				id, err = workloadAccountID(guid)
+				utils.LogIfFatal(err)
+			}
+
+			workload, err := nrClient.Workloads.GetWorkload(id, guid)
+			utils.LogIfFatal(err)
+
+			content, err := yaml.Marshal(exportWorkload(workload))
+			utils.LogIfFatal(err)
+
+			fmt.Print(string(content))
+		})
+	},
+}
+
+var cmdPlan = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the changes needed to match a workload definition.",
+	Long: `Show the changes needed to match a workload definition
+
+The plan command compares a YAML workload definition with the workload of the same
+name in its account, and shows the entity GUIDs, entity search queries and scope
+accounts 'workload apply' would add and remove.  A definition looks like:
+
+  name: Payments
+  accountId: 12345678
+  entityGuids:
+    - MjUyMDUyOHxBUE18QVBQTElDQVRJT058MjE1MDM3Nzk1
+    - name=checkout
+  entitySearchQueries:
+    - "tags.team = 'payments'"
+  scopeAccountIds: [12345678]
+
+Entities can be given by name as name=<name>.  Fields that are left out are left
+alone, while empty lists remove every value.  With --fail-on-drift, the command
+exits with a non-zero status when the workload does not match the definition.
+`,
+	Example: `newrelic workload plan -f payments.yaml --fail-on-drift`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			change, err := planWorkloadDefinition(nrClient, definitionPath)
+			utils.LogIfFatal(err)
+
+			printWorkloadChange(change)
+
+			if failOnDrift && change.Action != workloadActionNone {
+				log.Fatalf("workload %s has drifted from %s", change.Name, definitionPath)
+			}
+		})
+	},
+}
+
+var cmdApply = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update a New Relic One workload from a YAML definition.",
+	Long: `Create or update a New Relic One workload from a YAML definition
+
+The apply command creates the workload of a YAML definition, or updates the
+workload of the same name in its account to match it, after showing the changes
+made as 'workload plan' does.  Nothing is changed when the workload already
+matches the definition, so applying a definition again is safe.
+`,
+	Example: `newrelic workload apply -f payments.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			change, err := planWorkloadDefinition(nrClient, definitionPath)
+			utils.LogIfFatal(err)
+
+			printWorkloadChange(change)
+
+			if change.Action == workloadActionNone {
+				return
+			}
+
+			workloadGUID, err := applyWorkloadChange(utils.SignalCtx, &nrClient.Workloads, &nrClient.NerdGraph, change)
+			utils.LogIfFatal(err)
+
+			log.Infof("%sd workload %s (%s)", change.Action, change.Name, workloadGUID)
+		})
+	},
+}
+
+// planWorkloadDefinition reads a workload definition, resolves the entities
+// it gives by name and compares it with the workload of the same name.
+func planWorkloadDefinition(nrClient *newrelic.NewRelic, path string) (*workloadChange, error) {
+	def, err := readWorkloadDefinition(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(def.EntityGUIDs) > 0 {
+		def.EntityGUIDs, err = resolver.New(&nrClient.NerdGraph).ResolveAll(utils.SignalCtx, def.EntityGUIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := listWorkloads(&nrClient.Workloads, def.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return planWorkload(existing, *def)
+}
+
+// printWorkloadChange prints a change as a diff.  Other formats print the
+// change itself.
+func printWorkloadChange(change *workloadChange) {
+	if output.CurrentFormat() != output.FormatText {
+		utils.LogIfFatal(output.Print(change))
+		return
+	}
+
+	fmt.Print(change)
+}
+
+func init() {
+	Command.AddCommand(cmdExport)
+	cmdExport.Flags().StringVarP(&guid, "guid", "g", "", "the GUID of the workload to export")
+	cmdExport.Flags().IntVarP(&accountID, "accountId", "a", 0, "the New Relic account ID where the workload is located, taken from the GUID by default")
+	utils.LogIfError(cmdExport.MarkFlagRequired("guid"))
+
+	Command.AddCommand(cmdPlan)
+	cmdPlan.Flags().StringVarP(&definitionPath, "file", "f", "", "the YAML file of the workload definition")
+	cmdPlan.Flags().BoolVar(&failOnDrift, "fail-on-drift", false, "exit with a non-zero status when the workload does not match the definition")
+	utils.LogIfError(cmdPlan.MarkFlagRequired("file"))
+
+	Command.AddCommand(cmdApply)
+	cmdApply.Flags().StringVarP(&definitionPath, "file", "f", "", "the YAML file of the workload definition")
+	utils.LogIfError(cmdApply.MarkFlagRequired("file"))
+}
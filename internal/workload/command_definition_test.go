@@ -0,0 +1,32 @@
+// +build unit
+
+package workload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestExport(t *testing.T) {
+	assert.Equal(t, "export", cmdExport.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdExport)
+	testcobra.CheckCobraRequiredFlags(t, cmdExport, []string{})
+}
+
+func TestPlan(t *testing.T) {
+	assert.Equal(t, "plan", cmdPlan.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdPlan)
+	testcobra.CheckCobraRequiredFlags(t, cmdPlan, []string{})
+}
+
+func TestApply(t *testing.T) {
+	assert.Equal(t, "apply", cmdApply.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdApply)
+	testcobra.CheckCobraRequiredFlags(t, cmdApply, []string{})
+}
@@ -0,0 +1,100 @@
+package workload
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/output"
+	"github.com/newrelic/newrelic-cli/internal/utils"
+	"github.com/newrelic/newrelic-client-go/newrelic"
+)
+
+var (
+	generateTag          string
+	generateNameTemplate string
+	generatePrune        bool
+	generateDryRun       bool
+)
+
+var cmdGenerate = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a New Relic One workload for each value of a tag.",
+	Long: `Generate a New Relic One workload for each value of a tag
+
+The generate command finds the distinct values of a tag across the entities of an
+account, and creates or updates one dynamic workload per value, made up of the
+entities found by an entity search query such as tags.team = 'payments'.  The
+workloads are named from a Go template given the value as {{.value}} and the tag
+key as {{.tag}}, and are matched to existing workloads by name, so generating
+workloads again only changes the workloads that are out of date.
+
+With --prune, the workloads generated for values that no longer exist are deleted.
+A workload is taken to be generated when its only entity search query is the query
+of a value of the tag and it is named after that value by the template, so other
+workloads are never deleted.  Use --dry-run to show the changes without making
+them.
+`,
+	Example: `newrelic workload generate --tag team --accountId 12345678 --name-template "{{.value}} services"
+newrelic workload generate --tag team --accountId 12345678 --prune --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		client.WithClient(func(nrClient *newrelic.NewRelic) {
+			g, err := newWorkloadGenerator(accountID, generateTag, generateNameTemplate)
+			utils.LogIfFatal(err)
+
+			values, err := tagValues(utils.SignalCtx, &nrClient.NerdGraph, accountID, generateTag)
+			utils.LogIfFatal(err)
+
+			existing, err := listWorkloads(&nrClient.Workloads, accountID)
+			utils.LogIfFatal(err)
+
+			changes, err := planGeneratedWorkloads(g, existing, values, generatePrune)
+			utils.LogIfFatal(err)
+
+			printWorkloadChanges(changes)
+
+			if generateDryRun {
+				return
+			}
+
+			for _, change := range changes {
+				if change.Action == workloadActionNone {
+					continue
+				}
+
+				workloadGUID, err := applyWorkloadChange(utils.SignalCtx, &nrClient.Workloads, &nrClient.NerdGraph, change)
+				utils.LogIfFatal(err)
+
+				log.Infof("%sd workload %s (%s)", change.Action, change.Name, workloadGUID)
+			}
+		})
+	},
+}
+
+// printWorkloadChanges prints changes as a diff followed by a summary.
+// Other formats print the changes themselves.
+func printWorkloadChanges(changes []*workloadChange) {
+	if output.CurrentFormat() != output.FormatText {
+		utils.LogIfFatal(output.Print(changes))
+		return
+	}
+
+	for _, c := range changes {
+		fmt.Print(c)
+	}
+
+	fmt.Printf("\nPlan: %s.\n", workloadChangesSummary(changes))
+}
+
+func init() {
+	Command.AddCommand(cmdGenerate)
+	cmdGenerate.Flags().StringVarP(&generateTag, "tag", "t", "", "the tag key to generate a workload for each value of")
+	cmdGenerate.Flags().IntVarP(&accountID, "accountId", "a", 0, "the New Relic account ID to find the tag values in and create the workloads in")
+	cmdGenerate.Flags().StringVar(&generateNameTemplate, "name-template", defaultNameTemplate, "the Go template of the workload names, given the tag value as {{.value}}")
+	cmdGenerate.Flags().BoolVar(&generatePrune, "prune", false, "delete the workloads generated for values that no longer exist")
+	cmdGenerate.Flags().BoolVar(&generateDryRun, "dry-run", false, "show the changes without making them")
+	utils.LogIfError(cmdGenerate.MarkFlagRequired("tag"))
+	utils.LogIfError(cmdGenerate.MarkFlagRequired("accountId"))
+}
@@ -0,0 +1,18 @@
+// +build unit
+
+package workload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/newrelic-cli/internal/testcobra"
+)
+
+func TestGenerate(t *testing.T) {
+	assert.Equal(t, "generate", cmdGenerate.Name())
+
+	testcobra.CheckCobraMetadata(t, cmdGenerate)
+	testcobra.CheckCobraRequiredFlags(t, cmdGenerate, []string{})
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/newrelic/newrelic-cli/internal/client"
+	"github.com/newrelic/newrelic-cli/internal/entities/resolver"
 	"github.com/newrelic/newrelic-cli/internal/output"
 	"github.com/newrelic/newrelic-cli/internal/utils"
 	"github.com/newrelic/newrelic-client-go/newrelic"
@@ -67,8 +68,12 @@ or multiple entity search queries can be provided for dynamic inclusion of entit
 Multiple queries will be aggregated together with an OR.  Multiple account scope
 IDs can optionally be provided to include entities from different sub-accounts that
 you also have access to.
+
+Entities can also be included by name, with an --entityGuid such as name=checkout or
+"name:checkout type:APPLICATION", which must match exactly one entity.
 `,
-	Example: `newrelic workload create --name 'Example workload' --accountId 12345678 --entitySearchQuery "name like 'Example application'"`,
+	Example: `newrelic workload create --name 'Example workload' --accountId 12345678 --entitySearchQuery "name like 'Example application'"
+newrelic workload create --name 'Checkout' --accountId 12345678 --entityGuid "name:checkout type:APPLICATION"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		client.WithClient(func(nrClient *newrelic.NewRelic) {
 			createInput := workloads.CreateInput{
@@ -76,7 +81,10 @@ you also have access to.
 			}
 
 			if len(entityGUIDs) > 0 {
-				createInput.EntityGUIDs = entityGUIDs
+				guids, err := resolver.New(&nrClient.NerdGraph).ResolveAll(utils.SignalCtx, entityGUIDs)
+				utils.LogIfFatal(err)
+
+				createInput.EntityGUIDs = guids
 			}
 
 			if len(entitySearchQueries) > 0 {
@@ -120,7 +128,10 @@ entities from different sub-accounts that you also have access to.
 			}
 
 			if len(entityGUIDs) > 0 {
-				updateInput.EntityGUIDs = entityGUIDs
+				guids, err := resolver.New(&nrClient.NerdGraph).ResolveAll(utils.SignalCtx, entityGUIDs)
+				utils.LogIfFatal(err)
+
+				updateInput.EntityGUIDs = guids
 			}
 
 			if len(entitySearchQueries) > 0 {
@@ -204,7 +215,7 @@ func init() {
 	Command.AddCommand(cmdCreate)
 	cmdCreate.Flags().IntVarP(&accountID, "accountId", "a", 0, "the New Relic account ID where you want to create the workload")
 	cmdCreate.Flags().StringVarP(&name, "name", "n", "", "the name of the workload")
-	cmdCreate.Flags().StringSliceVarP(&entityGUIDs, "entityGuid", "e", []string{}, "the list of entity Guids composing the workload")
+	cmdCreate.Flags().StringSliceVarP(&entityGUIDs, "entityGuid", "e", []string{}, "the list of entity Guids composing the workload, or entity names as name=<name>")
 	cmdCreate.Flags().StringSliceVarP(&entitySearchQueries, "entitySearchQuery", "q", []string{}, "a list of search queries, combined using an OR operator")
 	cmdCreate.Flags().IntSliceVarP(&scopeAccountIDs, "scopeAccountIds", "s", []int{}, "accounts that will be used to get entities from")
 	utils.LogIfError(cmdCreate.MarkFlagRequired("accountId"))
@@ -214,7 +225,7 @@ func init() {
 	Command.AddCommand(cmdUpdate)
 	cmdUpdate.Flags().StringVarP(&guid, "guid", "g", "", "the GUID of the workload you want to update")
 	cmdUpdate.Flags().StringVarP(&name, "name", "n", "", "the name of the workload")
-	cmdUpdate.Flags().StringSliceVarP(&entityGUIDs, "entityGuid", "e", []string{}, "the list of entity Guids composing the workload")
+	cmdUpdate.Flags().StringSliceVarP(&entityGUIDs, "entityGuid", "e", []string{}, "the list of entity Guids composing the workload, or entity names as name=<name>")
 	cmdUpdate.Flags().StringSliceVarP(&entitySearchQueries, "entitySearchQuery", "q", []string{}, "a list of search queries, combined using an OR operator")
 	cmdUpdate.Flags().IntSliceVarP(&scopeAccountIDs, "scopeAccountIds", "s", []int{}, "accounts that will be used to get entities from")
 	utils.LogIfError(cmdUpdate.MarkFlagRequired("guid"))
@@ -0,0 +1,410 @@
+package workload
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	nrErrors "github.com/newrelic/newrelic-client-go/pkg/errors"
+	"github.com/newrelic/newrelic-client-go/pkg/workloads"
+)
+
+const (
+	workloadActionCreate = "create"
+	workloadActionUpdate = "update"
+	workloadActionDelete = "delete"
+	workloadActionNone   = "none"
+)
+
+// workloadDefinition is a workload as kept in a YAML file.  Fields that are
+// left out are not managed, so their current values are kept, while empty
+// lists remove every value.
+type workloadDefinition struct {
+	Name                string   `json:"name" yaml:"name"`
+	AccountID           int      `json:"accountId" yaml:"accountId"`
+	EntityGUIDs         []string `json:"entityGuids,omitempty" yaml:"entityGuids,omitempty"`
+	EntitySearchQueries []string `json:"entitySearchQueries,omitempty" yaml:"entitySearchQueries,omitempty"`
+	ScopeAccountIDs     []int    `json:"scopeAccountIds,omitempty" yaml:"scopeAccountIds,omitempty"`
+}
+
+// readWorkloadDefinition reads and checks a workload definition file.
+func readWorkloadDefinition(path string) (*workloadDefinition, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var def workloadDefinition
+	if err := yaml.UnmarshalStrict(content, &def); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	if def.Name == "" {
+		return nil, fmt.Errorf("%s: no name given", path)
+	}
+
+	if def.AccountID == 0 {
+		return nil, fmt.Errorf("%s: no accountId given", path)
+	}
+
+	return &def, nil
+}
+
+// exportWorkload returns the definition of a workload, with its entities,
+// queries and scope accounts in order so definitions are stable.
+func exportWorkload(w *workloads.Workload) workloadDefinition {
+	def := workloadDefinition{
+		Name:                w.Name,
+		AccountID:           w.Account.ID,
+		EntityGUIDs:         []string{},
+		EntitySearchQueries: []string{},
+		ScopeAccountIDs:     append([]int{}, w.ScopeAccounts.AccountIDs...),
+	}
+
+	for _, e := range w.Entities {
+		def.EntityGUIDs = append(def.EntityGUIDs, e.GUID)
+	}
+
+	for _, q := range w.EntitySearchQueries {
+		def.EntitySearchQueries = append(def.EntitySearchQueries, q.Query)
+	}
+
+	sort.Strings(def.EntityGUIDs)
+	sort.Strings(def.EntitySearchQueries)
+	sort.Ints(def.ScopeAccountIDs)
+
+	return def
+}
+
+// workloadAccountID returns the ID of the account a workload belongs to,
+// which is part of its GUID.
+func workloadAccountID(guid string) (int, error) {
+	decoded, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(guid, "="))
+	if err != nil {
+		return 0, fmt.Errorf("invalid workload GUID %s: %s", guid, err)
+	}
+
+	parts := strings.Split(string(decoded), "|")
+	if len(parts) != 4 || parts[2] != "WORKLOAD" {
+		return 0, fmt.Errorf("%s is not the GUID of a workload", guid)
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid workload GUID %s: %s", guid, err)
+	}
+
+	return id, nil
+}
+
+// stringsDiff is the values added to and removed from a list.
+type stringsDiff struct {
+	Added   []string `json:"added,omitempty" yaml:"added,omitempty"`
+	Removed []string `json:"removed,omitempty" yaml:"removed,omitempty"`
+}
+
+func (d stringsDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+func diffStrings(current []string, desired []string) stringsDiff {
+	have := map[string]bool{}
+	for _, v := range current {
+		have[v] = true
+	}
+
+	want := map[string]bool{}
+	for _, v := range desired {
+		want[v] = true
+	}
+
+	var d stringsDiff
+
+	for _, v := range desired {
+		if !have[v] {
+			d.Added = append(d.Added, v)
+			have[v] = true
+		}
+	}
+
+	for _, v := range current {
+		if !want[v] {
+			d.Removed = append(d.Removed, v)
+			want[v] = true
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+
+	return d
+}
+
+func intsToStrings(values []int) []string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Itoa(v)
+	}
+
+	return s
+}
+
+// workloadChange is what applying a definition changes about a workload.
+type workloadChange struct {
+	Action              string      `json:"action" yaml:"action"`
+	Name                string      `json:"name" yaml:"name"`
+	AccountID           int         `json:"accountId" yaml:"accountId"`
+	GUID                string      `json:"guid,omitempty" yaml:"guid,omitempty"`
+	EntityGUIDs         stringsDiff `json:"entityGuids" yaml:"entityGuids"`
+	EntitySearchQueries stringsDiff `json:"entitySearchQueries" yaml:"entitySearchQueries"`
+	ScopeAccountIDs     stringsDiff `json:"scopeAccountIds" yaml:"scopeAccountIds"`
+
+	definition workloadDefinition
+	current    *workloads.Workload
+}
+
+// String renders the change as a diff.
+func (c workloadChange) String() string {
+	var b strings.Builder
+
+	switch c.Action {
+	case workloadActionCreate:
+		fmt.Fprintf(&b, "+ %s (new in account %d)\n", c.Name, c.AccountID)
+	case workloadActionUpdate:
+		fmt.Fprintf(&b, "~ %s (%s)\n", c.Name, c.GUID)
+	case workloadActionDelete:
+		fmt.Fprintf(&b, "- %s (%s)\n", c.Name, c.GUID)
+		return b.String()
+	default:
+		fmt.Fprintf(&b, "  %s (%s) is up to date\n", c.Name, c.GUID)
+		return b.String()
+	}
+
+	for _, f := range []struct {
+		name string
+		diff stringsDiff
+	}{
+		{"entityGuids", c.EntityGUIDs},
+		{"entitySearchQueries", c.EntitySearchQueries},
+		{"scopeAccountIds", c.ScopeAccountIDs},
+	} {
+		if f.diff.empty() {
+			continue
+		}
+
+		fmt.Fprintf(&b, "  %s:\n", f.name)
+
+		for _, v := range f.diff.Added {
+			fmt.Fprintf(&b, "    + %s\n", v)
+		}
+
+		for _, v := range f.diff.Removed {
+			fmt.Fprintf(&b, "    - %s\n", v)
+		}
+	}
+
+	return b.String()
+}
+
+// workloadsClient is the part of the workloads client used to find, create
+// and delete workloads.
+type workloadsClient interface {
+	ListWorkloads(int) ([]*workloads.Workload, error)
+	CreateWorkload(int, workloads.CreateInput) (*workloads.Workload, error)
+	DeleteWorkload(string) (*workloads.Workload, error)
+}
+
+// nerdGraphClient is the part of the NerdGraph client used to update
+// workloads.
+type nerdGraphClient interface {
+	QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error
+}
+
+// listWorkloads lists the workloads of an account, which may have none.
+func listWorkloads(client workloadsClient, accountID int) ([]*workloads.Workload, error) {
+	found, err := client.ListWorkloads(accountID)
+	if err != nil {
+		var notFound *nrErrors.NotFound
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// findWorkload returns the workload of the account with the name, or nil
+// when there is none.
+func findWorkload(existing []*workloads.Workload, name string) (*workloads.Workload, error) {
+	var found *workloads.Workload
+
+	for _, w := range existing {
+		if w.Name != name {
+			continue
+		}
+
+		if found != nil {
+			return nil, fmt.Errorf("more than one workload is named %s, workloads must have unique names to be applied", name)
+		}
+
+		found = w
+	}
+
+	return found, nil
+}
+
+// planWorkload compares a definition with the workload of the same name in
+// its account.
+func planWorkload(existing []*workloads.Workload, def workloadDefinition) (*workloadChange, error) {
+	current, err := findWorkload(existing, def.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &workloadChange{
+		Action:     workloadActionCreate,
+		Name:       def.Name,
+		AccountID:  def.AccountID,
+		definition: def,
+		current:    current,
+	}
+
+	if current == nil {
+		change.EntityGUIDs = diffStrings(nil, def.EntityGUIDs)
+		change.EntitySearchQueries = diffStrings(nil, def.EntitySearchQueries)
+		change.ScopeAccountIDs = diffStrings(nil, intsToStrings(def.ScopeAccountIDs))
+
+		return change, nil
+	}
+
+	change.GUID = current.GUID
+	exported := exportWorkload(current)
+
+	if def.EntityGUIDs != nil {
+		change.EntityGUIDs = diffStrings(exported.EntityGUIDs, def.EntityGUIDs)
+	}
+
+	if def.EntitySearchQueries != nil {
+		change.EntitySearchQueries = diffStrings(exported.EntitySearchQueries, def.EntitySearchQueries)
+	}
+
+	if def.ScopeAccountIDs != nil {
+		change.ScopeAccountIDs = diffStrings(intsToStrings(exported.ScopeAccountIDs), intsToStrings(def.ScopeAccountIDs))
+	}
+
+	change.Action = workloadActionNone
+	if !change.EntityGUIDs.empty() || !change.EntitySearchQueries.empty() || !change.ScopeAccountIDs.empty() {
+		change.Action = workloadActionUpdate
+	}
+
+	return change, nil
+}
+
+// applyWorkloadChange creates, updates or deletes the workload, returning its
+// GUID.
+// Nothing is done when the workload is up to date.
+func applyWorkloadChange(ctx context.Context, wlClient workloadsClient, ngClient nerdGraphClient, change *workloadChange) (string, error) {
+	def := change.definition
+
+	switch change.Action {
+	case workloadActionCreate:
+		input := workloads.CreateInput{
+			Name:        def.Name,
+			EntityGUIDs: def.EntityGUIDs,
+		}
+
+		for _, q := range def.EntitySearchQueries {
+			input.EntitySearchQueries = append(input.EntitySearchQueries, workloads.EntitySearchQueryInput{Query: q})
+		}
+
+		if len(def.ScopeAccountIDs) > 0 {
+			input.ScopeAccountsInput = &workloads.ScopeAccountsInput{AccountIDs: def.ScopeAccountIDs}
+		}
+
+		created, err := wlClient.CreateWorkload(def.AccountID, input)
+		if err != nil {
+			return "", err
+		}
+
+		return created.GUID, nil
+
+	case workloadActionUpdate:
+		vars := map[string]interface{}{
+			"guid":     change.GUID,
+			"workload": workloadUpdateInput(change.current, def),
+		}
+
+		var resp struct {
+			WorkloadUpdate struct {
+				GUID string `json:"guid"`
+			} `json:"workloadUpdate"`
+		}
+
+		if err := ngClient.QueryWithResponseAndContext(ctx, updateWorkloadMutation, vars, &resp); err != nil {
+			return "", err
+		}
+
+		return change.GUID, nil
+
+	case workloadActionDelete:
+		if _, err := wlClient.DeleteWorkload(change.GUID); err != nil {
+			return "", err
+		}
+	}
+
+	return change.GUID, nil
+}
+
+// workloadUpdateInput builds the input to update a workload to its
+// definition.  Unlike workloads.UpdateInput, empty lists are kept so every
+// value can be removed, and queries that are kept keep their IDs.
+func workloadUpdateInput(current *workloads.Workload, def workloadDefinition) map[string]interface{} {
+	input := map[string]interface{}{
+		"name": def.Name,
+	}
+
+	if def.EntityGUIDs != nil {
+		input["entityGuids"] = def.EntityGUIDs
+	}
+
+	if def.EntitySearchQueries != nil {
+		ids := map[string]int{}
+		for _, q := range current.EntitySearchQueries {
+			ids[q.Query] = q.ID
+		}
+
+		queries := []map[string]interface{}{}
+
+		for _, q := range def.EntitySearchQueries {
+			query := map[string]interface{}{"query": q}
+			if id, ok := ids[q]; ok {
+				query["id"] = id
+			}
+
+			queries = append(queries, query)
+		}
+
+		input["entitySearchQueries"] = queries
+	}
+
+	if def.ScopeAccountIDs != nil {
+		input["scopeAccounts"] = map[string]interface{}{"accountIds": def.ScopeAccountIDs}
+	}
+
+	return input
+}
+
+const updateWorkloadMutation = `mutation($guid: EntityGuid!, $workload: WorkloadUpdateInput!) {
+	workloadUpdate(guid: $guid, workload: $workload) {
+		guid
+	}
+}`
@@ -0,0 +1,230 @@
+// +build unit
+
+package workload
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nrErrors "github.com/newrelic/newrelic-client-go/pkg/errors"
+	"github.com/newrelic/newrelic-client-go/pkg/workloads"
+)
+
+type fakeWorkloads struct {
+	existing []*workloads.Workload
+	created  []workloads.CreateInput
+	deleted  []string
+}
+
+func (f *fakeWorkloads) ListWorkloads(accountID int) ([]*workloads.Workload, error) {
+	if len(f.existing) == 0 {
+		return nil, nrErrors.NewNotFound("")
+	}
+
+	return f.existing, nil
+}
+
+func (f *fakeWorkloads) CreateWorkload(accountID int, input workloads.CreateInput) (*workloads.Workload, error) {
+	f.created = append(f.created, input)
+
+	return &workloads.Workload{GUID: "NEW", Name: input.Name}, nil
+}
+
+func (f *fakeWorkloads) DeleteWorkload(guid string) (*workloads.Workload, error) {
+	f.deleted = append(f.deleted, guid)
+
+	return &workloads.Workload{GUID: guid}, nil
+}
+
+type fakeNerdGraph struct {
+	variables []map[string]interface{}
+}
+
+func (f *fakeNerdGraph) QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error {
+	f.variables = append(f.variables, variables)
+
+	return nil
+}
+
+func testWorkload() *workloads.Workload {
+	w := &workloads.Workload{
+		GUID:     "W1",
+		Name:     "Payments",
+		Entities: []workloads.EntityRef{{GUID: "G2"}, {GUID: "G1"}},
+		EntitySearchQueries: []workloads.EntitySearchQuery{
+			{ID: 7, Query: "tags.team = 'payments'"},
+			{ID: 8, Query: "name LIKE 'pay%'"},
+		},
+		ScopeAccounts: workloads.ScopeAccounts{AccountIDs: []int{2, 1}},
+	}
+	w.Account.ID = 1
+
+	return w
+}
+
+func TestReadWorkloadDefinition(t *testing.T) {
+	dir, err := ioutil.TempDir("", "newrelic")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "payments.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+name: Payments
+accountId: 1
+entityGuids: [G1, name=checkout]
+entitySearchQueries: []
+`), 0600))
+
+	def, err := readWorkloadDefinition(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Payments", def.Name)
+	assert.Equal(t, 1, def.AccountID)
+	assert.Equal(t, []string{"G1", "name=checkout"}, def.EntityGUIDs)
+	assert.NotNil(t, def.EntitySearchQueries)
+	assert.Nil(t, def.ScopeAccountIDs)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("name: Payments\n"), 0600))
+	_, err = readWorkloadDefinition(path)
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("name: Payments\naccountId: 1\nentities: []\n"), 0600))
+	_, err = readWorkloadDefinition(path)
+	assert.Error(t, err)
+}
+
+func TestExportWorkload(t *testing.T) {
+	def := exportWorkload(testWorkload())
+
+	assert.Equal(t, workloadDefinition{
+		Name:                "Payments",
+		AccountID:           1,
+		EntityGUIDs:         []string{"G1", "G2"},
+		EntitySearchQueries: []string{"name LIKE 'pay%'", "tags.team = 'payments'"},
+		ScopeAccountIDs:     []int{1, 2},
+	}, def)
+}
+
+func TestWorkloadAccountID(t *testing.T) {
+	id, err := workloadAccountID(base64.RawStdEncoding.EncodeToString([]byte("2520528|NR1|WORKLOAD|1283")))
+	require.NoError(t, err)
+	assert.Equal(t, 2520528, id)
+
+	_, err = workloadAccountID(base64.RawStdEncoding.EncodeToString([]byte("2520528|APM|APPLICATION|1283")))
+	assert.Error(t, err)
+
+	_, err = workloadAccountID("not a guid!")
+	assert.Error(t, err)
+}
+
+func TestPlanWorkloadCreate(t *testing.T) {
+	change, err := planWorkload(nil, workloadDefinition{
+		Name:                "Payments",
+		AccountID:           1,
+		EntityGUIDs:         []string{"G1"},
+		EntitySearchQueries: []string{"tags.team = 'payments'"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, workloadActionCreate, change.Action)
+	assert.Equal(t, []string{"G1"}, change.EntityGUIDs.Added)
+	assert.Equal(t, "+ Payments (new in account 1)\n  entityGuids:\n    + G1\n  entitySearchQueries:\n    + tags.team = 'payments'\n", change.String())
+
+	wl := &fakeWorkloads{}
+	guid, err := applyWorkloadChange(context.Background(), wl, &fakeNerdGraph{}, change)
+	require.NoError(t, err)
+	assert.Equal(t, "NEW", guid)
+	require.Len(t, wl.created, 1)
+	assert.Equal(t, []workloads.EntitySearchQueryInput{{Query: "tags.team = 'payments'"}}, wl.created[0].EntitySearchQueries)
+	assert.Nil(t, wl.created[0].ScopeAccountsInput)
+}
+
+func TestPlanWorkloadUpdate(t *testing.T) {
+	existing := []*workloads.Workload{testWorkload()}
+
+	change, err := planWorkload(existing, workloadDefinition{
+		Name:                "Payments",
+		AccountID:           1,
+		EntityGUIDs:         []string{"G1", "G3"},
+		EntitySearchQueries: []string{"tags.team = 'payments'"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, workloadActionUpdate, change.Action)
+	assert.Equal(t, "W1", change.GUID)
+	assert.Equal(t, stringsDiff{Added: []string{"G3"}, Removed: []string{"G2"}}, change.EntityGUIDs)
+	assert.Equal(t, stringsDiff{Removed: []string{"name LIKE 'pay%'"}}, change.EntitySearchQueries)
+	assert.True(t, change.ScopeAccountIDs.empty())
+
+	ng := &fakeNerdGraph{}
+	guid, err := applyWorkloadChange(context.Background(), &fakeWorkloads{}, ng, change)
+	require.NoError(t, err)
+	assert.Equal(t, "W1", guid)
+	require.Len(t, ng.variables, 1)
+	assert.Equal(t, map[string]interface{}{
+		"name":        "Payments",
+		"entityGuids": []string{"G1", "G3"},
+		"entitySearchQueries": []map[string]interface{}{
+			{"id": 7, "query": "tags.team = 'payments'"},
+		},
+	}, ng.variables[0]["workload"])
+}
+
+func TestPlanWorkloadUpToDate(t *testing.T) {
+	existing := []*workloads.Workload{testWorkload()}
+
+	change, err := planWorkload(existing, exportWorkload(testWorkload()))
+	require.NoError(t, err)
+	assert.Equal(t, workloadActionNone, change.Action)
+	assert.Equal(t, "  Payments (W1) is up to date\n", change.String())
+
+	ng := &fakeNerdGraph{}
+	_, err = applyWorkloadChange(context.Background(), &fakeWorkloads{}, ng, change)
+	require.NoError(t, err)
+	assert.Empty(t, ng.variables)
+
+	// Fields that are left out are not managed.
+	change, err = planWorkload(existing, workloadDefinition{Name: "Payments", AccountID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, workloadActionNone, change.Action)
+}
+
+func TestPlanWorkloadEmptyLists(t *testing.T) {
+	existing := []*workloads.Workload{testWorkload()}
+
+	change, err := planWorkload(existing, workloadDefinition{
+		Name:            "Payments",
+		AccountID:       1,
+		EntityGUIDs:     []string{},
+		ScopeAccountIDs: []int{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"G1", "G2"}, change.EntityGUIDs.Removed)
+	assert.Equal(t, []string{"1", "2"}, change.ScopeAccountIDs.Removed)
+
+	input := workloadUpdateInput(testWorkload(), change.definition)
+	assert.Equal(t, []string{}, input["entityGuids"])
+	assert.Equal(t, map[string]interface{}{"accountIds": []int{}}, input["scopeAccounts"])
+	assert.NotContains(t, input, "entitySearchQueries")
+}
+
+func TestFindWorkload(t *testing.T) {
+	w := testWorkload()
+
+	found, err := findWorkload([]*workloads.Workload{w}, "Orders")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	_, err = findWorkload([]*workloads.Workload{w, testWorkload()}, "Payments")
+	assert.Error(t, err)
+}
+
+func TestListWorkloadsNone(t *testing.T) {
+	found, err := listWorkloads(&fakeWorkloads{}, 1)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
@@ -0,0 +1,266 @@
+package workload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/newrelic-client-go/pkg/workloads"
+)
+
+const defaultNameTemplate = "{{.value}}"
+
+type tagValuesResponse struct {
+	Actor struct {
+		EntitySearch struct {
+			Results struct {
+				NextCursor *string `json:"nextCursor"`
+				Entities   []struct {
+					Tags []struct {
+						Key    string   `json:"key"`
+						Values []string `json:"values"`
+					} `json:"tags"`
+				} `json:"entities"`
+			} `json:"results"`
+		} `json:"entitySearch"`
+	} `json:"actor"`
+}
+
+// tagValues pages through the entities of an account and returns the
+// distinct values of the tag key, in order.
+func tagValues(ctx context.Context, client nerdGraphClient, accountID int, key string) ([]string, error) {
+	vars := map[string]interface{}{
+		"query": fmt.Sprintf("accountId = %d", accountID),
+	}
+
+	found := map[string]bool{}
+
+	for page := 1; ; page++ {
+		var resp tagValuesResponse
+		if err := client.QueryWithResponseAndContext(ctx, tagValuesQuery, vars, &resp); err != nil {
+			return nil, err
+		}
+
+		results := resp.Actor.EntitySearch.Results
+
+		for _, e := range results.Entities {
+			for _, t := range e.Tags {
+				if t.Key != key {
+					continue
+				}
+
+				for _, v := range t.Values {
+					found[v] = true
+				}
+			}
+		}
+
+		log.WithFields(log.Fields{
+			"page":   page,
+			"values": len(found),
+		}).Debug("received tag values")
+
+		if results.NextCursor == nil || *results.NextCursor == "" {
+			break
+		}
+
+		vars["cursor"] = *results.NextCursor
+	}
+
+	values := make([]string, 0, len(found))
+	for v := range found {
+		values = append(values, v)
+	}
+
+	sort.Strings(values)
+
+	return values, nil
+}
+
+var simpleTagKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// tagQuery returns the entity search query for the entities with the tag
+// value.  Keys other than simple words are quoted with backticks.
+func tagQuery(key string, value string) string {
+	if !simpleTagKey.MatchString(key) {
+		key = "`" + key + "`"
+	}
+
+	return fmt.Sprintf("tags.%s = '%s'", key, strings.ReplaceAll(value, "'", `\'`))
+}
+
+// workloadGenerator generates one workload per value of a tag, named from a
+// template given the tag key as .tag and its value as .value.
+type workloadGenerator struct {
+	AccountID    int
+	Tag          string
+	NameTemplate *template.Template
+}
+
+func newWorkloadGenerator(accountID int, tag string, nameTemplate string) (*workloadGenerator, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("no tag key given")
+	}
+
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name template: %s", err)
+	}
+
+	return &workloadGenerator{
+		AccountID:    accountID,
+		Tag:          tag,
+		NameTemplate: tmpl,
+	}, nil
+}
+
+func (g *workloadGenerator) name(value string) (string, error) {
+	var b bytes.Buffer
+
+	data := map[string]string{"tag": g.Tag, "value": value}
+	if err := g.NameTemplate.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid name template: %s", err)
+	}
+
+	name := strings.TrimSpace(b.String())
+	if name == "" {
+		return "", fmt.Errorf("the name template gives an empty name for %s", value)
+	}
+
+	return name, nil
+}
+
+// definitions returns the definition of the workload of each value.  The
+// workloads are made up only of the entities matching their query.
+func (g *workloadGenerator) definitions(values []string) ([]workloadDefinition, error) {
+	var defs []workloadDefinition
+
+	names := map[string]string{}
+
+	for _, v := range values {
+		name, err := g.name(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("the values %s and %s both give the workload name %s", other, v, name)
+		}
+
+		names[name] = v
+
+		defs = append(defs, workloadDefinition{
+			Name:                name,
+			AccountID:           g.AccountID,
+			EntityGUIDs:         []string{},
+			EntitySearchQueries: []string{tagQuery(g.Tag, v)},
+		})
+	}
+
+	return defs, nil
+}
+
+// generated returns the value a workload was generated for, if it was.  A
+// workload is taken to be generated when its only query is the query of a
+// value of the tag and it is named after that value.
+func (g *workloadGenerator) generated(w *workloads.Workload) (string, bool) {
+	if len(w.Entities) > 0 || len(w.EntitySearchQueries) != 1 {
+		return "", false
+	}
+
+	query := w.EntitySearchQueries[0].Query
+	prefix := strings.TrimSuffix(tagQuery(g.Tag, ""), "'")
+
+	if !strings.HasPrefix(query, prefix) || !strings.HasSuffix(query, "'") || len(query) <= len(prefix) {
+		return "", false
+	}
+
+	value := strings.ReplaceAll(query[len(prefix):len(query)-1], `\'`, "'")
+
+	name, err := g.name(value)
+	if err != nil || name != w.Name || tagQuery(g.Tag, value) != query {
+		return "", false
+	}
+
+	return value, true
+}
+
+// planGeneratedWorkloads plans the creation or update of the workload of
+// each value and, with prune, the deletion of the workloads generated for
+// values that no longer exist.
+func planGeneratedWorkloads(g *workloadGenerator, existing []*workloads.Workload, values []string, prune bool) ([]*workloadChange, error) {
+	defs, err := g.definitions(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*workloadChange
+
+	for _, def := range defs {
+		change, err := planWorkload(existing, def)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	if !prune {
+		return changes, nil
+	}
+
+	current := map[string]bool{}
+	for _, v := range values {
+		current[v] = true
+	}
+
+	for _, w := range existing {
+		value, ok := g.generated(w)
+		if !ok || current[value] {
+			continue
+		}
+
+		exported := exportWorkload(w)
+
+		changes = append(changes, &workloadChange{
+			Action:              workloadActionDelete,
+			Name:                w.Name,
+			AccountID:           g.AccountID,
+			GUID:                w.GUID,
+			EntitySearchQueries: diffStrings(exported.EntitySearchQueries, nil),
+			current:             w,
+		})
+	}
+
+	return changes, nil
+}
+
+// workloadChangesSummary summarizes changes as the number of workloads
+// created, updated and deleted.
+func workloadChangesSummary(changes []*workloadChange) string {
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Action]++
+	}
+
+	return fmt.Sprintf("%d to create, %d to update, %d to delete, %d up to date",
+		counts[workloadActionCreate], counts[workloadActionUpdate], counts[workloadActionDelete], counts[workloadActionNone])
+}
+
+const tagValuesQuery = `query($query: String, $cursor: String) { actor { entitySearch(query: $query) {
+	results(cursor: $cursor) {
+		nextCursor
+		entities {
+			tags {
+				key
+				values
+			}
+		}
+	}
+} } }`
@@ -0,0 +1,144 @@
+// +build unit
+
+package workload
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/newrelic-client-go/pkg/workloads"
+)
+
+type fakeTagSearch struct {
+	pages []string
+	vars  []map[string]interface{}
+}
+
+func (f *fakeTagSearch) QueryWithResponseAndContext(ctx context.Context, query string, variables map[string]interface{}, respBody interface{}) error {
+	vars := map[string]interface{}{}
+	for k, v := range variables {
+		vars[k] = v
+	}
+
+	f.vars = append(f.vars, vars)
+
+	page := f.pages[0]
+	f.pages = f.pages[1:]
+
+	return json.Unmarshal([]byte(page), respBody)
+}
+
+func generatedWorkload(guid string, name string, query string) *workloads.Workload {
+	return &workloads.Workload{
+		GUID:                guid,
+		Name:                name,
+		EntitySearchQueries: []workloads.EntitySearchQuery{{ID: 1, Query: query}},
+	}
+}
+
+func TestTagValues(t *testing.T) {
+	client := &fakeTagSearch{pages: []string{
+		`{"actor":{"entitySearch":{"results":{"nextCursor":"c1","entities":[
+			{"tags":[{"key":"team","values":["payments"]},{"key":"env","values":["production"]}]},
+			{"tags":[]}
+		]}}}}`,
+		`{"actor":{"entitySearch":{"results":{"nextCursor":null,"entities":[
+			{"tags":[{"key":"team","values":["orders","payments"]}]}
+		]}}}}`,
+	}}
+
+	values, err := tagValues(context.Background(), client, 1, "team")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "payments"}, values)
+
+	require.Len(t, client.vars, 2)
+	assert.Equal(t, "accountId = 1", client.vars[0]["query"])
+	assert.Equal(t, "c1", client.vars[1]["cursor"])
+}
+
+func TestTagQuery(t *testing.T) {
+	assert.Equal(t, "tags.team = 'payments'", tagQuery("team", "payments"))
+	assert.Equal(t, "tags.`app.team` = 'o\\'reilly'", tagQuery("app.team", "o'reilly"))
+}
+
+func TestWorkloadGeneratorDefinitions(t *testing.T) {
+	g, err := newWorkloadGenerator(1, "team", "{{.value}} services")
+	require.NoError(t, err)
+
+	defs, err := g.definitions([]string{"orders", "payments"})
+	require.NoError(t, err)
+	assert.Equal(t, []workloadDefinition{
+		{Name: "orders services", AccountID: 1, EntityGUIDs: []string{}, EntitySearchQueries: []string{"tags.team = 'orders'"}},
+		{Name: "payments services", AccountID: 1, EntityGUIDs: []string{}, EntitySearchQueries: []string{"tags.team = 'payments'"}},
+	}, defs)
+
+	g, err = newWorkloadGenerator(1, "team", "all services")
+	require.NoError(t, err)
+
+	_, err = g.definitions([]string{"orders", "payments"})
+	assert.Error(t, err)
+
+	_, err = newWorkloadGenerator(1, "team", "{{.value")
+	assert.Error(t, err)
+
+	_, err = newWorkloadGenerator(1, "", defaultNameTemplate)
+	assert.Error(t, err)
+}
+
+func TestWorkloadGeneratorGenerated(t *testing.T) {
+	g, err := newWorkloadGenerator(1, "team", "{{.value}} services")
+	require.NoError(t, err)
+
+	value, ok := g.generated(generatedWorkload("W1", "o'reilly services", `tags.team = 'o\'reilly'`))
+	assert.True(t, ok)
+	assert.Equal(t, "o'reilly", value)
+
+	_, ok = g.generated(generatedWorkload("W2", "Checkout", "tags.team = 'orders'"))
+	assert.False(t, ok)
+
+	_, ok = g.generated(generatedWorkload("W3", "orders services", "tags.team = 'orders' OR name = 'x'"))
+	assert.False(t, ok)
+
+	_, ok = g.generated(generatedWorkload("W4", "orders services", "tags.env = 'orders'"))
+	assert.False(t, ok)
+
+	w := generatedWorkload("W5", "orders services", "tags.team = 'orders'")
+	w.Entities = []workloads.EntityRef{{GUID: "G1"}}
+	_, ok = g.generated(w)
+	assert.False(t, ok)
+}
+
+func TestPlanGeneratedWorkloads(t *testing.T) {
+	g, err := newWorkloadGenerator(1, "team", "{{.value}} services")
+	require.NoError(t, err)
+
+	existing := []*workloads.Workload{
+		generatedWorkload("W1", "payments services", "tags.team = 'payments'"),
+		generatedWorkload("W2", "billing services", "tags.team = 'billing'"),
+		generatedWorkload("W3", "Checkout", "tags.team = 'checkout'"),
+	}
+
+	changes, err := planGeneratedWorkloads(g, existing, []string{"orders", "payments"}, false)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, workloadActionCreate, changes[0].Action)
+	assert.Equal(t, "orders services", changes[0].Name)
+	assert.Equal(t, workloadActionNone, changes[1].Action)
+	assert.Equal(t, "1 to create, 0 to update, 0 to delete, 1 up to date", workloadChangesSummary(changes))
+
+	changes, err = planGeneratedWorkloads(g, existing, []string{"orders", "payments"}, true)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, workloadActionDelete, changes[2].Action)
+	assert.Equal(t, "W2", changes[2].GUID)
+	assert.Equal(t, "- billing services (W2)\n", changes[2].String())
+
+	wl := &fakeWorkloads{}
+	_, err = applyWorkloadChange(context.Background(), wl, &fakeNerdGraph{}, changes[2])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"W2"}, wl.deleted)
+}